@@ -1,44 +1,97 @@
-package main
-
-import (
-	"log"
-	"os"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/routes"
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-)
-
-func main() {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-
-	if err != nil {
-		log.Fatalf("Error: Problem while loading environment variables. \n\t Error: %s", err)
-	}
-
-	port := os.Getenv("PORT")
-
-	if port == "" {
-		port = "8000"
-	}
-
-	gin.DefaultWriter = logger.Log.Writer()
-
-	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(middleware.ExternalRateLimiter())
-
-	routes.AuthRoutes(router)
-	routes.NotesRoutes(router)
-
-	logger.Log.Printf("Message: Running the server at port: %s", port)
-	router.Run(":" + port)
-
-	defer func() {
-		database.MongoObject.Client.Disconnect(database.MongoObject.Ctx)
-		database.MongoObject.Cancel()
-	}()
-}
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/routes"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/searchindex"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	// Load the configuration from the flags, the environment and the optional config file.
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Error: Problem while loading the configuration. \n\t Error: %s", err)
+	}
+
+	err = logger.Init(cfg.Log.File)
+	if err != nil {
+		log.Fatalf("Error: Problems while opening log file.\n\tError: %s", err)
+	}
+
+	helper.SetAuthConfig(cfg.Auth)
+	err = helper.LoadSigningKeys()
+	if err != nil {
+		log.Fatalf("Error: Problem while loading the signing keys. \n\t Error: %s", err)
+	}
+
+	helper.SetTrashConfig(cfg.Trash)
+	helper.SetVerificationConfig(cfg.Verification)
+	helper.SetMailConfig(cfg.Mail)
+	helper.SetLockoutConfig(cfg.Lockout)
+	helper.SetAdminConfig(cfg.Admin)
+	helper.SetWorkspaceConfig(cfg.Workspace)
+	middleware.SetRateLimitConfig(cfg.Rate_Limit)
+	middleware.SetAdminConfig(cfg.Admin)
+
+	// Open the configured mailer.
+	_, err = mailer.Open(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Error: Problem while opening the mailer. \n\t Error: %s", err)
+	}
+
+	// Open the configured store.
+	store, err := database.OpenStore(cfg)
+	if err != nil {
+		log.Fatalf("Error: Problem while opening the store. \n\t Error: %s", err)
+	}
+
+	defer store.Close(context.Background())
+
+	// Search through the embedded index if configured, the store keeping it in sync with every change.
+	if cfg.Search.Engine == config.BleveSearchEngine {
+		index, err := searchindex.Open(context.Background(), cfg.Search, store)
+		if err != nil {
+			log.Fatalf("Error: Problem while opening the search index. \n\t Error: %s", err)
+		}
+
+		defer index.Close()
+
+		searchindex.IndexObject = index
+		database.StoreObject = searchindex.NewIndexedStore(store, index)
+	}
+
+	// Make admins of the verified users with the configured admin emails.
+	err = helper.PromoteConfiguredAdmins(context.Background())
+	if err != nil {
+		log.Fatalf("Error: Problem while promoting the configured admins. \n\t Error: %s", err)
+	}
+
+	// Empty the trash of the notes kept longer than the retention.
+	helper.StartTrashPurge(context.Background())
+
+	gin.DefaultWriter = logger.Log.Writer()
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(middleware.ExternalRateLimiter())
+
+	// Admin, workspace and share link routes go before the notes routes, which authenticate every route added after them.
+	routes.AuthRoutes(router)
+	routes.AdminRoutes(router)
+	routes.WorkspaceRoutes(router)
+	routes.ShareLinkRoutes(router)
+	routes.NotesRoutes(router)
+
+	logger.Log.Printf("Message: Running the server at port: %s", cfg.Port)
+	router.Run(":" + cfg.Port)
+}
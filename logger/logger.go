@@ -1,18 +1,31 @@
-package logger
-
-import (
-	"log"
-	"os"
-)
-
-var Log *log.Logger
-
-func init() {
-	file, err := os.OpenFile("C:\\Users\\User\\Desktop\\GoLang\\Project\\app.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-
-	if err != nil {
-		log.Fatalf("Error: Problems while opening log file.\n\tError: %s", err)
-	}
-
-	Log = log.New(file, "", log.Ldate|log.Ltime|log.Lshortfile)
-}
+package logger
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// Logs to stderr till Init has been called with the configured destination.
+var Log = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
+
+// Sends the log to the given file, or to stdout or stderr.
+func Init(destination string) error {
+	var writer io.Writer
+
+	switch destination {
+	case "stdout":
+		writer = os.Stdout
+	case "stderr":
+		writer = os.Stderr
+	default:
+		file, err := os.OpenFile(destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+		if err != nil {
+			return err
+		}
+		writer = file
+	}
+
+	Log.SetOutput(writer)
+	return nil
+}
@@ -0,0 +1,677 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the storage backends, kept in sync with the ones of the database package.
+const (
+	MongoBackend  = "mongo"
+	MemoryBackend = "memory"
+	SQLiteBackend = "sqlite"
+)
+
+// Names of the search engines, the storage backend itself or the embedded Bleve index.
+const (
+	StoreSearchEngine = "store"
+	BleveSearchEngine = "bleve"
+)
+
+// Languages the Bleve index can analyze text in, standard only splitting and lower casing words.
+var SearchLanguages = []string{"standard", "de", "en", "es", "fr", "it", "nl", "pt"}
+
+type MongoConfig struct {
+	URI                        string `yaml:"uri" toml:"uri"`
+	Database_Name              string `yaml:"databaseName" toml:"databaseName"`
+	Users_Collection           string `yaml:"usersCollection" toml:"usersCollection"`
+	Notes_Collection           string `yaml:"notesCollection" toml:"notesCollection"`
+	Revoked_Tokens_Collection  string `yaml:"revokedTokensCollection" toml:"revokedTokensCollection"`
+	Note_Revisions_Collection  string `yaml:"noteRevisionsCollection" toml:"noteRevisionsCollection"`
+	Notebooks_Collection       string `yaml:"notebooksCollection" toml:"notebooksCollection"`
+	Sessions_Collection        string `yaml:"sessionsCollection" toml:"sessionsCollection"`
+	Password_Resets_Collection string `yaml:"passwordResetsCollection" toml:"passwordResetsCollection"`
+	Recovery_Codes_Collection  string `yaml:"recoveryCodesCollection" toml:"recoveryCodesCollection"`
+	Access_Tokens_Collection   string `yaml:"accessTokensCollection" toml:"accessTokensCollection"`
+	Login_Failures_Collection  string `yaml:"loginFailuresCollection" toml:"loginFailuresCollection"`
+	Lockouts_Collection        string `yaml:"lockoutsCollection" toml:"lockoutsCollection"`
+	Workspaces_Collection      string `yaml:"workspacesCollection" toml:"workspacesCollection"`
+	Invitations_Collection     string `yaml:"invitationsCollection" toml:"invitationsCollection"`
+	Share_Links_Collection     string `yaml:"shareLinksCollection" toml:"shareLinksCollection"`
+}
+
+type SQLiteConfig struct {
+	Path string `yaml:"path" toml:"path"`
+}
+
+// Algorithms the signing keys can sign tokens with.
+const (
+	RS256Algorithm = "RS256"
+	EdDSAAlgorithm = "EdDSA"
+)
+
+// Key signing and verifying the tokens, its id is the kid of the tokens it signs.
+// Rotating keys means adding the new key with a later sign from time, and keeping the old one
+// till the tokens it signed have expired.
+type SigningKeyConfig struct {
+	Id string `yaml:"id" toml:"id"`
+	// RS256 or EdDSA.
+	Algorithm string `yaml:"algorithm" toml:"algorithm"`
+	// PEM file of the private key, or of the public key alone for a key which only verifies the tokens it signed before.
+	Key_File string `yaml:"keyFile" toml:"keyFile"`
+	// The key which started signing last signs the tokens, a key starting later is already published for verifiers.
+	// Zero for a key signing from the start.
+	Sign_From time.Time `yaml:"signFrom" toml:"signFrom"`
+	// Tokens of the key are valid till this time, zero for as long as the key is configured.
+	Verify_Until time.Time `yaml:"verifyUntil" toml:"verifyUntil"`
+}
+
+type AuthConfig struct {
+	// Secret of the HS256 tokens, which are signed only when there are no signing keys,
+	// and verified for as long as it is set.
+	Secret_Key             string             `yaml:"secretKey" toml:"secretKey"`
+	Signing_Keys           []SigningKeyConfig `yaml:"signingKeys" toml:"signingKeys"`
+	Access_Token_Lifetime  time.Duration      `yaml:"accessTokenLifetime" toml:"accessTokenLifetime"`
+	Refresh_Token_Lifetime time.Duration      `yaml:"refreshTokenLifetime" toml:"refreshTokenLifetime"`
+	// Issuer and audience the tokens are issued with, tokens naming others are rejected.
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// Clock skew allowed when checking the times of the tokens.
+	Leeway time.Duration `yaml:"leeway" toml:"leeway"`
+	// How long the token mailed to reset a forgotten password can be used.
+	Password_Reset_Lifetime time.Duration `yaml:"passwordResetLifetime" toml:"passwordResetLifetime"`
+	// How long the challenge token of a login, which still needs the second factor, can be used.
+	MFA_Challenge_Lifetime time.Duration `yaml:"mfaChallengeLifetime" toml:"mfaChallengeLifetime"`
+	// Name the authenticator apps show the TOTP accounts under.
+	TOTP_Issuer string `yaml:"totpIssuer" toml:"totpIssuer"`
+}
+
+// Rates are in requests per second, bursts in requests.
+type RateLimitConfig struct {
+	Global_Rate  float64 `yaml:"globalRate" toml:"globalRate"`
+	Global_Burst int     `yaml:"globalBurst" toml:"globalBurst"`
+	User_Rate    float64 `yaml:"userRate" toml:"userRate"`
+	User_Burst   int     `yaml:"userBurst" toml:"userBurst"`
+}
+
+type SearchConfig struct {
+	Engine string `yaml:"engine" toml:"engine"`
+	// Directory of the Bleve index.
+	Index_Path string `yaml:"indexPath" toml:"indexPath"`
+	Language   string `yaml:"language" toml:"language"`
+	// Number of typos a word may have and still match, from 0 to 2.
+	Fuzziness int `yaml:"fuzziness" toml:"fuzziness"`
+}
+
+// Failed logins are counted per account and per ip address. Every failure makes the next attempt wait twice
+// as long, from the base delay up to the max delay, and reaching the threshold locks the login out.
+type LockoutConfig struct {
+	Account_Threshold int           `yaml:"accountThreshold" toml:"accountThreshold"`
+	IP_Threshold      int           `yaml:"ipThreshold" toml:"ipThreshold"`
+	Base_Delay        time.Duration `yaml:"baseDelay" toml:"baseDelay"`
+	Max_Delay         time.Duration `yaml:"maxDelay" toml:"maxDelay"`
+	// How long a login stays locked out once the threshold is reached.
+	Duration time.Duration `yaml:"duration" toml:"duration"`
+	// Failures older than this are forgotten.
+	Failure_Window time.Duration `yaml:"failureWindow" toml:"failureWindow"`
+}
+
+type TrashConfig struct {
+	// How long deleted notes stay in the trash before they are purged.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+	// How often the trash is checked for notes to purge.
+	Purge_Interval time.Duration `yaml:"purgeInterval" toml:"purgeInterval"`
+}
+
+// Actions users who have not verified their email can be kept from.
+const (
+	LoginAction  = "login"  // logging in, signing up then returns no tokens
+	CreateAction = "create" // creating notes and notebooks
+	ShareAction  = "share"  // sharing notes and changing who they are shared with
+)
+
+var UnverifiedActions = []string{LoginAction, CreateAction, ShareAction}
+
+type VerificationConfig struct {
+	// How long the token mailed to verify an email can be used.
+	Lifetime time.Duration `yaml:"lifetime" toml:"lifetime"`
+	// Shortest time between two verification mails to the same user.
+	Resend_Interval time.Duration `yaml:"resendInterval" toml:"resendInterval"`
+	// Actions users are kept from till they have verified their email.
+	Unverified_Restrictions []string `yaml:"unverifiedRestrictions" toml:"unverifiedRestrictions"`
+}
+
+// Names of the mailers, writing the mails to files or sending them over SMTP.
+const (
+	FileMailer = "file"
+	SMTPMailer = "smtp"
+)
+
+type MailConfig struct {
+	// file or smtp.
+	Mailer string `yaml:"mailer" toml:"mailer"`
+	// Address the mails are sent from.
+	From string `yaml:"from" toml:"from"`
+	// Directory the file mailer writes the mails to, one file each.
+	Drop_Directory string `yaml:"dropDirectory" toml:"dropDirectory"`
+	SMTP_Host      string `yaml:"smtpHost" toml:"smtpHost"`
+	SMTP_Port      string `yaml:"smtpPort" toml:"smtpPort"`
+	// Address of the API the links in the mails point to.
+	Link_Base_URL string `yaml:"linkBaseUrl" toml:"linkBaseUrl"`
+	// Without a username the SMTP server is used without authentication.
+	SMTP_Username string `yaml:"smtpUsername" toml:"smtpUsername"`
+	SMTP_Password string `yaml:"smtpPassword" toml:"smtpPassword"`
+}
+
+type WorkspaceConfig struct {
+	// How long the token mailed to invite someone into a workspace can be used.
+	Invitation_Lifetime time.Duration `yaml:"invitationLifetime" toml:"invitationLifetime"`
+}
+
+type AdminConfig struct {
+	// Key to send in the X-Admin-Key header of the admin endpoints instead of the token of an admin, not accepted when empty.
+	Key string `yaml:"key" toml:"key"`
+	// Users with these emails are made admins once they have verified them, at startup or when verifying.
+	Emails []string `yaml:"emails" toml:"emails"`
+}
+
+type LogConfig struct {
+	// Path of the log file, or stdout or stderr.
+	File string `yaml:"file" toml:"file"`
+}
+
+type Config struct {
+	Port            string             `yaml:"port" toml:"port"`
+	Storage_Backend string             `yaml:"storageBackend" toml:"storageBackend"`
+	Mongo           MongoConfig        `yaml:"mongo" toml:"mongo"`
+	SQLite          SQLiteConfig       `yaml:"sqlite" toml:"sqlite"`
+	Auth            AuthConfig         `yaml:"auth" toml:"auth"`
+	Rate_Limit      RateLimitConfig    `yaml:"rateLimit" toml:"rateLimit"`
+	Lockout         LockoutConfig      `yaml:"lockout" toml:"lockout"`
+	Search          SearchConfig       `yaml:"search" toml:"search"`
+	Trash           TrashConfig        `yaml:"trash" toml:"trash"`
+	Verification    VerificationConfig `yaml:"verification" toml:"verification"`
+	Mail            MailConfig         `yaml:"mail" toml:"mail"`
+	Workspace       WorkspaceConfig    `yaml:"workspace" toml:"workspace"`
+	Admin           AdminConfig        `yaml:"admin" toml:"admin"`
+	Log             LogConfig          `yaml:"log" toml:"log"`
+}
+
+// Configuration used when nothing else is given.
+func Default() *Config {
+	return &Config{
+		Port:            "8000",
+		Storage_Backend: MongoBackend,
+		Mongo: MongoConfig{
+			Users_Collection:           "users",
+			Notes_Collection:           "notes",
+			Revoked_Tokens_Collection:  "revokedTokens",
+			Note_Revisions_Collection:  "noteRevisions",
+			Notebooks_Collection:       "notebooks",
+			Sessions_Collection:        "sessions",
+			Password_Resets_Collection: "passwordResets",
+			Recovery_Codes_Collection:  "recoveryCodes",
+			Access_Tokens_Collection:   "accessTokens",
+			Login_Failures_Collection:  "loginFailures",
+			Lockouts_Collection:        "lockouts",
+			Workspaces_Collection:      "workspaces",
+			Invitations_Collection:     "invitations",
+			Share_Links_Collection:     "shareLinks",
+		},
+		SQLite: SQLiteConfig{
+			Path: "notes.db",
+		},
+		Auth: AuthConfig{
+			Access_Token_Lifetime:   24 * time.Hour,
+			Refresh_Token_Lifetime:  168 * time.Hour,
+			Issuer:                  "note-sharing-api",
+			Audience:                "note-sharing-api",
+			Leeway:                  30 * time.Second,
+			Password_Reset_Lifetime: time.Hour,
+			MFA_Challenge_Lifetime:  5 * time.Minute,
+			TOTP_Issuer:             "Note Sharing",
+		},
+		Rate_Limit: RateLimitConfig{
+			Global_Rate:  1,
+			Global_Burst: 5,
+			User_Rate:    1,
+			User_Burst:   5,
+		},
+		Lockout: LockoutConfig{
+			Account_Threshold: 5,
+			IP_Threshold:      20,
+			Base_Delay:        time.Second,
+			Max_Delay:         time.Minute,
+			Duration:          15 * time.Minute,
+			Failure_Window:    time.Hour,
+		},
+		Search: SearchConfig{
+			Engine:     StoreSearchEngine,
+			Index_Path: "notes.bleve",
+			Language:   "en",
+			Fuzziness:  1,
+		},
+		Trash: TrashConfig{
+			Retention:      720 * time.Hour,
+			Purge_Interval: time.Hour,
+		},
+		Verification: VerificationConfig{
+			Lifetime:                24 * time.Hour,
+			Resend_Interval:         5 * time.Minute,
+			Unverified_Restrictions: []string{ShareAction},
+		},
+		Mail: MailConfig{
+			Mailer:         FileMailer,
+			From:           "no-reply@localhost",
+			Drop_Directory: "mail",
+			Link_Base_URL:  "http://localhost:8000",
+			SMTP_Port:      "587",
+		},
+		Workspace: WorkspaceConfig{
+			Invitation_Lifetime: 168 * time.Hour,
+		},
+		Log: LogConfig{
+			File: "app.log",
+		},
+	}
+}
+
+// Loads the configuration once at startup, later sources overriding earlier ones:
+// the defaults, the optional YAML or TOML file, the environment and the command line flags.
+// The file is given with -config or CONFIG_FILE.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	flagSet := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path of a YAML or TOML configuration file")
+	port := flagSet.String("port", "", "port to listen on")
+	storageBackend := flagSet.String("storage", "", "storage backend: mongo, sqlite or memory")
+	mongoURI := flagSet.String("mongo-uri", "", "MongoDB connection string")
+	sqlitePath := flagSet.String("sqlite-path", "", "path of the SQLite database file")
+	logFile := flagSet.String("log-file", "", "path of the log file, or stdout or stderr")
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		err = loadFile(cfg, *configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = loadEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the flags actually given override the other sources.
+	flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = *port
+		case "storage":
+			cfg.Storage_Backend = *storageBackend
+		case "mongo-uri":
+			cfg.Mongo.URI = *mongoURI
+		case "sqlite-path":
+			cfg.SQLite.Path = *sqlitePath
+		case "log-file":
+			cfg.Log.File = *logFile
+		}
+	})
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		_, err = toml.Decode(string(content), cfg)
+	default:
+		return fmt.Errorf("config file %s is neither YAML nor TOML", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	stringFields := map[string]*string{
+		"PORT":                       &cfg.Port,
+		"STORAGE_BACKEND":            &cfg.Storage_Backend,
+		"MONGODB_URI":                &cfg.Mongo.URI,
+		"MONGODB_DATABASE_NAME":      &cfg.Mongo.Database_Name,
+		"USERS_COLLECTION":           &cfg.Mongo.Users_Collection,
+		"NOTES_COLLECTION":           &cfg.Mongo.Notes_Collection,
+		"REVOKED_TOKENS_COLLECTION":  &cfg.Mongo.Revoked_Tokens_Collection,
+		"NOTE_REVISIONS_COLLECTION":  &cfg.Mongo.Note_Revisions_Collection,
+		"NOTEBOOKS_COLLECTION":       &cfg.Mongo.Notebooks_Collection,
+		"SESSIONS_COLLECTION":        &cfg.Mongo.Sessions_Collection,
+		"PASSWORD_RESETS_COLLECTION": &cfg.Mongo.Password_Resets_Collection,
+		"RECOVERY_CODES_COLLECTION":  &cfg.Mongo.Recovery_Codes_Collection,
+		"ACCESS_TOKENS_COLLECTION":   &cfg.Mongo.Access_Tokens_Collection,
+		"LOGIN_FAILURES_COLLECTION":  &cfg.Mongo.Login_Failures_Collection,
+		"LOCKOUTS_COLLECTION":        &cfg.Mongo.Lockouts_Collection,
+		"WORKSPACES_COLLECTION":      &cfg.Mongo.Workspaces_Collection,
+		"INVITATIONS_COLLECTION":     &cfg.Mongo.Invitations_Collection,
+		"SHARE_LINKS_COLLECTION":     &cfg.Mongo.Share_Links_Collection,
+		"SQLITE_PATH":                &cfg.SQLite.Path,
+		"SECRET_KEY":                 &cfg.Auth.Secret_Key,
+		"TOKEN_ISSUER":               &cfg.Auth.Issuer,
+		"TOKEN_AUDIENCE":             &cfg.Auth.Audience,
+		"TOTP_ISSUER":                &cfg.Auth.TOTP_Issuer,
+		"SEARCH_ENGINE":              &cfg.Search.Engine,
+		"SEARCH_INDEX_PATH":          &cfg.Search.Index_Path,
+		"SEARCH_LANGUAGE":            &cfg.Search.Language,
+		"MAILER":                     &cfg.Mail.Mailer,
+		"MAIL_FROM":                  &cfg.Mail.From,
+		"MAIL_DROP_DIRECTORY":        &cfg.Mail.Drop_Directory,
+		"MAIL_LINK_BASE_URL":         &cfg.Mail.Link_Base_URL,
+		"SMTP_HOST":                  &cfg.Mail.SMTP_Host,
+		"SMTP_PORT":                  &cfg.Mail.SMTP_Port,
+		"SMTP_USERNAME":              &cfg.Mail.SMTP_Username,
+		"SMTP_PASSWORD":              &cfg.Mail.SMTP_Password,
+		"ADMIN_KEY":                  &cfg.Admin.Key,
+		"LOG_FILE":                   &cfg.Log.File,
+	}
+
+	// Empty variables count as unset, like they did with the .env file.
+	for name, target := range stringFields {
+		if value := os.Getenv(name); value != "" {
+			*target = value
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"ACCESS_TOKEN_LIFETIME":         &cfg.Auth.Access_Token_Lifetime,
+		"REFRESH_TOKEN_LIFETIME":        &cfg.Auth.Refresh_Token_Lifetime,
+		"TOKEN_LEEWAY":                  &cfg.Auth.Leeway,
+		"PASSWORD_RESET_LIFETIME":       &cfg.Auth.Password_Reset_Lifetime,
+		"MFA_CHALLENGE_LIFETIME":        &cfg.Auth.MFA_Challenge_Lifetime,
+		"VERIFICATION_LIFETIME":         &cfg.Verification.Lifetime,
+		"VERIFICATION_RESEND_INTERVAL":  &cfg.Verification.Resend_Interval,
+		"LOCKOUT_BASE_DELAY":            &cfg.Lockout.Base_Delay,
+		"LOCKOUT_MAX_DELAY":             &cfg.Lockout.Max_Delay,
+		"LOCKOUT_DURATION":              &cfg.Lockout.Duration,
+		"LOCKOUT_FAILURE_WINDOW":        &cfg.Lockout.Failure_Window,
+		"TRASH_RETENTION":               &cfg.Trash.Retention,
+		"TRASH_PURGE_INTERVAL":          &cfg.Trash.Purge_Interval,
+		"WORKSPACE_INVITATION_LIFETIME": &cfg.Workspace.Invitation_Lifetime,
+	}
+
+	for name, target := range durations {
+		if value := os.Getenv(name); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			*target = duration
+		}
+	}
+
+	rates := map[string]*float64{
+		"GLOBAL_RATE_LIMIT": &cfg.Rate_Limit.Global_Rate,
+		"USER_RATE_LIMIT":   &cfg.Rate_Limit.User_Rate,
+	}
+
+	for name, target := range rates {
+		if value := os.Getenv(name); value != "" {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			*target = rate
+		}
+	}
+
+	lists := map[string]*[]string{
+		"UNVERIFIED_RESTRICTIONS": &cfg.Verification.Unverified_Restrictions,
+		"ADMIN_EMAILS":            &cfg.Admin.Emails,
+	}
+
+	// Lists are comma separated, a single comma leaves a list empty.
+	for name, target := range lists {
+		if value := os.Getenv(name); value != "" {
+			list := []string{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			*target = list
+		}
+	}
+
+	integers := map[string]*int{
+		"GLOBAL_RATE_BURST":         &cfg.Rate_Limit.Global_Burst,
+		"USER_RATE_BURST":           &cfg.Rate_Limit.User_Burst,
+		"SEARCH_FUZZINESS":          &cfg.Search.Fuzziness,
+		"LOCKOUT_ACCOUNT_THRESHOLD": &cfg.Lockout.Account_Threshold,
+		"LOCKOUT_IP_THRESHOLD":      &cfg.Lockout.IP_Threshold,
+	}
+
+	for name, target := range integers {
+		if value := os.Getenv(name); value != "" {
+			integer, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			*target = integer
+		}
+	}
+
+	return nil
+}
+
+// Checks the configuration, reporting every problem at once.
+func (cfg *Config) Validate() error {
+	var problems []error
+
+	port, err := strconv.Atoi(cfg.Port)
+	if err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Errorf("port %q is not a valid port number", cfg.Port))
+	}
+
+	switch cfg.Storage_Backend {
+	case MongoBackend:
+		if cfg.Mongo.URI == "" {
+			problems = append(problems, errors.New("mongo uri is required for the mongo storage backend"))
+		}
+		if cfg.Mongo.Database_Name == "" {
+			problems = append(problems, errors.New("mongo database name is required for the mongo storage backend"))
+		}
+		if cfg.Mongo.Users_Collection == "" || cfg.Mongo.Notes_Collection == "" || cfg.Mongo.Revoked_Tokens_Collection == "" || cfg.Mongo.Note_Revisions_Collection == "" ||
+			cfg.Mongo.Notebooks_Collection == "" || cfg.Mongo.Sessions_Collection == "" || cfg.Mongo.Password_Resets_Collection == "" ||
+			cfg.Mongo.Recovery_Codes_Collection == "" || cfg.Mongo.Access_Tokens_Collection == "" || cfg.Mongo.Login_Failures_Collection == "" || cfg.Mongo.Lockouts_Collection == "" ||
+			cfg.Mongo.Workspaces_Collection == "" || cfg.Mongo.Invitations_Collection == "" || cfg.Mongo.Share_Links_Collection == "" {
+			problems = append(problems, errors.New("mongo collection names must not be empty"))
+		}
+	case SQLiteBackend:
+		if cfg.SQLite.Path == "" {
+			problems = append(problems, errors.New("sqlite path is required for the sqlite storage backend"))
+		}
+	case MemoryBackend:
+	default:
+		problems = append(problems, fmt.Errorf("unknown storage backend %q", cfg.Storage_Backend))
+	}
+
+	if cfg.Auth.Secret_Key == "" && len(cfg.Auth.Signing_Keys) == 0 {
+		problems = append(problems, errors.New("secret key or signing keys are required"))
+	}
+
+	keyIds := map[string]bool{}
+	for _, key := range cfg.Auth.Signing_Keys {
+		if key.Id == "" || keyIds[key.Id] {
+			problems = append(problems, fmt.Errorf("signing key id %q is empty or not unique", key.Id))
+		}
+		keyIds[key.Id] = true
+
+		if key.Algorithm != RS256Algorithm && key.Algorithm != EdDSAAlgorithm {
+			problems = append(problems, fmt.Errorf("signing key %q has unknown algorithm %q", key.Id, key.Algorithm))
+		}
+		if key.Key_File == "" {
+			problems = append(problems, fmt.Errorf("signing key %q has no key file", key.Id))
+		}
+		if !key.Verify_Until.IsZero() && !key.Verify_Until.After(key.Sign_From) {
+			problems = append(problems, fmt.Errorf("signing key %q stops verifying before it starts signing", key.Id))
+		}
+	}
+
+	if cfg.Auth.Access_Token_Lifetime <= 0 {
+		problems = append(problems, errors.New("access token lifetime must be positive"))
+	}
+
+	if cfg.Auth.Refresh_Token_Lifetime < cfg.Auth.Access_Token_Lifetime {
+		problems = append(problems, errors.New("refresh token lifetime must not be shorter than the access token lifetime"))
+	}
+
+	if cfg.Auth.Issuer == "" || cfg.Auth.Audience == "" {
+		problems = append(problems, errors.New("token issuer and audience are required"))
+	}
+
+	if cfg.Auth.Leeway < 0 {
+		problems = append(problems, errors.New("token leeway must not be negative"))
+	}
+
+	if cfg.Auth.Password_Reset_Lifetime <= 0 {
+		problems = append(problems, errors.New("password reset lifetime must be positive"))
+	}
+
+	if cfg.Auth.MFA_Challenge_Lifetime <= 0 {
+		problems = append(problems, errors.New("mfa challenge lifetime must be positive"))
+	}
+
+	if cfg.Auth.TOTP_Issuer == "" || strings.Contains(cfg.Auth.TOTP_Issuer, ":") {
+		problems = append(problems, errors.New("totp issuer is required and must not contain a colon"))
+	}
+
+	if cfg.Verification.Lifetime <= 0 {
+		problems = append(problems, errors.New("verification lifetime must be positive"))
+	}
+
+	if cfg.Verification.Resend_Interval < 0 || cfg.Verification.Resend_Interval >= cfg.Verification.Lifetime {
+		problems = append(problems, errors.New("verification resend interval must not be negative and must be shorter than the verification lifetime"))
+	}
+
+	for _, action := range cfg.Verification.Unverified_Restrictions {
+		if !slices.Contains(UnverifiedActions, action) {
+			problems = append(problems, fmt.Errorf("unverified restriction %q is not one of %s", action, strings.Join(UnverifiedActions, ", ")))
+		}
+	}
+
+	if cfg.Workspace.Invitation_Lifetime <= 0 {
+		problems = append(problems, errors.New("workspace invitation lifetime must be positive"))
+	}
+
+	for _, email := range cfg.Admin.Emails {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			problems = append(problems, fmt.Errorf("admin email %q is not a bare email address", email))
+		}
+	}
+
+	if cfg.Rate_Limit.Global_Rate <= 0 || cfg.Rate_Limit.User_Rate <= 0 {
+		problems = append(problems, errors.New("rate limits must be positive"))
+	}
+
+	if cfg.Rate_Limit.Global_Burst < 1 || cfg.Rate_Limit.User_Burst < 1 {
+		problems = append(problems, errors.New("rate limit bursts must be at least 1"))
+	}
+
+	if cfg.Lockout.Account_Threshold < 1 || cfg.Lockout.IP_Threshold < 1 {
+		problems = append(problems, errors.New("lockout thresholds must be at least 1"))
+	}
+
+	if cfg.Lockout.Base_Delay < 0 || cfg.Lockout.Max_Delay < cfg.Lockout.Base_Delay {
+		problems = append(problems, errors.New("lockout base delay must not be negative and not longer than the max delay"))
+	}
+
+	if cfg.Lockout.Duration <= 0 || cfg.Lockout.Failure_Window <= 0 {
+		problems = append(problems, errors.New("lockout duration and failure window must be positive"))
+	}
+
+	switch cfg.Search.Engine {
+	case StoreSearchEngine:
+	case BleveSearchEngine:
+		if cfg.Search.Index_Path == "" {
+			problems = append(problems, errors.New("search index path is required for the bleve search engine"))
+		}
+		if !slices.Contains(SearchLanguages, cfg.Search.Language) {
+			problems = append(problems, fmt.Errorf("search language %q is not one of %s", cfg.Search.Language, strings.Join(SearchLanguages, ", ")))
+		}
+		if cfg.Search.Fuzziness < 0 || cfg.Search.Fuzziness > 2 {
+			problems = append(problems, errors.New("search fuzziness must be from 0 to 2"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unknown search engine %q", cfg.Search.Engine))
+	}
+
+	if cfg.Trash.Retention <= 0 || cfg.Trash.Purge_Interval <= 0 {
+		problems = append(problems, errors.New("trash retention and purge interval must be positive"))
+	}
+
+	switch cfg.Mail.Mailer {
+	case FileMailer:
+		if cfg.Mail.Drop_Directory == "" {
+			problems = append(problems, errors.New("mail drop directory is required for the file mailer"))
+		}
+	case SMTPMailer:
+		if cfg.Mail.SMTP_Host == "" {
+			problems = append(problems, errors.New("smtp host is required for the smtp mailer"))
+		}
+		smtpPort, err := strconv.Atoi(cfg.Mail.SMTP_Port)
+		if err != nil || smtpPort < 1 || smtpPort > 65535 {
+			problems = append(problems, fmt.Errorf("smtp port %q is not a valid port number", cfg.Mail.SMTP_Port))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unknown mailer %q", cfg.Mail.Mailer))
+	}
+
+	if cfg.Mail.From == "" {
+		problems = append(problems, errors.New("mail from address is required"))
+	}
+
+	if linkBaseURL, err := url.Parse(cfg.Mail.Link_Base_URL); err != nil || (linkBaseURL.Scheme != "http" && linkBaseURL.Scheme != "https") || linkBaseURL.Host == "" {
+		problems = append(problems, fmt.Errorf("mail link base url %q is not an http or https url", cfg.Mail.Link_Base_URL))
+	}
+
+	if cfg.Log.File == "" {
+		problems = append(problems, errors.New("log file is required"))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/auth/tokens: create a personal access token with a name, scopes and an optional expiry,
+// responding with the token once.
+func CreateAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var request models.CreateAccessTokenRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		err = helper.ValidateAccessTokenRequest(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid access token request.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid access token request of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		token, accessToken, err := helper.CreateAccessToken(c.Request.Context(), userId, &request)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the access token.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while creating the access token of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Message: Access token created. Keep it safe, it is not shown again.", "data": gin.H{"token": token, "accessToken": accessToken}})
+		logger.Log.Printf("Message: Successfully created access token id: %s of user id: %s", accessToken.ID.Hex(), userId)
+	}
+}
+
+// GET /api/auth/tokens: list the personal access tokens of the authenticated user, the newest first, without the tokens themselves.
+func GetAccessTokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		accessTokens, err := database.StoreObject.ListAccessTokens(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the access tokens.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the access tokens.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": accessTokens})
+		logger.Log.Printf("Message: Successfully responded with the access tokens of user id: %s", userId)
+	}
+}
+
+// DELETE /api/auth/tokens/:id: revoke a personal access token of the authenticated user.
+func DeleteAccessTokenByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		err := database.StoreObject.DeleteAccessToken(c.Request.Context(), userId, tokenId)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No access token with id: %s found.", tokenId)})
+			logger.Log.Printf("Error: No access token with id: %s found for user id: %s.", tokenId, userId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while revoking the access token with id: %s.\n\tError: %s", tokenId, err.Error())})
+			logger.Log.Printf("Error: Problem while revoking the access token with id: %s.\n\tError: %s", tokenId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Access token revoked."})
+		logger.Log.Printf("Message: Successfully revoked access token id: %s of user id: %s", tokenId, userId)
+	}
+}
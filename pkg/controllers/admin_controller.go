@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/searchindex"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/admin/search/reindex: rebuild the search index from every note of the store.
+func ReindexSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if searchindex.IndexObject == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: The server does not search through an index."})
+			logger.Log.Println("Error: Reindex requested without a search index.")
+			c.Abort()
+			return
+		}
+
+		count, err := searchindex.IndexObject.Reindex(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while rebuilding the search index.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while rebuilding the search index.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Search index rebuilt.", "indexed": count})
+		logger.Log.Printf("Message: Successfully rebuilt the search index with: %d notes.", count)
+	}
+}
+
+// GET /api/admin/lockouts?limit=: list the logins locked out after too many failures, newest first.
+func GetLockouts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := helper.DefaultLockoutListSize
+		if c.Query("limit") != "" {
+			var err error
+			limit, err = strconv.Atoi(c.Query("limit"))
+			if err != nil || limit < 1 || limit > helper.MaxLockoutListSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Limit must be a number from 1 to %d.", helper.MaxLockoutListSize)})
+				logger.Log.Printf("Error: Invalid limit: %s given to list the lockouts.", c.Query("limit"))
+				c.Abort()
+				return
+			}
+		}
+
+		lockouts, err := database.StoreObject.ListLockouts(c.Request.Context(), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the lockouts.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the lockouts.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": lockouts})
+		logger.Log.Printf("Message: Successfully listed: %d lockouts.", len(lockouts))
+	}
+}
+
+// Account of the user as the admins see it.
+func userAccount(user *models.UserDataServer) models.UserAccount {
+	role := user.Role
+	if role == "" {
+		role = models.UserRole
+	}
+
+	return models.UserAccount{
+		User_Id:        user.UserID,
+		First_Name:     user.First_Name,
+		Last_Name:      user.Last_Name,
+		Email:          user.Email,
+		Role:           role,
+		Disabled:       user.Disabled,
+		Email_Verified: user.Email_Verified,
+		TOTP_Enabled:   user.TOTP_Enabled,
+		Created_At:     user.Created_At,
+		Last_Login:     user.Last_Login,
+	}
+}
+
+// GET /api/admin/users?q=&role=&cursor=&limit=: list the users whose email or names contain the query, in the order of their ids,
+// with the cursor of the next page.
+func GetUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := database.UserListQuery{
+			Search: c.Query("q"),
+			Role:   c.Query("role"),
+			After:  c.Query("cursor"),
+			Limit:  helper.DefaultUserListSize,
+		}
+
+		if query.Role != "" && !helper.IsValidRole(query.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Unknown role: %s.", query.Role)})
+			logger.Log.Printf("Error: Unknown role: %s given to list the users.", query.Role)
+			c.Abort()
+			return
+		}
+
+		if c.Query("limit") != "" {
+			limit, err := strconv.Atoi(c.Query("limit"))
+			if err != nil || limit < 1 || limit > helper.MaxUserListSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Limit must be a number from 1 to %d.", helper.MaxUserListSize)})
+				logger.Log.Printf("Error: Invalid limit: %s given to list the users.", c.Query("limit"))
+				c.Abort()
+				return
+			}
+			query.Limit = limit
+		}
+
+		// Ask for one user more than the page holds, to know whether there is a next page.
+		pageSize := query.Limit
+		query.Limit = pageSize + 1
+
+		foundUsers, err := database.StoreObject.ListUsers(c.Request.Context(), &query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the users.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the users.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		response := gin.H{}
+		if len(foundUsers) > pageSize {
+			foundUsers = foundUsers[:pageSize]
+			response["next"] = foundUsers[pageSize-1].UserID
+		}
+
+		accounts := []models.UserAccount{}
+		for i := range foundUsers {
+			accounts = append(accounts, userAccount(&foundUsers[i]))
+		}
+		response["data"] = accounts
+
+		c.JSON(http.StatusOK, response)
+		logger.Log.Printf("Message: Successfully listed: %d users for admin user id: %s", len(accounts), c.GetString("userId"))
+	}
+}
+
+// Finds the user of the url, having responded with the error if there is none.
+func targetUser(c *gin.Context) (*models.UserDataServer, bool) {
+	userId := c.Param("id")
+
+	foundUser, err := database.StoreObject.GetUserById(c.Request.Context(), userId)
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No user with user id: %s found.", userId)})
+		logger.Log.Printf("Error: No user with user id: %s found.", userId)
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the user with user id: %s.\n\tError: %s", userId, err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	return foundUser, true
+}
+
+// Keeps admins from changing their own account, so that they cannot lock themselves out.
+func notOwnAccount(c *gin.Context, foundUser *models.UserDataServer, change string) bool {
+	if foundUser.UserID == c.GetString("userId") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Admins cannot %s their own account.", change)})
+		logger.Log.Printf("Error: Admin user id: %s kept from changing their own account.", foundUser.UserID)
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// GET /api/admin/users/:id: get the account of a user.
+func GetUserByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := targetUser(c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": userAccount(foundUser)})
+		logger.Log.Printf("Message: Successfully responded with the account of user id: %s", foundUser.UserID)
+	}
+}
+
+// PUT /api/admin/users/:id/role: change the role of a user, revoking their tokens.
+func SetUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.SetRoleRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !helper.IsValidRole(request.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Unknown role: %s.", request.Role)})
+			logger.Log.Printf("Error: Unknown role: %s given.", request.Role)
+			c.Abort()
+			return
+		}
+
+		foundUser, ok := targetUser(c)
+		if !ok || !notOwnAccount(c, foundUser, "change the role of") {
+			return
+		}
+
+		err = helper.SetUserRole(c.Request.Context(), foundUser.UserID, request.Role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while changing the role.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while changing the role of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		foundUser.Role = request.Role
+		c.JSON(http.StatusOK, gin.H{"message": "Message: Role changed, the user has to log in again.", "data": userAccount(foundUser)})
+		logger.Log.Printf("Message: Admin user id: %s gave user id: %s the role: %s", c.GetString("userId"), foundUser.UserID, request.Role)
+	}
+}
+
+// POST /api/admin/users/:id/disable and /enable: disable the account of a user, revoking their tokens, or enable it again.
+func SetUserDisabled(disabled bool) gin.HandlerFunc {
+	change := "enable"
+	if disabled {
+		change = "disable"
+	}
+
+	return func(c *gin.Context) {
+		foundUser, ok := targetUser(c)
+		if !ok || !notOwnAccount(c, foundUser, change) {
+			return
+		}
+
+		err := helper.SetUserDisabled(c.Request.Context(), foundUser.UserID, disabled)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while trying to %s the account.\n\tError: %s", change, err.Error())})
+			logger.Log.Printf("Error: Problem while trying to %s the account of user id: %s.\n\tError: %s", change, foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		foundUser.Disabled = disabled
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Account %sd.", change), "data": userAccount(foundUser)})
+		logger.Log.Printf("Message: Admin user id: %s %sd the account of user id: %s", c.GetString("userId"), change, foundUser.UserID)
+	}
+}
+
+// POST /api/admin/users/:id/logout: revoke every token of a user, ending all of their sessions.
+func LogoutUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := targetUser(c)
+		if !ok {
+			return
+		}
+
+		err := helper.RevokeAllTokens(c.Request.Context(), foundUser.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while revoking the tokens.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while revoking the tokens of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: User logged out of every session."})
+		logger.Log.Printf("Message: Admin user id: %s logged user id: %s out of every session", c.GetString("userId"), foundUser.UserID)
+	}
+}
+
+// POST /api/admin/users/:id/password: set a new password of a user, or without one mail them a link to reset it,
+// revoking their tokens either way.
+func ResetUserPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The body is optional.
+		var request models.AdminPasswordResetRequest
+		err := c.ShouldBindJSON(&request)
+		if err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if request.Password != "" && len(request.Password) < helper.MinPasswordLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Password must have at least %d characters.", helper.MinPasswordLength)})
+			logger.Log.Println("Error: Too short password given by an admin.")
+			c.Abort()
+			return
+		}
+
+		foundUser, ok := targetUser(c)
+		if !ok {
+			return
+		}
+
+		message := "Message: Password set, the user has to log in again."
+		if request.Password != "" {
+			err = helper.SetPasswordByAdmin(c.Request.Context(), foundUser.UserID, request.Password)
+		} else {
+			message = "Message: Password reset mailed to the user, who has to log in again."
+			err = helper.StartPasswordReset(c.Request.Context(), foundUser)
+			if err == nil {
+				err = helper.RevokeAllTokens(c.Request.Context(), foundUser.UserID)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while resetting the password.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while resetting the password of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": message})
+		logger.Log.Printf("Message: Admin user id: %s reset the password of user id: %s", c.GetString("userId"), foundUser.UserID)
+	}
+}
+
+// Finds the note of the url, in the trash too, having responded with the error if there is none.
+func anyNote(c *gin.Context) (*models.NoteData, bool) {
+	noteId := c.Param("id")
+
+	foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+	if err == database.ErrNotFound {
+		foundNote, err = database.StoreObject.GetTrashedNote(c.Request.Context(), noteId)
+	}
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No note with note id: %s found.", noteId)})
+		logger.Log.Printf("Error: No note with note id: %s found.", noteId)
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	return foundNote, true
+}
+
+// GET /api/admin/notes/:id: get any note, in the trash too.
+func GetAnyNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundNote, ok := anyNote(c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": foundNote})
+		logger.Log.Printf("Message: User id: %s with the role: %s viewed note id: %s", c.GetString("userId"), c.GetString("role"), foundNote.ID.Hex())
+	}
+}
+
+// DELETE /api/admin/notes/:id: delete any note for good, along with its revisions.
+func DeleteAnyNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundNote, ok := anyNote(c)
+		if !ok {
+			return
+		}
+
+		if !deleteNoteForGood(c, foundNote) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: Note deleted for good."})
+		logger.Log.Printf("Message: User id: %s with the role: %s deleted note id: %s of user id: %s", c.GetString("userId"), c.GetString("role"), foundNote.ID.Hex(), *foundNote.User_Id)
+	}
+}
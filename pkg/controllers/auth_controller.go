@@ -1,238 +1,543 @@
-package controllers
-
-import (
-	"fmt"
-	"net/http"
-	"time"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
-	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-)
-
-// POST /api/auth/signup: create a new user account.
-
-func SignUp() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Bind the json into a userdata variable.
-		var userClient models.UserDataClient
-		err := c.BindJSON(&userClient)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Problem while binding the json.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Validate the data.
-		validate := validator.New()
-		err = validate.Struct(&userClient)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Problem while validating data.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Get the user collection.
-		userCollection, err := database.MongoObject.GetUserCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Problem with opening the user collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Find whether user with the same email address exists in the collection already or not.
-		var foundDocument models.UserDataServer
-		filter := bson.D{{Key: "email", Value: userClient.Email}}
-
-		err = userCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundDocument)
-
-		// If there is a document, error will be given.
-		if err == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: User with same email id: %s, already exists.", *userClient.Email)})
-			logger.Log.Printf("\nError: User with same email id: %s, already exists.", *userClient.Email)
-			c.Abort()
-			return
-		}
-
-		// If there is an error and it is not equivalent to errnodocuments then it is a decoding error.
-		if err != nil && err != mongo.ErrNoDocuments {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding document.\n\tError: %s", err.Error())})
-			logger.Log.Printf("\nError: Problem while decoding document.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// There is an error from the previous step but it is due to no document found and thus no document for decoding, hence, next step is to hash the password.
-		hashedPassword := helper.HashPassword(userClient.Password)
-
-		// Create the tokens.
-		token, refreshToken, err := helper.GenerateAllToken(*userClient.Email, *userClient.First_Name, *userClient.Last_Name, userClient.UserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Problem while creating tokens for the new user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		createdAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("\nError: Problem while storing the creation time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("\nError: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		lastLogin, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("\nError: Problem while storing the lost login time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Fill fields for user data client side.
-		userClient.ID = primitive.NewObjectID()
-		userClient.Token = &token
-		userClient.UserID = userClient.ID.Hex()
-
-		// Fill fields for user data server side.
-		var userServer models.UserDataServer
-		userServer.ID = userClient.ID
-		userServer.First_Name = userClient.First_Name
-		userServer.Last_Name = userClient.Last_Name
-		userServer.Password = &hashedPassword
-		userServer.Email = userClient.Email
-		userServer.Created_At = createdAt
-		userServer.Updated_At = updatedAt
-		userServer.Last_Login = lastLogin
-		userServer.Refresh_Token = &refreshToken
-		userServer.UserID = userClient.UserID
-
-		// Save the user data struct inside the user data collection.
-		_, err = userCollection.InsertOne(database.MongoObject.Ctx, userServer)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Pronlem while storing the new user in the database.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Send the respective user data struct with all the fields in the response and the correct response code.
-		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful signing up of user with user id: %s and user email id: %s", userClient.UserID, *userClient.Email), "data": userClient})
-		logger.Log.Printf("\nMessage: Successful signing up of user with user id: %s and user email id: %s", userClient.UserID, *userClient.Email)
-	}
-}
-
-// POST /api/auth/login: log in to an existing user account and receive an access token.
-func Login() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Extract login info from the user.
-		var user models.UserDataClient
-		err := c.BindJSON(&user)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
-			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Get the user collection.
-		userCollection, err := database.MongoObject.GetUserCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while opening the user collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Check whether the user exist in the user collection.
-		var foundUser models.UserDataServer
-		filter := bson.D{{Key: "email", Value: user.Email}}
-
-		err = userCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundUser)
-
-		// If there is an error due to decoding or no document, error will be given.
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No user with email id: %s registered.", *user.Email)})
-				logger.Log.Printf("Error: No user with email id: %s registered.", *user.Email)
-				c.Abort()
-				return
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding found user.\n\tError: %s", err.Error())})
-				logger.Log.Printf("Error: Problem while decoding found user.\n\tError: %s", err.Error())
-				c.Abort()
-				return
-			}
-		}
-
-		// Document has been found so match the password.
-		boolVal, err := helper.VerifyPassword(*user.Password, *foundUser.Password)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while verifying password.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// If password does not match, send bad request.
-		if !boolVal {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Email or password is wrong.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// If password matches, generate all tokens.
-		token, refreshToken, err := helper.GenerateAllToken(*foundUser.Email, *foundUser.First_Name, *foundUser.Last_Name, foundUser.UserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("\nError: Problem while generating tokens to update for the existing user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Update the last login date and refresh token in the server side user database.
-		lastLogin, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
-			logger.Log.Printf("Error: Problem while trying to update the last login date.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		err = helper.UpdateLastLoginAndRefreshToken(foundUser.UserID, lastLogin, refreshToken)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while updating the last login date for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Update the token and send it to the user along with status ok.
-		user.ID = foundUser.ID
-		user.First_Name = foundUser.First_Name
-		user.Last_Name = foundUser.Last_Name
-		user.Token = &token
-		user.UserID = foundUser.UserID
-
-		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful logging up of user with user id: %s and user email id: %s", user.UserID, *user.Email), "data": user})
-		logger.Log.Printf("Message: Successful logging up of user with user id: %s and user email id: %s", user.UserID, *user.Email)
-	}
-}
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// POST /api/auth/signup: create a new user account.
+
+func SignUp() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Bind the json into a userdata variable.
+		var userClient models.UserDataClient
+		err := c.BindJSON(&userClient)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("\nError: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Validate the data.
+		validate := validator.New()
+		err = validate.Struct(&userClient)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("\nError: Problem while validating data.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Mails can only be sent to a bare address.
+		if userClient.Email == nil || !helper.IsValidEmail(*userClient.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Email is not a valid email address."})
+			logger.Log.Printf("\nError: Email is not a valid email address.")
+			c.Abort()
+			return
+		}
+
+		// Find whether user with the same email address exists in the store already or not.
+		_, err = database.StoreObject.GetUserByEmail(c.Request.Context(), *userClient.Email)
+
+		// If there is a document, error will be given.
+		if err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: User with same email id: %s, already exists.", *userClient.Email)})
+			logger.Log.Printf("\nError: User with same email id: %s, already exists.", *userClient.Email)
+			c.Abort()
+			return
+		}
+
+		// If there is an error and it is not equivalent to not found then it is a decoding error.
+		if err != database.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding document.\n\tError: %s", err.Error())})
+			logger.Log.Printf("\nError: Problem while decoding document.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// There is an error from the previous step but it is due to no document found and thus no document for decoding, hence, next step is to hash the password.
+		hashedPassword := helper.HashPassword(userClient.Password)
+
+		// Create the user id before the tokens, so that the tokens carry it.
+		userClient.ID = primitive.NewObjectID()
+		userClient.UserID = userClient.ID.Hex()
+
+		createdAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			logger.Log.Printf("\nError: Problem while storing the creation time tamp for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			logger.Log.Printf("\nError: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		lastLogin, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			logger.Log.Printf("\nError: Problem while storing the lost login time tamp for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Fill fields for user data server side.
+		var userServer models.UserDataServer
+		userServer.ID = userClient.ID
+		userServer.First_Name = userClient.First_Name
+		userServer.Last_Name = userClient.Last_Name
+		userServer.Password = &hashedPassword
+		userServer.Email = userClient.Email
+		userServer.Created_At = createdAt
+		userServer.Updated_At = updatedAt
+		userServer.Last_Login = lastLogin
+		userServer.UserID = userClient.UserID
+		// Configured admin emails only make admins once verified.
+		userServer.Role = models.UserRole
+
+		// Accounts start unverified, till the token mailed to the email is used.
+		verificationToken, err := helper.NewEmailVerification(&userServer, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("\nError: Problem while creating the verification token for the new user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Save the user data struct inside the store.
+		err = database.StoreObject.CreateUser(c.Request.Context(), &userServer)
+		if err == database.ErrDuplicate {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: User with same email id: %s, already exists.", *userClient.Email)})
+			logger.Log.Printf("\nError: User with same email id: %s, already exists.", *userClient.Email)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("\nError: Pronlem while storing the new user in the database.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		helper.MailEmailVerification(&userServer, verificationToken)
+
+		// Users who may not log in before verifying get no tokens either.
+		if helper.IsRestrictedForUnverified(config.LoginAction) {
+			c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful signing up of user with user id: %s and user email id: %s. Verify the email to log in.", userClient.UserID, *userClient.Email), "data": userClient})
+			logger.Log.Printf("\nMessage: Successful signing up of user with user id: %s and user email id: %s, awaiting verification.", userClient.UserID, *userClient.Email)
+			return
+		}
+
+		// Signing up logs the user in on the device, in a new session.
+		token, refreshToken, err := helper.StartSession(c.Request.Context(), &userServer, c.Request.UserAgent(), c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("\nError: Problem while creating tokens for the new user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Fill fields for user data client side.
+		userClient.Token = &token
+		userClient.Refresh_Token = &refreshToken
+
+		// Send the respective user data struct with all the fields in the response and the correct response code.
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful signing up of user with user id: %s and user email id: %s", userClient.UserID, *userClient.Email), "data": userClient})
+		logger.Log.Printf("\nMessage: Successful signing up of user with user id: %s and user email id: %s", userClient.UserID, *userClient.Email)
+	}
+}
+
+// POST /api/auth/login: log in to an existing user account and receive an access token.
+func Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract login info from the user.
+		var user models.UserDataClient
+		err := c.BindJSON(&user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Email and password are required."})
+			logger.Log.Printf("Error: Email and password are required.")
+			c.Abort()
+			return
+		}
+
+		// Logins to the email or from the address failing too often have to wait before trying again.
+		if !allowLoginAttempt(c, *user.Email) {
+			return
+		}
+
+		// Check whether the user exist in the store.
+		foundUser, err := database.StoreObject.GetUserByEmail(c.Request.Context(), *user.Email)
+
+		// If there is an error due to decoding or no document, error will be given.
+		if err != nil {
+			if err == database.ErrNotFound {
+				// Checking a password anyway makes the response take as long as for a registered email.
+				helper.VerifyDummyPassword(*user.Password)
+				refuseLogin(c, *user.Email)
+				logger.Log.Printf("Error: No user with email id: %s registered.", *user.Email)
+				return
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding found user.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while decoding found user.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// Document has been found so match the password.
+		boolVal, err := helper.VerifyPassword(*user.Password, *foundUser.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while verifying password.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// If password does not match, refuse the login the same way as for an unknown email.
+		if !boolVal {
+			refuseLogin(c, *user.Email)
+			logger.Log.Printf("Error: Wrong password given for user id: %s.", foundUser.UserID)
+			return
+		}
+
+		// Disabled accounts cannot log in, which is told only once the password has matched.
+		if foundUser.Disabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The account has been disabled."})
+			logger.Log.Printf("Error: Disabled user id: %s kept from logging in.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		// Users may have to verify their email before logging in.
+		if !foundUser.Email_Verified && helper.IsRestrictedForUnverified(config.LoginAction) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The email of the account has to be verified to log in."})
+			logger.Log.Printf("Error: Unverified user id: %s kept from logging in.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		// Users with TOTP enabled get a challenge to finish the login with their second factor, instead of the tokens.
+		if foundUser.TOTP_Enabled {
+			mfaToken, err := helper.GenerateMFAToken(foundUser.UserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				logger.Log.Printf("Error: Problem while generating the mfa token for the user.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusOK, gin.H{"message": "Message: Second factor required. Finish the login at /api/auth/login/mfa.", "data": gin.H{"mfaRequired": true, "mfaToken": mfaToken}})
+			logger.Log.Printf("Message: Password of user id: %s verified, awaiting the second factor.", foundUser.UserID)
+			return
+		}
+
+		respondWithSession(c, foundUser)
+	}
+}
+
+// Checks that a login to the email may be tried from the address of the request, else responds that it has to wait.
+func allowLoginAttempt(c *gin.Context, email string) bool {
+	wait, err := helper.CheckLoginAllowed(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the failed logins.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while checking the failed logins.\n\tError: %s", err.Error())
+		c.Abort()
+		return false
+	}
+
+	if wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Error: Too many failed logins. Try again in %d seconds.", seconds)})
+		logger.Log.Printf("Error: Login to email id: %s from ip address: %s refused for the next %d seconds.", email, c.ClientIP(), seconds)
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// Counts a failed login and responds that the credentials are invalid, without telling whether the email is registered.
+func refuseLogin(c *gin.Context, email string) {
+	err := helper.RecordFailedLogin(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the failed login.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while recording the failed login.\n\tError: %s", err.Error())
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Invalid credentials."})
+	c.Abort()
+}
+
+// Logs the user in, whose credentials have been checked, on the device in a new session and responds with its tokens.
+func respondWithSession(c *gin.Context, foundUser *models.UserDataServer) {
+	// A finished login forgets the failed ones before it.
+	err := helper.ClearFailedLogins(c.Request.Context(), *foundUser.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logger.Log.Printf("Error: Problem while clearing the failed logins of the user.\n\tError: %s", err.Error())
+		c.Abort()
+		return
+	}
+
+	// Update the last login date in the server side user database.
+	lastLogin, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		logger.Log.Printf("Error: Problem while trying to update the last login date.\n\tError: %s", err.Error())
+		c.Abort()
+		return
+	}
+	err = database.StoreObject.UpdateLastLogin(c.Request.Context(), foundUser.UserID, lastLogin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logger.Log.Printf("Error: Problem while updating the last login date for the user.\n\tError: %s", err.Error())
+		c.Abort()
+		return
+	}
+
+	// Every login starts a new session of its own, leaving the ones on other devices alone.
+	token, refreshToken, err := helper.StartSession(c.Request.Context(), foundUser, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logger.Log.Printf("\nError: Problem while generating tokens to update for the existing user.\n\tError: %s", err.Error())
+		c.Abort()
+		return
+	}
+
+	// Send the tokens to the user along with status ok.
+	var user models.UserDataClient
+	user.ID = foundUser.ID
+	user.First_Name = foundUser.First_Name
+	user.Last_Name = foundUser.Last_Name
+	user.Email = foundUser.Email
+	user.Token = &token
+	user.Refresh_Token = &refreshToken
+	user.UserID = foundUser.UserID
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful logging up of user with user id: %s and user email id: %s", user.UserID, *user.Email), "data": user})
+	logger.Log.Printf("Message: Successful logging up of user with user id: %s and user email id: %s", user.UserID, *user.Email)
+}
+
+// POST /api/auth/refresh: exchange a refresh token for a new access token and refresh token.
+func RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the refresh token from the request body.
+		var request struct {
+			Refresh_Token *string `json:"refreshToken"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if request.Refresh_Token == nil || *request.Refresh_Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No refresh token provided."})
+			logger.Log.Println("Error: No refresh token provided.")
+			c.Abort()
+			return
+		}
+
+		// Validate the refresh token itself.
+		claims, err := helper.ValidateRefreshToken(*request.Refresh_Token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while validating the refresh token.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Refresh tokens issued before a logout from all devices are no longer accepted.
+		revoked, err := helper.IsTokenRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while checking revocation of the refresh token.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Refresh token has been revoked."})
+			logger.Log.Printf("Error: Revoked refresh token used by user id: %s.", claims.User_Id)
+			c.Abort()
+			return
+		}
+
+		// Find the user the refresh token was issued to.
+		foundUser, err := database.StoreObject.GetUserById(c.Request.Context(), claims.User_Id)
+		if err != nil {
+			if err == database.ErrNotFound {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Error: No user with user id: %s registered.", claims.User_Id)})
+				logger.Log.Printf("Error: No user with user id: %s registered.", claims.User_Id)
+				c.Abort()
+				return
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding found user.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while decoding found user.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// Find the session the refresh token was issued to, revoking the token checked that there is one.
+		foundSession, err := database.StoreObject.GetSessionById(c.Request.Context(), claims.Token_Family)
+		if err != nil {
+			if err == database.ErrNotFound {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Refresh token is no longer valid."})
+				logger.Log.Printf("Error: Refresh token for user id: %s is no longer valid.", claims.User_Id)
+				c.Abort()
+				return
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the session.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while finding the session.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// The refresh token must be the one currently stored for the session.
+		// If it is an older token of the session, it has been used before, which means it
+		// has been leaked, so the whole session is revoked and the user has to log in again.
+		refreshTokenHash := helper.HashToken(*request.Refresh_Token)
+		if foundSession.Refresh_Token_Hash != refreshTokenHash {
+			revokeReusedSession(c, claims)
+			return
+		}
+
+		// Generate the new pair of tokens in the same session.
+		token, refreshToken, err := helper.GenerateAllToken(*foundUser.Email, *foundUser.First_Name, *foundUser.Last_Name, foundUser.UserID, foundUser.Role, claims.Token_Family)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while generating tokens to refresh for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Store the new refresh token, provided no concurrent request has rotated the old one first.
+		now := time.Now()
+		rotated, err := database.StoreObject.RotateSessionToken(c.Request.Context(), claims.Token_Family, refreshTokenHash, helper.HashToken(refreshToken),
+			c.ClientIP(), now, now.Add(helper.RefreshTokenLifetime()))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while storing the refresh token for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !rotated {
+			revokeReusedSession(c, claims)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful refreshing of tokens for user with user id: %s", foundUser.UserID), "data": gin.H{"token": token, "refreshToken": refreshToken}})
+		logger.Log.Printf("Message: Successful refreshing of tokens for user with user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/logout: revoke the access token used for the request and end its session.
+func Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id and token details.
+		userId := c.GetString("userId")
+		tokenId := c.GetString("tokenId")
+		tokenFamily := c.GetString("tokenFamily")
+		tokenExpiresAt := c.GetInt64("tokenExpiresAt")
+
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Put the access token on the deny-list.
+		err := helper.RevokeToken(c.Request.Context(), tokenId, userId, tokenExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while revoking the token of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		// The session of the same login ends, so its refresh token can no longer be used either.
+		err = database.StoreObject.DeleteSession(c.Request.Context(), tokenFamily)
+		if err != nil && err != database.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while ending the session of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful logging out of user with user id: %s", userId)})
+		logger.Log.Printf("Message: Successful logging out of user with user id: %s", userId)
+	}
+}
+
+// POST /api/auth/logout-all: revoke every token issued to the authenticated user.
+func LogoutAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		err := helper.RevokeAllTokens(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while revoking all tokens of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successful logging out of user with user id: %s from all devices.", userId)})
+		logger.Log.Printf("Message: Successful logging out of user with user id: %s from all devices.", userId)
+	}
+}
+
+// Ends the session whose refresh token has been used more than once, responding with unauthorized.
+func revokeReusedSession(c *gin.Context, claims *models.SignedDetails) {
+	err := database.StoreObject.DeleteSession(c.Request.Context(), claims.Token_Family)
+	if err != nil && err != database.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logger.Log.Printf("Error: Problem while revoking the session: %s for user id: %s.\n\tError: %s", claims.Token_Family, claims.User_Id, err.Error())
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Refresh token has already been used. All tokens of this session have been revoked."})
+	logger.Log.Printf("Error: Reuse of refresh token detected for user id: %s. Session: %s has been revoked.", claims.User_Id, claims.Token_Family)
+	c.Abort()
+}
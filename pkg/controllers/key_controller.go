@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /.well-known/jwks.json: publish the public keys verifying the tokens, by their kid.
+func GetJSONWebKeySet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Verifiers may cache the keys for a while, new keys are published before they start signing.
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, helper.JSONWebKeySet())
+		logger.Log.Printf("Message: Successfully responded with the JSON web key set.")
+	}
+}
@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Width and height of the QR code PNG, in pixels.
+const totpQRCodeSize = 256
+
+// Finds the authenticated user, responding with an error if there is none.
+func authenticatedUser(c *gin.Context) (*models.UserDataServer, bool) {
+	userId := c.GetString("userId")
+	if userId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+		logger.Log.Print("Error: No user id present.")
+		c.Abort()
+		return nil, false
+	}
+
+	foundUser, err := database.StoreObject.GetUserById(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the user with user id: %s.\n\tError: %s", userId, err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	return foundUser, true
+}
+
+// POST /api/auth/login/mfa: finish a login with its challenge token and a TOTP or recovery code, receiving the tokens.
+func LoginMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.MFALoginRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		claims, err := helper.ValidateMFAToken(request.MFA_Token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Error: Invalid mfa token.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid mfa token.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		foundUser, err := database.StoreObject.GetUserById(c.Request.Context(), claims.User_Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the user with user id: %s.\n\tError: %s", claims.User_Id, err.Error())
+			c.Abort()
+			return
+		}
+
+		if foundUser.Disabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The account has been disabled."})
+			logger.Log.Printf("Error: Disabled user id: %s kept from finishing a login.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		// Codes are guessed no faster than passwords, failing ones count as failed logins.
+		if !allowLoginAttempt(c, *foundUser.Email) {
+			return
+		}
+
+		// The challenge is used up before the code is checked, in one step, so that concurrent requests with it
+		// cannot both finish the login. A wrong code means logging in with the password again.
+		consumed, err := helper.ConsumeToken(c.Request.Context(), claims.ID, claims.User_Id, claims.ExpiresAt.Unix())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while using the mfa token.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while using the mfa token of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if !consumed {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Mfa token has already been used."})
+			logger.Log.Printf("Error: Used mfa token passed for user id: %s.", claims.User_Id)
+			c.Abort()
+			return
+		}
+
+		verified, err := helper.VerifySecondFactor(c.Request.Context(), foundUser, request.Code, request.Recovery_Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the code.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while checking the code of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if !verified {
+			err = helper.RecordFailedLogin(c.Request.Context(), *foundUser.Email, c.ClientIP())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the failed login.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while recording the failed login.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Code is invalid."})
+			logger.Log.Printf("Error: Invalid second factor given for user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		respondWithSession(c, foundUser)
+	}
+}
+
+// GET /api/auth/mfa: tell whether TOTP is enabled for the authenticated user, and how many recovery codes are left.
+func GetMFAStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		recoveryCodesLeft, err := database.StoreObject.CountRecoveryCodes(c.Request.Context(), foundUser.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while counting the recovery codes.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while counting the recovery codes of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"totpEnabled": foundUser.TOTP_Enabled, "recoveryCodesLeft": recoveryCodesLeft}})
+		logger.Log.Printf("Message: Successfully responded with the mfa status of user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/mfa/totp: start a TOTP enrollment, receiving its secret and otpauth:// URI.
+func EnrollTOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		key, err := helper.StartTOTPEnrollment(c.Request.Context(), foundUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while starting the TOTP enrollment.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while starting the TOTP enrollment of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if key == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: TOTP is already enabled."})
+			logger.Log.Printf("Error: TOTP enrollment started by user id: %s with TOTP enabled.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: Add the key to an authenticator app and confirm it with a code.", "data": gin.H{"secret": key.Secret(), "uri": key.URL()}})
+		logger.Log.Printf("Message: Successfully started the TOTP enrollment of user id: %s", foundUser.UserID)
+	}
+}
+
+// GET /api/auth/mfa/totp/qr: get the otpauth:// URI of the pending TOTP enrollment as a QR code PNG.
+func GetTOTPQRCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		if foundUser.TOTP_Enabled || foundUser.TOTP_Secret == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: No pending TOTP enrollment found."})
+			logger.Log.Printf("Error: No pending TOTP enrollment found for user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		qrCode, err := helper.TOTPQRCode(foundUser, totpQRCodeSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while drawing the QR code.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while drawing the QR code of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		// The code holds the secret, it must not be kept by caches.
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "image/png", qrCode)
+		logger.Log.Printf("Message: Successfully responded with the TOTP QR code of user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/mfa/totp/confirm: enable the pending TOTP enrollment with a code of it, receiving the recovery codes.
+func ConfirmTOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		var request models.TOTPCodeRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if foundUser.TOTP_Enabled || foundUser.TOTP_Secret == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: No pending TOTP enrollment found."})
+			logger.Log.Printf("Error: No pending TOTP enrollment found for user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		recoveryCodes, err := helper.ConfirmTOTPEnrollment(c.Request.Context(), foundUser, request.Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while enabling TOTP.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while enabling TOTP of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if recoveryCodes == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Code is invalid."})
+			logger.Log.Printf("Error: Invalid code given to confirm the TOTP enrollment of user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: TOTP has been enabled. Keep the recovery codes, they are shown only once.", "data": gin.H{"recoveryCodes": recoveryCodes}})
+		logger.Log.Printf("Message: Successfully enabled TOTP of user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/mfa/totp/disable: disable TOTP with the password and a TOTP or recovery code.
+func DisableTOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		var request models.DisableTOTPRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !foundUser.TOTP_Enabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: TOTP is not enabled."})
+			logger.Log.Printf("Error: TOTP disabled by user id: %s without TOTP enabled.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		// A wrong password is a mismatch to bcrypt, not a failure.
+		passwordMatches, _ := helper.VerifyPassword(request.Password, *foundUser.Password)
+		if !passwordMatches {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Password is wrong."})
+			logger.Log.Printf("Error: Wrong password given to disable TOTP of user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		verified, err := helper.VerifySecondFactor(c.Request.Context(), foundUser, request.Code, request.Recovery_Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the code.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while checking the code of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Code is invalid."})
+			logger.Log.Printf("Error: Invalid code given to disable TOTP of user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		err = database.StoreObject.DisableTOTP(c.Request.Context(), foundUser.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while disabling TOTP.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while disabling TOTP of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: TOTP has been disabled."})
+		logger.Log.Printf("Message: Successfully disabled TOTP of user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/mfa/recovery-codes: replace the recovery codes with new ones, confirmed with a TOTP code.
+func RegenerateRecoveryCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		var request models.TOTPCodeRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !foundUser.TOTP_Enabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: TOTP is not enabled."})
+			logger.Log.Printf("Error: Recovery codes requested by user id: %s without TOTP enabled.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		verified, err := helper.VerifyTOTPCode(c.Request.Context(), foundUser, request.Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the code.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while checking the code of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Code is invalid."})
+			logger.Log.Printf("Error: Invalid code given to regenerate the recovery codes of user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+
+		recoveryCodes, err := helper.NewRecoveryCodes(c.Request.Context(), foundUser.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while generating the recovery codes.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while generating the recovery codes of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: The previous recovery codes have been replaced. Keep the new ones, they are shown only once.", "data": gin.H{"recoveryCodes": recoveryCodes}})
+		logger.Log.Printf("Message: Successfully regenerated the recovery codes of user id: %s", foundUser.UserID)
+	}
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/notes/:id/access: list the users a note is shared with, for the owner of the note.
+func GetNoteAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		isOwner, ok := checkNoteRole(c, foundNote, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+		if !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to see who the notes with note id: %s is shared with.", userId, noteId)})
+			logger.Log.Printf("Error: User with user id: %s is not allowed to see who the notes with note id: %s is shared with.", userId, noteId)
+			c.Abort()
+			return
+		}
+
+		accessList := foundNote.Access
+		if accessList == nil {
+			accessList = []models.NoteAccess{}
+		}
+
+		c.JSON(http.StatusOK, accessList)
+		logger.Log.Printf("Message: Successfully responded with the access list of the note with note id: %s", noteId)
+	}
+}
+
+// PUT /api/notes/:id/access/:userId: change the role a user has been granted on a note.
+func ChangeNoteAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id and the grantee user id from the url.
+		noteId := c.Param("id")
+		granteeUserId := c.Param("userId")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the new role.
+		var request struct {
+			Role string `json:"role"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !helper.IsGrantableNoteRole(request.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Role: %s cannot be granted.", request.Role)})
+			logger.Log.Printf("Error: Role: %s cannot be granted.", request.Role)
+			c.Abort()
+			return
+		}
+
+		foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		isOwner, ok := checkNoteRole(c, foundNote, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+		if !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to change the access to the notes with note id: %s", userId, noteId)})
+			logger.Log.Printf("Error: User with user id: %s is not allowed to change the access to the notes with note id: %s", userId, noteId)
+			c.Abort()
+			return
+		}
+
+		// Find the existing grant, which keeps its email but gets the new role.
+		var grant *models.NoteAccess
+		for _, access := range foundNote.Access {
+			if access.User_Id == granteeUserId {
+				grant = &access
+				break
+			}
+		}
+
+		if grant == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: Notes with note id: %s is not shared with user id: %s", noteId, granteeUserId)})
+			logger.Log.Printf("Error: Notes with note id: %s is not shared with user id: %s", noteId, granteeUserId)
+			c.Abort()
+			return
+		}
+
+		grant.Role = request.Role
+		grant.Granted_By = userId
+		grant.Granted_At = time.Now()
+
+		updatedNote, err := database.StoreObject.SetNoteAccess(c.Request.Context(), noteId, foundNote.Version, helper.UpsertNoteAccess(foundNote.Access, *grant))
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, noteId)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedNote.Access)
+		logger.Log.Printf("Message: Successfully changed the role of user id: %s on note with note id: %s to %s", granteeUserId, noteId, request.Role)
+	}
+}
+
+// DELETE /api/notes/:id/access/:userId: revoke the access of a user to a note.
+// The owner can revoke anyone, and a grantee can give up their own access.
+func RevokeNoteAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id and the grantee user id from the url.
+		noteId := c.Param("id")
+		granteeUserId := c.Param("userId")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		isOwner, ok := checkNoteRole(c, foundNote, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+		if !isOwner && granteeUserId != userId {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to revoke the access to the notes with note id: %s", userId, noteId)})
+			logger.Log.Printf("Error: User with user id: %s is not allowed to revoke the access to the notes with note id: %s", userId, noteId)
+			c.Abort()
+			return
+		}
+
+		accessList, found := helper.RemoveNoteAccess(foundNote.Access, granteeUserId)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: Notes with note id: %s is not shared with user id: %s", noteId, granteeUserId)})
+			logger.Log.Printf("Error: Notes with note id: %s is not shared with user id: %s", noteId, granteeUserId)
+			c.Abort()
+			return
+		}
+
+		_, err = database.StoreObject.SetNoteAccess(c.Request.Context(), noteId, foundNote.Version, accessList)
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, noteId)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Successfully revoked the access of user id: %s to note with note id: %s", granteeUserId, noteId)})
+		logger.Log.Printf("Message: Successfully revoked the access of user id: %s to note with note id: %s", granteeUserId, noteId)
+	}
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Finds the note and checks that the authenticated user has at least the given role on it,
+// writing the error response if not.
+func findNoteWithRole(c *gin.Context, noteId string, userId string, role string) (*models.NoteData, bool) {
+	foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	allowed, ok := checkNoteRole(c, foundNote, userId, role)
+	if !ok {
+		return nil, false
+	}
+
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Notes is not accessible to the user with user id: %s", userId)})
+		logger.Log.Printf("Error: Notes is not accessible to the user with user id: %s", userId)
+		c.Abort()
+		return nil, false
+	}
+
+	return foundNote, true
+}
+
+// Checks whether the user has at least the given role on the note, anyone viewing a sharable note.
+// Writes the error response if the role of the user cannot be found out, reporting false as ok.
+func checkNoteRole(c *gin.Context, note *models.NoteData, userId string, role string) (allowed bool, ok bool) {
+	var err error
+	if role == models.ViewerRole {
+		allowed, err = helper.CanViewNote(c.Request.Context(), note, userId)
+	} else {
+		allowed, err = helper.HasNoteRole(c.Request.Context(), note, userId, role)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the role of the user on the note.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the role of user id: %s on the note with note id: %s.\n\tError: %s", userId, note.ID.Hex(), err.Error())
+		c.Abort()
+		return false, false
+	}
+
+	return allowed, true
+}
+
+// GET /api/notes/:id/revisions: list the revisions of a note, without their content.
+func GetNoteRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findNoteWithRole(c, noteId, userId, models.ViewerRole)
+		if !ok {
+			return
+		}
+
+		foundRevisions, err := database.StoreObject.ListNoteRevisions(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the revisions of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while listing the revisions of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, foundRevisions)
+		logger.Log.Printf("Message: Successfully responded with the revisions of the note with note id: %s", noteId)
+	}
+}
+
+// GET /api/notes/:id/revisions/:rev: get a single revision of a note with its content.
+func GetNoteRevisionByNumber() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id and the revision number from the url.
+		noteId := c.Param("id")
+
+		revision, err := strconv.ParseInt(c.Param("rev"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Revision: %s is not a number.", c.Param("rev"))})
+			logger.Log.Printf("Error: Revision: %s is not a number.", c.Param("rev"))
+			c.Abort()
+			return
+		}
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findNoteWithRole(c, noteId, userId, models.ViewerRole)
+		if !ok {
+			return
+		}
+
+		foundRevision, err := database.StoreObject.GetNoteRevision(c.Request.Context(), noteId, revision)
+		if err != nil {
+			if err == database.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No revision: %d of the note with note id: %s.", revision, noteId)})
+				logger.Log.Printf("Error: No revision: %d of the note with note id: %s.", revision, noteId)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, foundRevision)
+		logger.Log.Printf("Message: Successfully responded with revision: %d of the note with note id: %s", revision, noteId)
+	}
+}
+
+// GET /api/notes/:id/revisions/diff?from=:rev&to=:rev: line diff between two revisions of a note.
+// Without from and to, the latest revision is compared with the one before it.
+// With format=unified the diff is sent as plain text in the unified diff format.
+func DiffNoteRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findNoteWithRole(c, noteId, userId, models.ViewerRole)
+		if !ok {
+			return
+		}
+
+		// Work out the revisions to compare.
+		latestRevision, err := database.StoreObject.GetLatestNoteRevisionNumber(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the latest revision.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the latest revision.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		toRevision := latestRevision
+		if c.Query("to") != "" {
+			toRevision, err = strconv.ParseInt(c.Query("to"), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Revision: %s is not a number.", c.Query("to"))})
+				logger.Log.Printf("Error: Revision: %s is not a number.", c.Query("to"))
+				c.Abort()
+				return
+			}
+		}
+
+		fromRevision := toRevision - 1
+		if c.Query("from") != "" {
+			fromRevision, err = strconv.ParseInt(c.Query("from"), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Revision: %s is not a number.", c.Query("from"))})
+				logger.Log.Printf("Error: Revision: %s is not a number.", c.Query("from"))
+				c.Abort()
+				return
+			}
+		}
+
+		var revisions []*models.NoteRevision
+		for _, revision := range []int64{fromRevision, toRevision} {
+			foundRevision, err := database.StoreObject.GetNoteRevision(c.Request.Context(), noteId, revision)
+			if err != nil {
+				if err == database.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No revision: %d of the note with note id: %s.", revision, noteId)})
+					logger.Log.Printf("Error: No revision: %d of the note with note id: %s.", revision, noteId)
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())})
+					logger.Log.Printf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())
+				}
+				c.Abort()
+				return
+			}
+
+			revisions = append(revisions, foundRevision)
+		}
+
+		fromText, toText := "", ""
+		if revisions[0].Data != nil {
+			fromText = *revisions[0].Data
+		}
+		if revisions[1].Data != nil {
+			toText = *revisions[1].Data
+		}
+
+		diffLines, err := helper.LineDiff(fromText, toText)
+		if err == helper.ErrDiffTooLarge {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Error: Revisions: %d and %d are too large to diff, the limit is %d lines and %d bytes each.", fromRevision, toRevision, helper.MaxDiffLines, helper.MaxDiffBytes)})
+			logger.Log.Printf("Error: Revisions: %d and %d of the note with note id: %s are too large to diff.", fromRevision, toRevision, noteId)
+			c.Abort()
+			return
+		}
+		unifiedDiff := helper.UnifiedDiff(fmt.Sprintf("revision %d", fromRevision), fmt.Sprintf("revision %d", toRevision), diffLines, 3)
+
+		if c.Query("format") == "unified" {
+			c.String(http.StatusOK, unifiedDiff)
+		} else {
+			c.JSON(http.StatusOK, gin.H{
+				"from":       revisions[0].Revision,
+				"to":         revisions[1].Revision,
+				"headerFrom": revisions[0].Header,
+				"headerTo":   revisions[1].Header,
+				"lines":      diffLines,
+				"unified":    unifiedDiff,
+			})
+		}
+		logger.Log.Printf("Message: Successfully responded with the diff between revisions: %d and %d of the note with note id: %s", fromRevision, toRevision, noteId)
+	}
+}
+
+// POST /api/notes/:id/revisions/:rev/restore: make the content of an old revision the current one, as a new revision.
+func RestoreNoteRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id and the revision number from the url.
+		noteId := c.Param("id")
+
+		revision, err := strconv.ParseInt(c.Param("rev"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Revision: %s is not a number.", c.Param("rev"))})
+			logger.Log.Printf("Error: Revision: %s is not a number.", c.Param("rev"))
+			c.Abort()
+			return
+		}
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundNote, ok := findNoteWithRole(c, noteId, userId, models.EditorRole)
+		if !ok {
+			return
+		}
+
+		// If the client restores over an older version of the note, send status precondition failed.
+		if !checkIfMatch(c, foundNote) {
+			return
+		}
+
+		foundRevision, err := database.StoreObject.GetNoteRevision(c.Request.Context(), noteId, revision)
+		if err != nil {
+			if err == database.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No revision: %d of the note with note id: %s.", revision, noteId)})
+				logger.Log.Printf("Error: No revision: %d of the note with note id: %s.", revision, noteId)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while decoding the revision.\n\tError: %s", err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		// Put the content of the revision back into the note.
+		uniqueHeader := database.UniqueHeader(database.HeaderOwner(foundNote), foundNote.Notebook_Id, *foundRevision.Header)
+
+		// Another note may have taken the old header since.
+		if !checkUniqueHeaderFree(c, uniqueHeader, noteId) {
+			return
+		}
+
+		update := database.NoteUpdate{
+			Header:        foundRevision.Header,
+			Unique_Header: &uniqueHeader,
+			Data:          foundRevision.Data,
+			Updated_At:    time.Now(),
+		}
+
+		updateNote, err := database.StoreObject.UpdateNote(c.Request.Context(), noteId, foundNote.Version, &update)
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, noteId)
+			return
+		}
+		if err == database.ErrDuplicate {
+			respondDuplicateHeader(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while upating data.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while upating data.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Record the restored content as a new revision.
+		newRevision, err := helper.AppendNoteRevision(c.Request.Context(), updateNote, userId, &revision, updateNote.Updated_At)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the revision of the note.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while recording the revision of the note.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Header("ETag", helper.NoteETag(updateNote))
+		c.JSON(http.StatusOK, gin.H{"data": updateNote, "revision": newRevision})
+		logger.Log.Printf("Message: Restored revision: %d of notes with notes id: %s as revision: %d successfully.", revision, noteId, newRevision.Revision)
+	}
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// POST /api/notebooks: create a notebook for the authenticated user, at the top or inside another notebook.
+func CreateNotebook() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the name of the notebook and the notebook to put it in.
+		var request struct {
+			Name      string `json:"name"`
+			Parent_Id string `json:"parentId"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		name, err := helper.NormalizeNotebookName(request.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid notebook name.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid notebook name.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+
+		notebook := models.Notebook{
+			ID:         primitive.NewObjectID(),
+			User_Id:    userId,
+			Name:       name,
+			Ancestors:  []string{},
+			Created_At: now,
+			Updated_At: now,
+		}
+
+		if request.Parent_Id != "" {
+			parent := findOwnedNotebook(c, userId, request.Parent_Id)
+			if parent == nil {
+				return
+			}
+
+			if len(parent.Ancestors)+2 > helper.MaxNotebookDepth {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Notebooks can be nested at most %d levels deep.", helper.MaxNotebookDepth)})
+				logger.Log.Printf("Error: Notebooks can be nested at most %d levels deep.", helper.MaxNotebookDepth)
+				c.Abort()
+				return
+			}
+
+			notebook.Parent_Id = &request.Parent_Id
+			notebook.Ancestors = append(parent.Ancestors, request.Parent_Id)
+		}
+
+		err = database.StoreObject.CreateNotebook(c.Request.Context(), &notebook)
+		if err == database.ErrDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Error: A notebook named: %s already exists there.", name)})
+			logger.Log.Printf("Error: A notebook named: %s already exists there.", name)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the notebook.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while creating the notebook.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusCreated, notebook)
+		logger.Log.Printf("Message: Successfully created notebook with notebook id: %s for user id: %s", notebook.ID.Hex(), userId)
+	}
+}
+
+// GET /api/notebooks: list the notebooks of the authenticated user, every notebook before the ones inside it.
+func GetNotebooks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		notebooks, err := database.StoreObject.ListNotebooks(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the notebooks.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the notebooks.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": notebooks})
+		logger.Log.Printf("Message: Successfully responded with the notebooks of user id: %s", userId)
+	}
+}
+
+// GET /api/notebooks/:id: get a notebook of the authenticated user.
+func GetNotebookByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		notebook := findOwnedNotebook(c, userId, c.Param("id"))
+		if notebook == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, notebook)
+		logger.Log.Printf("Message: Successfully responded with notebook with notebook id: %s", c.Param("id"))
+	}
+}
+
+// PUT /api/notebooks/:id: rename a notebook, or move it with everything inside it into another notebook or to the top.
+func UpdateNotebookByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the notebook id from the url.
+		notebookId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the new name and the new parent, an empty parent id moves the notebook to the top.
+		var request struct {
+			Name      *string `json:"name"`
+			Parent_Id *string `json:"parentId"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if request.Name == nil && request.Parent_Id == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No name or parent id provided for the notebook."})
+			logger.Log.Print("Error: No name or parent id provided for the notebook.")
+			c.Abort()
+			return
+		}
+
+		notebook := findOwnedNotebook(c, userId, notebookId)
+		if notebook == nil {
+			return
+		}
+
+		update := database.NotebookUpdate{Updated_At: time.Now().UTC().Truncate(time.Second)}
+
+		if request.Name != nil {
+			name, err := helper.NormalizeNotebookName(*request.Name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid notebook name.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Invalid notebook name.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+			update.Name = &name
+		}
+
+		if request.Parent_Id != nil {
+			var parent *models.Notebook
+			if *request.Parent_Id != "" {
+				parent = findOwnedNotebook(c, userId, *request.Parent_Id)
+				if parent == nil {
+					return
+				}
+			}
+
+			notebooks, err := database.StoreObject.ListNotebooks(c.Request.Context(), userId)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the notebooks.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while listing the notebooks.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			err = helper.CheckNotebookMove(notebooks, notebook, parent)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Notebook with notebook id: %s cannot be moved there.\n\tError: %s", notebookId, err.Error())})
+				logger.Log.Printf("Error: Notebook with notebook id: %s cannot be moved there.\n\tError: %s", notebookId, err.Error())
+				c.Abort()
+				return
+			}
+
+			update.Parent_Id = request.Parent_Id
+		}
+
+		updatedNotebook, err := database.StoreObject.UpdateNotebook(c.Request.Context(), notebookId, &update)
+		if err == database.ErrDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: A notebook with the same name already exists there."})
+			logger.Log.Printf("Error: A notebook with the same name as notebook id: %s already exists there.", notebookId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while updating the notebook.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while updating the notebook.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedNotebook)
+		logger.Log.Printf("Message: Updated notebook with notebook id: %s successfully.", notebookId)
+	}
+}
+
+// DELETE /api/notebooks/:id?mode=reparent|cascade: delete a notebook, moving what it holds up into its parent,
+// or deleting it along with the notebooks inside it and moving the notes inside them to the trash.
+func DeleteNotebookByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the notebook id from the url.
+		notebookId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var cascade bool
+		switch mode := c.Query("mode"); mode {
+		case "", "reparent":
+		case "cascade":
+			cascade = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Unknown delete mode: %s, use reparent or cascade.", mode)})
+			logger.Log.Printf("Error: Unknown delete mode: %s.", mode)
+			c.Abort()
+			return
+		}
+
+		if findOwnedNotebook(c, userId, notebookId) == nil {
+			return
+		}
+
+		changedNotes, err := database.StoreObject.DeleteNotebook(c.Request.Context(), notebookId, cascade)
+		if err == database.ErrDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: A notebook or a note inside has the same name or header as one in the parent, delete with mode cascade or rename it first."})
+			logger.Log.Printf("Error: Notebook with notebook id: %s holds a notebook or a note clashing with one in its parent.", notebookId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the notebook.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while deleting the notebook.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if cascade {
+			c.JSON(http.StatusOK, gin.H{"message": "Notebook deleted with the notebooks inside it, the notes inside them moved to the trash.", "trashedNotes": len(changedNotes)})
+		} else {
+			c.JSON(http.StatusOK, gin.H{"message": "Notebook deleted, what it held moved up into its parent.", "movedNotes": len(changedNotes)})
+		}
+		logger.Log.Printf("Message: Deleted notebook with notebook id: %s successfully, %d notes trashed or moved.", notebookId, len(changedNotes))
+	}
+}
+
+// Finds the notebook of the user, responding and returning nil when there is none.
+func findOwnedNotebook(c *gin.Context, userId string, notebookId string) *models.Notebook {
+	notebook, err := helper.GetOwnedNotebook(c.Request.Context(), userId, notebookId)
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No notebook with notebook id: %s found for the user.", notebookId)})
+		logger.Log.Printf("Error: No notebook with notebook id: %s found for user id: %s.", notebookId, userId)
+		c.Abort()
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the notebook.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the notebook.\n\tError: %s", err.Error())
+		c.Abort()
+		return nil
+	}
+
+	return notebook
+}
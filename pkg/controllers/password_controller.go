@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Response to every request for a reset mail, so that it does not tell which emails have accounts.
+const forgotPasswordMessage = "Message: If an account with the email exists, a mail to reset its password has been sent."
+
+// POST /api/auth/password/forgot: mail a token to reset the password of the account with the email.
+func ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ForgotPasswordRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		email := strings.TrimSpace(request.Email)
+		if email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No email present."})
+			logger.Log.Print("Error: No email present.")
+			c.Abort()
+			return
+		}
+
+		foundUser, err := database.StoreObject.GetUserByEmail(c.Request.Context(), email)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			logger.Log.Printf("Message: Password reset requested for unknown email: %s", email)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the user with email: %s.\n\tError: %s", email, err.Error())
+			c.Abort()
+			return
+		}
+
+		err = helper.StartPasswordReset(c.Request.Context(), foundUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while starting the password reset.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while starting the password reset of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		logger.Log.Printf("Message: Successfully started the password reset of user id: %s", foundUser.UserID)
+	}
+}
+
+// POST /api/auth/password/reset: set a new password with a mailed token, logging the user out everywhere.
+func ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ResetPasswordRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if request.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No reset token present."})
+			logger.Log.Print("Error: No reset token present.")
+			c.Abort()
+			return
+		}
+
+		if len(request.Password) < helper.MinPasswordLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Password has to be at least %d characters long.", helper.MinPasswordLength)})
+			logger.Log.Printf("Error: Password shorter than %d characters given for a reset.", helper.MinPasswordLength)
+			c.Abort()
+			return
+		}
+
+		userId, err := helper.ResetPassword(c.Request.Context(), request.Token, request.Password)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Reset token is invalid or has expired."})
+			logger.Log.Print("Error: Reset token is invalid or has expired.")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while resetting the password.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while resetting the password.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Message: Password has been reset. Log in with the new password."})
+		logger.Log.Printf("Message: Successfully reset the password of user id: %s", userId)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/auth/sessions: list the sessions of the authenticated user, the last used first,
+// marking the one the request was made from.
+func GetSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		sessions, err := database.StoreObject.ListSessions(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the sessions.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the sessions.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		data := []gin.H{}
+		for _, session := range sessions {
+			data = append(data, gin.H{"session": session, "current": session.ID.Hex() == c.GetString("tokenFamily")})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": data})
+		logger.Log.Printf("Message: Successfully responded with the sessions of user id: %s", userId)
+	}
+}
+
+// DELETE /api/auth/sessions/:id: revoke a session of the authenticated user, along with all of its tokens.
+func DeleteSessionByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the session id from the url.
+		sessionId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Sessions of other users are reported as missing, like the ones which do not exist.
+		foundSession, err := database.StoreObject.GetSessionById(c.Request.Context(), sessionId)
+		if err == nil && foundSession.User_Id != userId {
+			err = database.ErrNotFound
+		}
+		if err == nil {
+			err = database.StoreObject.DeleteSession(c.Request.Context(), sessionId)
+		}
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No session with session id: %s found.", sessionId)})
+			logger.Log.Printf("Error: No session with session id: %s found for user id: %s.", sessionId, userId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while revoking the session with session id: %s.\n\tError: %s", sessionId, err.Error())})
+			logger.Log.Printf("Error: Problem while revoking the session with session id: %s.\n\tError: %s", sessionId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked.", "data": foundSession})
+		logger.Log.Printf("Message: Successfully revoked session with session id: %s of user id: %s", sessionId, userId)
+	}
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Header a password protected share link takes its password in, keeping it out of the url and the logs.
+const shareLinkPasswordHeader = "X-Share-Password"
+
+// POST /api/notes/:id/links: create a link opening the note without an account, with a role, an optional
+// password, expiry and number of views, responding with the link once.
+func CreateShareLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		noteId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var request models.CreateShareLinkRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		err = helper.ValidateShareLinkRequest(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid share link request.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid share link request of user id: %s.\n\tError: %s", userId, err.Error())
+			c.Abort()
+			return
+		}
+
+		// Only the owner decides who the note is shared with, links included.
+		_, ok := findNoteWithRole(c, noteId, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+
+		token, link, err := helper.CreateShareLink(c.Request.Context(), noteId, userId, &request)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the share link.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while creating the share link of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Share link created. Keep it safe, it is not shown again.", "data": gin.H{"url": helper.ShareLinkURL(token), "token": token, "link": link}})
+		logger.Log.Printf("Message: Successfully created share link id: %s of the note with note id: %s by user id: %s", link.ID.Hex(), noteId, userId)
+	}
+}
+
+// GET /api/notes/:id/links: list the links of a note which have not expired, the newest first, without their tokens.
+func GetShareLinks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		noteId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findNoteWithRole(c, noteId, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+
+		links, err := database.StoreObject.ListShareLinks(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the share links.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the share links of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": links})
+		logger.Log.Printf("Message: Successfully responded with the share links of the note with note id: %s", noteId)
+	}
+}
+
+// DELETE /api/notes/:id/links/:linkId: revoke a link of a note, it stops working at once.
+func DeleteShareLinkByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		noteId := c.Param("id")
+		linkId := c.Param("linkId")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findNoteWithRole(c, noteId, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+
+		err := database.StoreObject.DeleteShareLink(c.Request.Context(), noteId, linkId)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No share link with id: %s found for the note.", linkId)})
+			logger.Log.Printf("Error: No share link with id: %s found for the note with note id: %s.", linkId, noteId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while revoking the share link with id: %s.\n\tError: %s", linkId, err.Error())})
+			logger.Log.Printf("Error: Problem while revoking the share link with id: %s.\n\tError: %s", linkId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Share link revoked."})
+		logger.Log.Printf("Message: Successfully revoked share link id: %s of the note with note id: %s by user id: %s", linkId, noteId, userId)
+	}
+}
+
+// GET /s/:token: open a share link without an account, with its password in the X-Share-Password header
+// if it has one, responding with the note and the role the link gives on it.
+func OpenShareLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The token is a secret, responses to it are not to be kept by caches.
+		c.Header("Cache-Control", "no-store")
+		c.Header("Referrer-Policy", "no-referrer")
+
+		link, err := helper.FindShareLink(c.Request.Context(), c.Param("token"))
+		if err == helper.ErrInvalidShareLink {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: The share link is invalid or has expired."})
+			logger.Log.Print("Error: Invalid or expired share link opened.")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the share link.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the share link.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if link.Password_Protected {
+			password := c.GetHeader(shareLinkPasswordHeader)
+			if password == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Error: The share link needs a password, send it in the %s header.", shareLinkPasswordHeader)})
+				logger.Log.Printf("Error: Share link id: %s opened without its password.", link.ID.Hex())
+				c.Abort()
+				return
+			}
+
+			wait, err := helper.CheckShareLinkPasswordAllowed(c.Request.Context(), link)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the wrong passwords.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while checking the wrong passwords of share link id: %s.\n\tError: %s", link.ID.Hex(), err.Error())
+				c.Abort()
+				return
+			}
+
+			if wait > 0 {
+				seconds := int(math.Ceil(wait.Seconds()))
+				c.Header("Retry-After", strconv.Itoa(seconds))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Error: Too many wrong passwords. Try again in %d seconds.", seconds)})
+				logger.Log.Printf("Error: Share link id: %s refused to ip address: %s for the next %d seconds.", link.ID.Hex(), c.ClientIP(), seconds)
+				c.Abort()
+				return
+			}
+
+			valid, err := helper.VerifyShareLinkPassword(c.Request.Context(), link, password)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the password.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while checking the password of share link id: %s.\n\tError: %s", link.ID.Hex(), err.Error())
+				c.Abort()
+				return
+			}
+
+			if !valid {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Wrong password for the share link."})
+				logger.Log.Printf("Error: Wrong password for share link id: %s from ip address: %s.", link.ID.Hex(), c.ClientIP())
+				c.Abort()
+				return
+			}
+		}
+
+		sharedNote, err := helper.OpenShareLink(c.Request.Context(), link)
+		if err == helper.ErrInvalidShareLink {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: The share link is invalid or has expired."})
+			logger.Log.Printf("Error: Share link id: %s has run out of views or its note is gone.", link.ID.Hex())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while opening the share link.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while opening share link id: %s.\n\tError: %s", link.ID.Hex(), err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": sharedNote})
+		logger.Log.Printf("Message: Successfully opened share link id: %s of the note with note id: %s", link.ID.Hex(), link.Note_Id)
+	}
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/notes/:id/tags: add tags to a note, for its owner and editors.
+func AddNoteTags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the tags to add.
+		var request struct {
+			Tags []string `json:"tags"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if len(request.Tags) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No tags provided to add to the note."})
+			logger.Log.Print("Error: No tags provided to add to the note.")
+			c.Abort()
+			return
+		}
+
+		foundNote := findTaggableNote(c, noteId, userId)
+		if foundNote == nil {
+			return
+		}
+
+		tags, err := helper.AddTags(foundNote.Tags, request.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tags for the note.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tags for the note.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		updatedNote := setNoteTags(c, foundNote, tags)
+		if updatedNote == nil {
+			return
+		}
+
+		c.Header("ETag", helper.NoteETag(updatedNote))
+		c.JSON(http.StatusOK, updatedNote)
+		logger.Log.Printf("Message: Successfully added tags to the note with note id: %s", noteId)
+	}
+}
+
+// DELETE /api/notes/:id/tags/:tag: remove a tag from a note, for its owner and editors.
+func RemoveNoteTag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id and the tag from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		tag, err := helper.NormalizeTag(c.Param("tag"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tag.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tag.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		foundNote := findTaggableNote(c, noteId, userId)
+		if foundNote == nil {
+			return
+		}
+
+		tags, removed := helper.RemoveTag(foundNote.Tags, tag)
+		if !removed {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: Notes with note id: %s is not tagged with: %s", noteId, tag)})
+			logger.Log.Printf("Error: Notes with note id: %s is not tagged with: %s", noteId, tag)
+			c.Abort()
+			return
+		}
+
+		updatedNote := setNoteTags(c, foundNote, tags)
+		if updatedNote == nil {
+			return
+		}
+
+		c.Header("ETag", helper.NoteETag(updatedNote))
+		c.JSON(http.StatusOK, updatedNote)
+		logger.Log.Printf("Message: Successfully removed tag: %s from the note with note id: %s", tag, noteId)
+	}
+}
+
+// GET /api/tags: list the tags of the notes of the authenticated user, with the number of notes having each.
+func GetTags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		tagCounts, err := database.StoreObject.ListTags(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the tags.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the tags.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": tagCounts})
+		logger.Log.Printf("Message: Successfully responded with the tags of user id: %s", userId)
+	}
+}
+
+// PUT /api/tags/:tag: rename a tag on every note of the authenticated user.
+func RenameTag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the new name of the tag.
+		var request struct {
+			Name string `json:"name"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		tag, err := helper.NormalizeTag(c.Param("tag"))
+		if err == nil {
+			request.Name, err = helper.NormalizeTag(request.Name)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tag.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tag.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		replaceTags(c, userId, []string{tag}, request.Name)
+	}
+}
+
+// POST /api/tags/merge: merge tags into one on every note of the authenticated user.
+func MergeTags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		// Get the tags to merge, and the tag to merge them into.
+		var request struct {
+			Tags []string `json:"tags"`
+			Into string   `json:"into"`
+		}
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if len(request.Tags) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No tags provided to merge."})
+			logger.Log.Print("Error: No tags provided to merge.")
+			c.Abort()
+			return
+		}
+
+		tags, err := helper.NormalizeTags(request.Tags)
+		if err == nil {
+			request.Into, err = helper.NormalizeTag(request.Into)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tag.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tag.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		replaceTags(c, userId, tags, request.Into)
+	}
+}
+
+// Replaces the old tags by the new one on the notes of the user and responds with the number of notes changed.
+func replaceTags(c *gin.Context, userId string, oldTags []string, newTag string) {
+	changedNotes, err := database.StoreObject.ReplaceTags(c.Request.Context(), userId, oldTags, newTag)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while replacing the tags: %v by: %s.\n\tError: %s", oldTags, newTag, err.Error())})
+		logger.Log.Printf("Error: Problem while replacing the tags: %v by: %s.\n\tError: %s", oldTags, newTag, err.Error())
+		c.Abort()
+		return
+	}
+
+	if len(changedNotes) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No notes of user id: %s are tagged with: %v", userId, oldTags)})
+		logger.Log.Printf("Error: No notes of user id: %s are tagged with: %v", userId, oldTags)
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Tags: %v replaced by: %s.", oldTags, newTag), "updatedNotes": len(changedNotes)})
+	logger.Log.Printf("Message: Successfully replaced the tags: %v by: %s on %d notes of user id: %s", oldTags, newTag, len(changedNotes), userId)
+}
+
+// Finds the note whose tags the user wants to change, checking that the user may edit it.
+// Responds and returns nil when the tags cannot be changed.
+func findTaggableNote(c *gin.Context, noteId string, userId string) *models.NoteData {
+	foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return nil
+	}
+
+	allowed, ok := checkNoteRole(c, foundNote, userId, models.EditorRole)
+	if !ok {
+		return nil
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to change the tags of the notes with note id: %s", userId, noteId)})
+		logger.Log.Printf("Error: User with user id: %s is not allowed to change the tags of the notes with note id: %s", userId, noteId)
+		c.Abort()
+		return nil
+	}
+
+	// If the client changed an older version of the note, send status precondition failed.
+	if !checkIfMatch(c, foundNote) {
+		return nil
+	}
+
+	return foundNote
+}
+
+// Stores the tags of the note, responding and returning nil when that fails.
+func setNoteTags(c *gin.Context, foundNote *models.NoteData, tags []string) *models.NoteData {
+	noteId := foundNote.ID.Hex()
+
+	updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		logger.Log.Printf("Error: Problem while storing the update time tamp for the note.\n\tError: %s", err.Error())
+		c.Abort()
+		return nil
+	}
+
+	update := database.NoteUpdate{Tags: &tags, Updated_At: updatedAt}
+
+	updatedNote, err := database.StoreObject.UpdateNote(c.Request.Context(), noteId, foundNote.Version, &update)
+	if err == database.ErrVersionConflict {
+		respondVersionConflict(c, noteId)
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while updating the tags of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: Problem while updating the tags of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return nil
+	}
+
+	return updatedNote
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/trash: list the notes in the trash of the authenticated user, the last deleted first,
+// with the time each is purged at. With ?workspace=id the trash of the workspace is listed instead.
+func GetTrash() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		trashedNotes, ok := listTrash(c, userId)
+		if !ok {
+			return
+		}
+
+		data := []gin.H{}
+		for _, note := range trashedNotes {
+			data = append(data, gin.H{"note": note, "purgeAt": helper.TrashPurgeTime(*note.Deleted_At)})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": data})
+		logger.Log.Printf("Message: Successfully responded with the trash of user id: %s", userId)
+	}
+}
+
+// POST /api/trash/:id/restore: take a note out of the trash, back into its notebook.
+func RestoreTrashedNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundNote := findTrashedNote(c, noteId, userId)
+		if foundNote == nil {
+			return
+		}
+
+		// A note whose notebook went away meanwhile comes back outside of notebooks.
+		notebookId := foundNote.Notebook_Id
+		if notebookId != nil {
+			_, err := database.StoreObject.GetNotebookById(c.Request.Context(), *notebookId)
+			if err == database.ErrNotFound {
+				notebookId = nil
+			} else if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the notebook of the note.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while finding the notebook of the note.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		var header string
+		if foundNote.Header != nil {
+			header = *foundNote.Header
+		}
+
+		uniqueHeader := database.UniqueHeader(database.HeaderOwner(foundNote), notebookId, header)
+
+		restoredNote, err := database.StoreObject.RestoreNote(c.Request.Context(), noteId, foundNote.Version, uniqueHeader, notebookId)
+		if err == database.ErrDuplicate {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: Another note with the same header exists now, rename or delete it first."})
+			logger.Log.Printf("Error: Note with note id: %s cannot be restored, another note has its header.", noteId)
+			c.Abort()
+			return
+		}
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, noteId)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while restoring the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while restoring the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Header("ETag", helper.NoteETag(restoredNote))
+		c.JSON(http.StatusOK, restoredNote)
+		logger.Log.Printf("Message: Successfully restored note with note id: %s from the trash of user id: %s", noteId, userId)
+	}
+}
+
+// DELETE /api/trash/:id: delete a note in the trash for good, along with its revisions.
+func DeleteTrashedNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundNote := findTrashedNote(c, noteId, userId)
+		if foundNote == nil {
+			return
+		}
+
+		if !deleteNoteForGood(c, foundNote) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Note deleted for good.", "data": foundNote})
+		logger.Log.Printf("Message: Successfully deleted note with note id: %s from the trash of user id: %s", noteId, userId)
+	}
+}
+
+// DELETE /api/trash: empty the trash of the authenticated user, or with ?workspace=id the one of the workspace.
+func EmptyTrash() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		trashedNotes, ok := listTrash(c, userId)
+		if !ok {
+			return
+		}
+
+		for i := range trashedNotes {
+			if !deleteNoteForGood(c, &trashedNotes[i]) {
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Trash emptied.", "deletedNotes": len(trashedNotes)})
+		logger.Log.Printf("Message: Successfully emptied the trash of user id: %s, %d notes deleted.", userId, len(trashedNotes))
+	}
+}
+
+// Lists the trash of the user, or of the workspace asked for in the query which only its admins look after.
+// Responds and returns false when it cannot be listed.
+func listTrash(c *gin.Context, userId string) ([]models.NoteData, bool) {
+	var trashedNotes []models.NoteData
+	var err error
+
+	if workspaceId := c.Query("workspace"); workspaceId != "" {
+		_, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return nil, false
+		}
+
+		trashedNotes, err = database.StoreObject.ListWorkspaceTrash(c.Request.Context(), workspaceId)
+	} else {
+		trashedNotes, err = database.StoreObject.ListTrash(c.Request.Context(), userId)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the trash.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while listing the trash.\n\tError: %s", err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	return trashedNotes, true
+}
+
+// Finds the note in the trash of the user, responding and returning nil when there is none.
+func findTrashedNote(c *gin.Context, noteId string, userId string) *models.NoteData {
+	foundNote, err := database.StoreObject.GetTrashedNote(c.Request.Context(), noteId)
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No note with note id: %s found in the trash.", noteId)})
+		logger.Log.Printf("Error: No note with note id: %s found in the trash.", noteId)
+		c.Abort()
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note in the trash.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the note in the trash.\n\tError: %s", err.Error())
+		c.Abort()
+		return nil
+	}
+
+	// The notes of a workspace are in the trash of the workspace, which its admins look after.
+	isOwner, ok := checkNoteRole(c, foundNote, userId, models.OwnerRole)
+	if !ok {
+		return nil
+	}
+	if !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Note with note id: %s is not in the trash of user with user id: %s", noteId, userId)})
+		logger.Log.Printf("Error: Note with note id: %s is not in the trash of user with user id: %s", noteId, userId)
+		c.Abort()
+		return nil
+	}
+
+	return foundNote
+}
+
+// Deletes the note with its revisions and share links, responding and returning false when that fails.
+func deleteNoteForGood(c *gin.Context, note *models.NoteData) bool {
+	noteId := note.ID.Hex()
+
+	err := database.StoreObject.DeleteNote(c.Request.Context(), noteId, note.Version)
+	if err == database.ErrVersionConflict {
+		respondVersionConflict(c, noteId)
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: Problem while deleting the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return false
+	}
+
+	// The revisions and share links of the note go with it.
+	err = database.StoreObject.DeleteNoteRevisions(c.Request.Context(), noteId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the revisions of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: Problem while deleting the revisions of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return false
+	}
+
+	err = database.StoreObject.DeleteNoteShareLinks(c.Request.Context(), noteId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the share links of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+		logger.Log.Printf("Error: Problem while deleting the share links of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		c.Abort()
+		return false
+	}
+
+	return true
+}
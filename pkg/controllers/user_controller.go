@@ -1,771 +1,1057 @@
-package controllers
-
-import (
-	"fmt"
-	"net/http"
-	"time"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
-	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-// GET /api/notes: get a list of all notes for the authenticated user.
-
-func GetAllNotes() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the authenticated user id.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
-			logger.Log.Print("Error: No user id present.")
-			c.Abort()
-			return
-		}
-
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error:": "Error: Problem while converting user id to string."})
-			logger.Log.Print("Error: Problem while converting user if to string.")
-			c.Abort()
-			return
-		}
-
-		// Get the note collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the note collection.\n\tError: %s", err)
-			c.Abort()
-			return
-		}
-
-		// Create a filter.
-		filter := bson.D{}
-
-		// Create a cursor for the whole document.
-		cursor, err := noteCollection.Find(database.MongoObject.Ctx, filter)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while creating cursor for the whole collection."})
-			logger.Log.Printf("Error: Problem while creating cursor for the whole collection.")
-			c.Abort()
-			return
-		}
-		defer cursor.Close(database.MongoObject.Ctx)
-
-		// Declare the list which will contain all the documents.
-		var foundDocuments []models.NoteData
-
-		// Iterate through each document.
-		for cursor.Next(database.MongoObject.Ctx) {
-			// Decode the document.
-			var foundDocument models.NoteData
-
-			if err := cursor.Decode(&foundDocument); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding document.\n\tError: %s", err.Error())})
-				logger.Log.Printf("Error: Problem while decoding document.\n\tError: %s", err.Error())
-				c.Abort()
-				return
-			}
-
-			if *foundDocument.User_Id == userId || *foundDocument.Sharable {
-				foundDocuments = append(foundDocuments, foundDocument)
-			}
-		}
-
-		// Check for any error during cursor iteration.
-		if err := cursor.Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while iterating through the collection using cursor.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while iterating through the collection using cursor.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Send a response ok with the list of documents.
-		c.JSON(http.StatusOK, foundDocuments)
-		logger.Log.Println("Message: Successfully responded with the list of all notes for the authenticated user.")
-	}
-}
-
-// GET /api/notes/:id: get a note by ID for the authenticated user.
-func GetNotesByID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the note id from the url.
-		notesId := c.Param("id")
-
-		// Get the authenticated user id.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
-			logger.Log.Print("Error: No user id present.")
-			c.Abort()
-			return
-		}
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error:": "Error: Problem while converting user id to string."})
-			logger.Log.Print("Error: Problem while converting user if to string.")
-			c.Abort()
-			return
-		}
-
-		// Get the note collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the note collection.\n\tError: %s", err)
-			c.Abort()
-			return
-		}
-
-		// Make the filter for search --> use the note id.
-		noteIdPrimitive, err := primitive.ObjectIDFromHex(notesId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while converting notes id to primitive object.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while converting notes id to primitve object.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		filter := bson.D{{Key: "_id", Value: noteIdPrimitive}}
-
-		// Decode and bind it in a note user.
-		var note models.NoteData
-
-		err = noteCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&note)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such note present. Problem while decoding the note.\n\tError: %s", err.Error()), "data": notesId})
-			logger.Log.Printf("Error: No such note present. Problem while decoding the note.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Check whether user id in the decoded version is same as the authenticated user id.
-		// If yes, then send status ok and send the data with header field.
-		// If no, then check if sharable is true.
-		// If yes, then send status ok and send the data with header field.
-		// Otherwise, send bad status.
-		if userId == *note.User_Id {
-			c.JSON(http.StatusOK, note)
-			logger.Log.Printf("Message: Note belongs to user with user id: %s and present in the db with notes id: %s. It is successfully shared.", userId, notesId)
-			c.Abort()
-			return
-		} else {
-			if *note.Sharable {
-				c.JSON(http.StatusOK, note)
-				logger.Log.Printf("Message: Note does not belong to user with user with user id: %s, but sharable and thus successfully shared notes with notes id: %s", userId, notesId)
-				c.Abort()
-				return
-			} else {
-				c.JSON(http.StatusBadRequest, nil)
-				logger.Log.Printf("Message: Note does not belong to user with user id: %s and it is also not sharable publically. Hence, it cannot be shared.", userId)
-				c.Abort()
-				return
-			}
-		}
-	}
-}
-
-// POST /api/notes: create a new note for the authenticated user.
-func CreateNotes() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Bind the note sent in the request body.
-		var note models.NoteData
-
-		err := c.BindJSON(&note)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while binding note from json.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while binding note from json.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Get the user id of the authenticated user from the middleware.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Problem while getting user id from the middleware."})
-			logger.Log.Printf("Error: Problem while getting user id from the middleware.")
-			c.Abort()
-			return
-		}
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id to string."})
-			logger.Log.Printf("Error: Problem while converting user id to string.")
-			c.Abort()
-			return
-		}
-
-		// Make the unqiue header.
-		unqiueHeader := fmt.Sprintf("%s%s", userId, *note.Header)
-
-		// Open the note collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while trying to get the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while trying to get the note collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Make the filter.
-		filter := bson.D{{Key: "uniqueHeader", Value: unqiueHeader}}
-
-		// Find the note if already present with the same unique header.
-		findErr := noteCollection.FindOne(database.MongoObject.Ctx, filter)
-
-		// If no document found, can create the note.
-		if findErr.Err() == mongo.ErrNoDocuments {
-			note.ID = primitive.NewObjectID()
-			note.User_Id = &userId
-			note.Unique_Header = &unqiueHeader
-
-			emailAny, exists := c.Get("email")
-			if !exists {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No email id provided for the user."})
-				logger.Log.Printf("Error: No email id provided for the user.")
-				c.Abort()
-				return
-			}
-			email, ok := emailAny.(string)
-			if !ok {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting email to string."})
-				logger.Log.Printf("Error: Problem while converting email to string.")
-				c.Abort()
-				return
-			}
-			note.Email = &email
-
-			createdAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-				logger.Log.Printf("\nError: Problem while storing the creation time tamp for the user.\n\tError: %s", err.Error())
-				c.Abort()
-				return
-			}
-			note.Created_At = createdAt
-
-			updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-				logger.Log.Printf("\nError: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
-				c.Abort()
-				return
-			}
-			note.Updated_At = updatedAt
-
-			// Insert the document.
-			_, err = noteCollection.InsertOne(database.MongoObject.Ctx, note)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while inserting the new document.\n\tError: %s", err.Error())})
-				logger.Log.Printf("Error: Problem while inserting the new document.\n\tError: %s", err.Error())
-				c.Abort()
-				return
-			}
-
-			// Find the inserted document.
-			var foundNote models.NoteData
-			err = noteCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundNote)
-
-			// If no such note found, then data not inserted.
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: No such note yet has been created.\n\tError: %s", err.Error())})
-					logger.Log.Printf("Error: No such note yet has been created.\n\tError: %s", err.Error())
-					c.Abort()
-					return
-				} else {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())})
-					logger.Log.Printf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())
-					c.Abort()
-					return
-				}
-			}
-
-			// Note has been found, send status ok and send it.
-			c.JSON(http.StatusOK, gin.H{"data": foundNote, "message": fmt.Sprintf("Message: Successfully created new note with note id: %s and uniquq header: %s", foundNote.ID, *foundNote.Unique_Header)})
-			logger.Log.Printf("Message:  Successfully created new note with note id: %s and unique header: %s", foundNote.ID, *foundNote.Unique_Header)
-			return
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Same note already exists in the database."})
-			logger.Log.Printf("Error: Same note already exists in the database.")
-			c.Abort()
-			return
-		}
-	}
-}
-
-// PUT /api/notes/:id: update an existing note by ID for the authenticated user.
-
-func UpdateNotesByID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Getting the notes id from the url.
-		notesId := c.Param("id")
-
-		// Getting the user id from the authenticator.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: The user-id is not passed from the middleware the create handler through context."})
-			logger.Log.Print("Error: The user-id is not passed from the middleware the create handler through context.")
-			c.Abort()
-			return
-		}
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting any to string."})
-			logger.Log.Print("Error: Problem while converting any to string.")
-			c.Abort()
-			return
-		}
-
-		// Get the notes collection.
-		notesCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while trying to open notes collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Find whether there is any notes present with the passed notes id in the url.
-		noteIdPrimitive, err := primitive.ObjectIDFromHex(notesId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while converting notes id to primitive object.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while converting notes id to primitve object.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		filter := bson.D{{Key: "_id", Value: noteIdPrimitive}}
-
-		var foundNotes models.NoteData
-
-		err = notesCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundNotes)
-
-		// If no notes present, send bad request.
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No notes with id: %s is present in the database.", notesId)})
-			logger.Log.Printf("Error: No notes with id: %s is present in the database.", notesId)
-			c.Abort()
-			return
-		}
-
-		// If authenticator user id is not same as document user id, send status bad request.
-		if *foundNotes.User_Id != userId {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Notes is not accessible to the user with user id: %s", userId)})
-			logger.Log.Printf("Error: Notes is not accessible to the user with user id: %s", userId)
-			c.Abort()
-			return
-		}
-
-		// If same, then the user has access to the note document and can update.
-		// Bind the json and extract the data into the note struct.
-		var note *models.NoteData
-
-		err = c.BindJSON(&note)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while binding the data to the note struct.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Fill in the other fields in the note which needs to be changed.
-		updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("Error: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		note.Updated_At = updatedAt
-
-		// Make the update object.
-		var updateMiniObj primitive.D
-
-		if note.Header != nil {
-			updateMiniObj = append(updateMiniObj, bson.E{Key: "header", Value: note.Header})
-
-			uniqueHeader := fmt.Sprintf("%s%s", userId, *note.Header)
-			updateMiniObj = append(updateMiniObj, bson.E{Key: "uniqueHeader", Value: uniqueHeader})
-		}
-
-		if note.Data != nil {
-			updateMiniObj = append(updateMiniObj, bson.E{Key: "notesData", Value: note.Data})
-		}
-
-		if note.Sharable != nil {
-			updateMiniObj = append(updateMiniObj, bson.E{Key: "sharable", Value: note.Sharable})
-		}
-
-		updateMiniObj = append(updateMiniObj, bson.E{Key: "updatedAt", Value: note.Updated_At})
-
-		// Make the options for the find and update operation.
-		options := options.FindOneAndUpdate().SetReturnDocument(options.After)
-
-		// Make the update.
-		var updateNote models.NoteData
-
-		updateObj := primitive.D{
-			{
-				Key: "$set", Value: updateMiniObj,
-			},
-		}
-
-		err = notesCollection.FindOneAndUpdate(database.MongoObject.Ctx, filter, updateObj, options).Decode(&updateNote)
-
-		// If could not, send bad request.
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while upating data.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while upating data.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// If update successful Send status ok.
-		c.JSON(http.StatusOK, updateNote)
-		logger.Log.Printf("Message: Updated notes with notes id: %s successfully.", notesId)
-	}
-}
-
-// DELETE /api/notes/:id: delete a note by ID for the authenticated user.
-
-func DeleteNotesByID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the notes id.
-		noteId := c.Param("id")
-
-		// Get the user id from the authentication.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
-			logger.Log.Print("Error: No user id given for authentication.")
-			c.Abort()
-			return
-		}
-
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
-			logger.Log.Print("Error: Problem while converting user id from any to string.")
-			c.Abort()
-			return
-		}
-
-		// Get access to the user collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Make the filter.
-		noteIdPrimitive, err := primitive.ObjectIDFromHex(noteId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while converting notes id to primitive object.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while converting notes id to primitve object.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		filter := bson.D{{Key: "_id", Value: noteIdPrimitive}}
-
-		// Find the object and decode it.
-		var foundNote models.NoteData
-
-		err = noteCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundNote)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
-			logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
-			c.Abort()
-			return
-		}
-
-		// If decoded userid is equivalent to the authenticated user id delete it and send status ok with the deleted notes details.
-		// If not, then send bad request.
-		if *foundNote.User_Id == userId {
-			_, err = noteCollection.DeleteOne(database.MongoObject.Ctx, filter)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the note with note id: %s by the user with user id: %s.\n\tError: %s", noteId, userId, err.Error())})
-				logger.Log.Printf("Error: Problem while deleting the note with note id: %s by the user with user id: %s.\n\tError: %s", noteId, userId, err.Error())
-				c.Abort()
-				return
-			}
-
-			c.JSON(http.StatusOK, foundNote)
-			logger.Log.Printf("Message: Successfully deleted note with note id: %s by the user with user id: %s", noteId, userId)
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to delete the notes with note id: %s", userId, noteId)})
-			logger.Log.Printf("Error: User with user id: %s is not allowed to delete the notes with note id: %s", userId, noteId)
-			c.Abort()
-			return
-		}
-	}
-}
-
-// POST /api/notes/:id/share: share a note with another user for the authenticated user.
-func ShareNotesByID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the note id from the url.
-		noteId := c.Param("id")
-
-		// Get the sender user id.
-		senderUserIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
-			logger.Log.Print("Error: No user id given for authentication.")
-		}
-		senderUserId, ok := senderUserIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
-			logger.Log.Print("Error: Problem while converting user id from any to string.")
-		}
-
-		// Get the receiver user id.
-		type receiver struct {
-			UserId string `json:"userId"`
-		}
-		var receiverObj receiver
-
-		err := c.BindJSON(&receiverObj)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No user id with whom the data is to be shared is given./Problem while trying to bind the data.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: No user id with whom the data is to be shared is given./Problem while trying to bind the data.\n\tError: %s", err.Error())
-		}
-
-		receiverUserId := receiverObj.UserId
-		fmt.Println(senderUserId, receiverUserId)
-
-		// If the sender and receiver is same, sent bad status request.
-		if senderUserId == receiverUserId {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Sender and receiver cannot be same."})
-			logger.Log.Print("Error: Sender and receiver cannot be same.")
-			c.Abort()
-			return
-		}
-
-		// Get the note collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())
-		}
-
-		// Make the filter to use note id to search in the note database.
-		noteIdPrimitve, err := primitive.ObjectIDFromHex(noteId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while trying to convert note id from string to primitive object.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while trying to convert note id from string to primitive object.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		filter := bson.D{{Key: "_id", Value: noteIdPrimitve}}
-
-		// Find whether the note present in the database or not.
-		var foundNote models.NoteData
-
-		err = noteCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&foundNote)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note with note id: %s.\n\tError: %s", noteId, err.Error())})
-			logger.Log.Printf("Error: Problem while finding the note with note id: %s.\n\tError: %s", noteId, err.Error())
-			c.Abort()
-			return
-		}
-
-		// Get the necessary receiver user details from the database.
-		userCollection, err := database.MongoObject.GetUserCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the user collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the user collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		filter = bson.D{{Key: "userId", Value: receiverUserId}}
-
-		var receiverUser models.UserDataServer
-
-		err = userCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&receiverUser)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Receiver does not exist in the database./Problem while decoding the found data.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Receiver does not exist in the database./Problem while decoding the found data.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Create the insert note data.
-		var insertNote models.NoteData
-		insertNote.ID = primitive.NewObjectID()
-		insertNote.User_Id = &receiverUserId
-		insertNote.Header = foundNote.Header
-
-		uniqueHeader := fmt.Sprintf("%s%s", *insertNote.User_Id, *insertNote.Header)
-		insertNote.Unique_Header = &uniqueHeader
-
-		// Check whether the any notes already present in the database with the same unique header by the receiver user.
-		filter = bson.D{{Key: "userId", Value: insertNote.User_Id}, {Key: "uniqueHeader", Value: insertNote.Unique_Header}}
-
-		findResult := noteCollection.FindOne(database.MongoObject.Ctx, filter)
-		if findResult.Err() != mongo.ErrNoDocuments {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: User: %s already got a note with header: %s", *insertNote.User_Id, *insertNote.Header)})
-			logger.Log.Printf("Error: User: %s already got a note with header: %s", *insertNote.User_Id, *insertNote.Header)
-			c.Abort()
-			return
-		}
-
-		insertNote.Email = receiverUser.Email
-		insertNote.Data = foundNote.Data
-
-		sharable := false
-		insertNote.Sharable = &sharable
-
-		createdAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("Error: Problem while storing the creation time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		insertNote.Created_At = createdAt
-
-		updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
-			logger.Log.Printf("Error: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		insertNote.Updated_At = updatedAt
-
-		_, err = noteCollection.InsertOne(database.MongoObject.Ctx, insertNote)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			logger.Log.Printf("Error: Problem while inserting new data into the notes collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Make the filter to find the inserted note.
-		filter = bson.D{{Key: "_id", Value: insertNote.ID}}
-
-		var insertedFoundNote models.NoteData
-
-		err = noteCollection.FindOne(database.MongoObject.Ctx, filter).Decode(&insertedFoundNote)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: No note found./Problem while decoding the found data.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: No note found./Problem while decoding the found data.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		c.JSON(http.StatusOK, insertedFoundNote)
-		logger.Log.Printf("Message: Successful creation of new note document with note id: %s", insertedFoundNote.ID)
-	}
-}
-
-// GET /api/search?q=:query: search for notes based on keywords for the authenticated user.
-
-func SearchNotesByKeywords() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the user id.
-		userIdAny, exists := c.Get("userId")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
-			logger.Log.Print("Error: No user id given for authentication.")
-			c.Abort()
-			return
-		}
-		userId, ok := userIdAny.(string)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
-			logger.Log.Print("Error: Problem while converting user id from any to string.")
-			c.Abort()
-			return
-		}
-
-		// Get the query.
-		query := c.Query("q")
-
-		// Get the note collection.
-		noteCollection, err := database.MongoObject.GetNoteCollection()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while getting the note collection.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Create a index.
-		_, err = noteCollection.Indexes().CreateOne(
-			database.MongoObject.Ctx,
-			mongo.IndexModel{
-				Keys: bson.D{{Key: "notesData", Value: 1}},
-			},
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while trying to create a index.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while trying to create a index.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-
-		// Create a filter.
-		filter := bson.D{
-			{Key: "$and", Value: bson.A{
-				bson.D{
-					{Key: "$or", Value: bson.A{
-						bson.D{{Key: "userId", Value: userId}},
-						bson.D{{Key: "sharable", Value: true}},
-					}},
-				},
-				bson.D{{
-					Key: "notesData", Value: bson.D{{
-						Key: "$regex", Value: fmt.Sprintf(".*%s.*", query),
-					}},
-				}},
-			}},
-		}
-
-		// Get the cursor.
-		cursor, err := noteCollection.Find(database.MongoObject.Ctx, filter)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the cursor.\n\tError: %s", err.Error())})
-			logger.Log.Printf("Error: Problem while creating the cursor.\n\tError: %s", err.Error())
-			c.Abort()
-			return
-		}
-		defer cursor.Close(database.MongoObject.Ctx)
-
-		// Declare the list of notes.
-		var foundNotes []models.NoteData
-
-		// Iterate through the cursor
-		for cursor.Next(database.MongoObject.Ctx) {
-			var foundNote models.NoteData
-
-			err := cursor.Decode(&foundNote)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())})
-				logger.Log.Printf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())
-			}
-
-			// Append each found note.
-			foundNotes = append(foundNotes, foundNote)
-		}
-
-		if foundNotes == nil {
-			c.JSON(http.StatusOK, gin.H{"error": "Error: No such note contained the passed keywords in it."})
-			logger.Log.Println("Error: No such note contained the passed keywords in it.")
-			c.Abort()
-			return
-		}
-
-		c.JSON(http.StatusOK, foundNotes)
-		logger.Log.Printf("Message: Successfully find all the notes with the keyword: %s", query)
-	}
-}
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/searchindex"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET /api/notes?filter=&sort=&order=&limit=&cursor=&fields=: get a page of the notes of the authenticated user.
+// filter is owned, shared or public, all the notes the user can view by default. workspace lists every note
+// of a workspace the user is a member of instead, the same for all of its members.
+// sort is createdAt, updatedAt or header, order is asc or desc, and cursor is the next token of the previous page.
+// fields is a comma separated list of the fields to send, all of them by default.
+
+func GetAllNotes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the authenticated user id.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error:": "Error: Problem while converting user id to string."})
+			logger.Log.Print("Error: Problem while converting user if to string.")
+			c.Abort()
+			return
+		}
+
+		// Build the listing query from the url.
+		query, err := parseNoteListQuery(c, userId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid listing parameters.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid listing parameters.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Only the members of a workspace can list its notes.
+		if query.Filter.Workspace_Id != "" {
+			_, ok := findWorkspaceWithRole(c, query.Filter.Workspace_Id, userId, models.WorkspaceViewerRole)
+			if !ok {
+				return
+			}
+		}
+
+		// Ask for one note more than the page holds, to know whether there is a next page.
+		pageSize := query.Limit
+		query.Limit = pageSize + 1
+
+		foundDocuments, err := database.StoreObject.ListNotes(c.Request.Context(), query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the notes.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the notes.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		nextToken := ""
+		if len(foundDocuments) > pageSize {
+			foundDocuments = foundDocuments[:pageSize]
+
+			nextToken, err = helper.EncodeNotesPageToken(query, &foundDocuments[pageSize-1])
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while making the next page token.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while making the next page token.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// If the client already has the same page, tell it so instead of sending it again.
+		etag := helper.NotesListETag(foundDocuments, strings.Join(query.Fields, ","), nextToken)
+		c.Header("ETag", etag)
+
+		if helper.MatchesETagWeakly(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			logger.Log.Println("Message: List of all notes for the authenticated user has not been modified.")
+			return
+		}
+
+		var data interface{} = foundDocuments
+		if query.Fields != nil {
+			data, err = helper.ProjectNoteFields(foundDocuments, query.Fields)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while leaving out the fields not asked for.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while leaving out the fields not asked for.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		response := gin.H{"data": data}
+		if nextToken != "" {
+			response["next"] = nextToken
+		}
+
+		// Send a response ok with the page of documents.
+		c.JSON(http.StatusOK, response)
+		logger.Log.Println("Message: Successfully responded with the list of all notes for the authenticated user.")
+	}
+}
+
+// Reads the filter, sort order, page size, tags, notebook, workspace, cursor and fields of the note listing from the url.
+func parseNoteListQuery(c *gin.Context, userId string) (*database.NoteListQuery, error) {
+	query := &database.NoteListQuery{
+		User_Id: userId,
+		Sort_By: database.SortByCreatedAt,
+		Limit:   helper.DefaultNotesPageSize,
+	}
+
+	switch filter := c.Query("filter"); filter {
+	case "", "all":
+		query.Scope = database.AllNotesScope
+	case database.OwnedNotesScope, database.SharedNotesScope, database.PublicNotesScope:
+		query.Scope = filter
+	default:
+		return nil, fmt.Errorf("unknown filter: %s", filter)
+	}
+
+	switch sortBy := c.Query("sort"); sortBy {
+	case "":
+	case database.SortByCreatedAt, database.SortByUpdatedAt, database.SortByHeader:
+		query.Sort_By = sortBy
+	default:
+		return nil, fmt.Errorf("unknown sort field: %s", sortBy)
+	}
+
+	switch order := c.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return nil, fmt.Errorf("unknown order: %s", order)
+	}
+
+	if c.Query("limit") != "" {
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > helper.MaxNotesPageSize {
+			return nil, fmt.Errorf("limit must be a number from 1 to %d", helper.MaxNotesPageSize)
+		}
+		query.Limit = limit
+	}
+
+	tags, err := helper.ParseTagFilter(c.Query("tag"))
+	if err != nil {
+		return nil, err
+	}
+	query.Filter.Tags = tags
+
+	if notebookId := c.Query("notebook"); notebookId != "" {
+		notebookIds, err := notebookFilter(c, userId, notebookId)
+		if err != nil {
+			return nil, err
+		}
+		query.Filter.Notebook_Ids = notebookIds
+	}
+
+	workspaceId, err := parseWorkspaceFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	query.Filter.Workspace_Id = workspaceId
+
+	if c.Query("cursor") != "" {
+		after, err := helper.DecodeNotesPageToken(query, c.Query("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	fields, err := helper.ParseNoteFields(c.Query("fields"))
+	if err != nil {
+		return nil, err
+	}
+	query.Fields = fields
+
+	return query, nil
+}
+
+// GET /api/notes/:id: get a note by ID for the authenticated user.
+func GetNotesByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		notesId := c.Param("id")
+
+		// Get the authenticated user id.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error:": "Error: Problem while converting user id to string."})
+			logger.Log.Print("Error: Problem while converting user if to string.")
+			c.Abort()
+			return
+		}
+
+		// Find the note by its id.
+		note, err := database.StoreObject.GetNoteById(c.Request.Context(), notesId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such note present. Problem while decoding the note.\n\tError: %s", err.Error()), "data": notesId})
+			logger.Log.Printf("Error: No such note present. Problem while decoding the note.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Check whether the authenticated user is the owner, has been granted access, or the note is sharable.
+		// If yes, then send status ok and send the data with header field.
+		// Otherwise, send forbidden status.
+		role, err := helper.GetNoteRole(c.Request.Context(), note, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the role of the user on the note.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the role of user id: %s on the note with note id: %s.\n\tError: %s", userId, notesId, err.Error())
+			c.Abort()
+			return
+		}
+
+		if role != "" || (note.Sharable != nil && *note.Sharable) {
+			etag := helper.NoteETag(note)
+			c.Header("ETag", etag)
+
+			if helper.MatchesETagWeakly(c.GetHeader("If-None-Match"), etag) {
+				c.Status(http.StatusNotModified)
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusOK, note)
+			logger.Log.Printf("Message: Note with notes id: %s is accessible to user with user id: %s as role: %s. It is successfully shared.", notesId, userId, role)
+			c.Abort()
+			return
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Notes is not accessible to the user with user id: %s", userId)})
+			logger.Log.Printf("Message: Note is not accessible to user with user id: %s and it is also not sharable publically. Hence, it cannot be shared.", userId)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// POST /api/notes: create a new note for the authenticated user.
+func CreateNotes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Bind the note sent in the request body.
+		var note models.NoteData
+
+		err := c.BindJSON(&note)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while binding note from json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding note from json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Get the user id of the authenticated user from the middleware.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Problem while getting user id from the middleware."})
+			logger.Log.Printf("Error: Problem while getting user id from the middleware.")
+			c.Abort()
+			return
+		}
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id to string."})
+			logger.Log.Printf("Error: Problem while converting user id to string.")
+			c.Abort()
+			return
+		}
+
+		// Making the note public shares it with everyone.
+		if note.Sharable != nil && !middleware.CheckScope(c, models.NotesShareScope) {
+			return
+		}
+		if note.Sharable != nil && *note.Sharable && !middleware.CheckVerifiedEmail(c, config.ShareAction) {
+			return
+		}
+
+		if note.Header == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No header provided for the note."})
+			logger.Log.Printf("Error: No header provided for the note.")
+			c.Abort()
+			return
+		}
+
+		// Keep the tags in the form they are stored and filtered by.
+		note.Tags, err = helper.AddTags(nil, note.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tags for the note.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tags for the note.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// The note goes into the notebook if one is given, which has to be one of the user.
+		if note.Notebook_Id != nil && *note.Notebook_Id == "" {
+			note.Notebook_Id = nil
+		}
+		if note.Notebook_Id != nil && !checkNotebookOwned(c, userId, *note.Notebook_Id) {
+			return
+		}
+
+		// The note belongs to the workspace if one is given, which the user has to be allowed to write in.
+		// Notebooks are of single users, so the notes of workspaces stay outside of them.
+		if note.Workspace_Id != nil && *note.Workspace_Id == "" {
+			note.Workspace_Id = nil
+		}
+		if note.Workspace_Id != nil {
+			if note.Notebook_Id != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Notes of a workspace cannot be put into a notebook."})
+				logger.Log.Printf("Error: User with user id: %s tried to put a note of workspace id: %s into a notebook.", userId, *note.Workspace_Id)
+				c.Abort()
+				return
+			}
+
+			_, ok := findWorkspaceWithRole(c, *note.Workspace_Id, userId, models.WorkspaceMemberRole)
+			if !ok {
+				return
+			}
+		}
+
+		// Make the unqiue header, the same header can be used once per notebook, or once in the workspace.
+		note.User_Id = &userId
+		unqiueHeader := database.UniqueHeader(database.HeaderOwner(&note), note.Notebook_Id, *note.Header)
+
+		// Find the note if already present with the same unique header.
+		_, findErr := database.StoreObject.GetNoteByUniqueHeader(c.Request.Context(), unqiueHeader)
+
+		// If no document found, can create the note.
+		if findErr == database.ErrNotFound {
+			note.ID = primitive.NewObjectID()
+			note.Unique_Header = &unqiueHeader
+			note.Access = []models.NoteAccess{}
+			note.Version = 1
+
+			emailAny, exists := c.Get("email")
+			if !exists {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No email id provided for the user."})
+				logger.Log.Printf("Error: No email id provided for the user.")
+				c.Abort()
+				return
+			}
+			email, ok := emailAny.(string)
+			if !ok {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting email to string."})
+				logger.Log.Printf("Error: Problem while converting email to string.")
+				c.Abort()
+				return
+			}
+			note.Email = &email
+
+			createdAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+				logger.Log.Printf("\nError: Problem while storing the creation time tamp for the user.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+			note.Created_At = createdAt
+
+			updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+				logger.Log.Printf("\nError: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+			note.Updated_At = updatedAt
+
+			// Insert the document.
+			err = database.StoreObject.CreateNote(c.Request.Context(), &note)
+			// Another note may have been created with the header since it was looked for.
+			if err == database.ErrDuplicate {
+				respondDuplicateHeader(c)
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while inserting the new document.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while inserting the new document.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			// Find the inserted document.
+			foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), note.ID.Hex())
+
+			// If no such note found, then data not inserted.
+			if err != nil {
+				if err == database.ErrNotFound {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: No such note yet has been created.\n\tError: %s", err.Error())})
+					logger.Log.Printf("Error: No such note yet has been created.\n\tError: %s", err.Error())
+					c.Abort()
+					return
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())})
+					logger.Log.Printf("Error: Problem while decoding the found note.\n\tError: %s", err.Error())
+					c.Abort()
+					return
+				}
+			}
+
+			// Record the content of the new note as its first revision.
+			_, err = helper.AppendNoteRevision(c.Request.Context(), foundNote, userId, nil, foundNote.Created_At)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the first revision of the note.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while recording the first revision of the note.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			// Note has been found, send status ok and send it.
+			c.Header("ETag", helper.NoteETag(foundNote))
+			c.JSON(http.StatusOK, gin.H{"data": foundNote, "message": fmt.Sprintf("Message: Successfully created new note with note id: %s and uniquq header: %s", foundNote.ID, *foundNote.Unique_Header)})
+			logger.Log.Printf("Message:  Successfully created new note with note id: %s and unique header: %s", foundNote.ID, *foundNote.Unique_Header)
+			return
+		} else if findErr == nil {
+			respondDuplicateHeader(c)
+			return
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note with the same header.\n\tError: %s", findErr.Error())})
+			logger.Log.Printf("Error: Problem while finding the note with the same header.\n\tError: %s", findErr.Error())
+			c.Abort()
+			return
+		}
+	}
+}
+
+// PUT /api/notes/:id: update an existing note by ID for the authenticated user.
+
+func UpdateNotesByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Getting the notes id from the url.
+		notesId := c.Param("id")
+
+		// Getting the user id from the authenticator.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: The user-id is not passed from the middleware the create handler through context."})
+			logger.Log.Print("Error: The user-id is not passed from the middleware the create handler through context.")
+			c.Abort()
+			return
+		}
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting any to string."})
+			logger.Log.Print("Error: Problem while converting any to string.")
+			c.Abort()
+			return
+		}
+
+		// Find whether there is any notes present with the passed notes id in the url.
+		foundNotes, err := database.StoreObject.GetNoteById(c.Request.Context(), notesId)
+
+		// If no notes present, send bad request.
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No notes with id: %s is present in the database.", notesId)})
+			logger.Log.Printf("Error: No notes with id: %s is present in the database.", notesId)
+			c.Abort()
+			return
+		}
+
+		// If authenticated user is neither the owner nor an editor of the note, send status forbidden.
+		allowed, ok := checkNoteRole(c, foundNotes, userId, models.EditorRole)
+		if !ok {
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Notes is not accessible to the user with user id: %s", userId)})
+			logger.Log.Printf("Error: Notes is not accessible to the user with user id: %s", userId)
+			c.Abort()
+			return
+		}
+
+		// If the client edited an older version of the note, send status precondition failed.
+		if !checkIfMatch(c, foundNotes) {
+			return
+		}
+
+		// If yes, then the user has access to the note document and can update.
+		// Bind the json and extract the data into the note struct.
+		var note *models.NoteData
+
+		err = c.BindJSON(&note)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			logger.Log.Printf("Error: Problem while binding the data to the note struct.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Only the owner can make the note public.
+		isOwner, ok := checkNoteRole(c, foundNotes, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+		if note.Sharable != nil && !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Only the owner can change whether notes with id: %s is sharable.", notesId)})
+			logger.Log.Printf("Error: User with user id: %s tried to change whether notes with id: %s is sharable.", userId, notesId)
+			c.Abort()
+			return
+		}
+		if note.Sharable != nil && !middleware.CheckScope(c, models.NotesShareScope) {
+			return
+		}
+		if note.Sharable != nil && *note.Sharable && !middleware.CheckVerifiedEmail(c, config.ShareAction) {
+			return
+		}
+
+		// Fill in the other fields in the note which needs to be changed.
+		updatedAt, err := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			logger.Log.Printf("Error: Problem while storing the update time tamp for the user.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		note.Updated_At = updatedAt
+
+		// Make the update object.
+		update := database.NoteUpdate{
+			Header:     note.Header,
+			Data:       note.Data,
+			Sharable:   note.Sharable,
+			Updated_At: note.Updated_At,
+		}
+
+		// Only the owner can move the note into another notebook, one of their own.
+		notebookId := foundNotes.Notebook_Id
+		if note.Notebook_Id != nil {
+			if !isOwner {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: Only the owner can move notes with id: %s into another notebook.", notesId)})
+				logger.Log.Printf("Error: User with user id: %s tried to move notes with id: %s into another notebook.", userId, notesId)
+				c.Abort()
+				return
+			}
+
+			if *note.Notebook_Id != "" && foundNotes.Workspace_Id != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Notes with id: %s is of a workspace and cannot be put into a notebook.", notesId)})
+				logger.Log.Printf("Error: User with user id: %s tried to put notes with id: %s of a workspace into a notebook.", userId, notesId)
+				c.Abort()
+				return
+			}
+
+			if *note.Notebook_Id != "" && !checkNotebookOwned(c, userId, *note.Notebook_Id) {
+				return
+			}
+
+			update.Notebook_Id = note.Notebook_Id
+			notebookId = note.Notebook_Id
+		}
+
+		if note.Header != nil || note.Notebook_Id != nil {
+			header := ""
+			if foundNotes.Header != nil {
+				header = *foundNotes.Header
+			}
+			if note.Header != nil {
+				header = *note.Header
+			}
+
+			uniqueHeader := database.UniqueHeader(database.HeaderOwner(foundNotes), notebookId, header)
+			update.Unique_Header = &uniqueHeader
+
+			// The header has to stay unique within the notebook the note ends up in.
+			if !checkUniqueHeaderFree(c, uniqueHeader, notesId) {
+				return
+			}
+		}
+
+		// Tags sent replace the ones of the note.
+		if note.Tags != nil {
+			tags, err := helper.AddTags(nil, note.Tags)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tags for the note.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Invalid tags for the note.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+			update.Tags = &tags
+		}
+
+		// Keep the content from before revisions were recorded, if this is such a note.
+		contentChanged := note.Header != nil || note.Data != nil
+		if contentChanged {
+			err = helper.EnsureInitialNoteRevision(c.Request.Context(), foundNotes)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the initial revision of the note.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while recording the initial revision of the note.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// Only update the version of the note which has been checked above.
+		updateNote, err := database.StoreObject.UpdateNote(c.Request.Context(), notesId, foundNotes.Version, &update)
+
+		// If the note changed in the meantime, send status precondition failed.
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, notesId)
+			return
+		}
+
+		// If another note took the header in the meantime, send status conflict.
+		if err == database.ErrDuplicate {
+			respondDuplicateHeader(c)
+			return
+		}
+
+		// If could not, send bad request.
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while upating data.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while upating data.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Record the updated content as a new revision.
+		if contentChanged {
+			_, err = helper.AppendNoteRevision(c.Request.Context(), updateNote, userId, nil, updateNote.Updated_At)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while recording the revision of the note.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while recording the revision of the note.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// If update successful Send status ok.
+		c.Header("ETag", helper.NoteETag(updateNote))
+		c.JSON(http.StatusOK, updateNote)
+		logger.Log.Printf("Message: Updated notes with notes id: %s successfully.", notesId)
+	}
+}
+
+// DELETE /api/notes/:id: move a note by ID to the trash for the authenticated user.
+
+func DeleteNotesByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the notes id.
+		noteId := c.Param("id")
+
+		// Get the user id from the authentication.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
+			logger.Log.Print("Error: No user id given for authentication.")
+			c.Abort()
+			return
+		}
+
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
+			logger.Log.Print("Error: Problem while converting user id from any to string.")
+			c.Abort()
+			return
+		}
+
+		// Find the note.
+		foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: No such document with note id: %s found.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		// If the authenticated user owns the note move it to the trash and send status ok with the deleted notes details.
+		// If not, then send bad request.
+		isOwner, ok := checkNoteRole(c, foundNote, userId, models.OwnerRole)
+		if !ok {
+			return
+		}
+
+		if isOwner {
+			// If the client wants to delete an older version of the note, send status precondition failed.
+			if !checkIfMatch(c, foundNote) {
+				return
+			}
+
+			// Only trash the version of the note which has been checked above, its revisions stay till it is purged.
+			trashedNote, err := database.StoreObject.TrashNote(c.Request.Context(), noteId, foundNote.Version, time.Now().UTC().Truncate(time.Second))
+			if err == database.ErrVersionConflict {
+				respondVersionConflict(c, noteId)
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while deleting the note with note id: %s by the user with user id: %s.\n\tError: %s", noteId, userId, err.Error())})
+				logger.Log.Printf("Error: Problem while deleting the note with note id: %s by the user with user id: %s.\n\tError: %s", noteId, userId, err.Error())
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusOK, trashedNote)
+			logger.Log.Printf("Message: Successfully moved note with note id: %s to the trash of the user with user id: %s", noteId, userId)
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to delete the notes with note id: %s", userId, noteId)})
+			logger.Log.Printf("Error: User with user id: %s is not allowed to delete the notes with note id: %s", userId, noteId)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// POST /api/notes/:id/share: share a note with another user for the authenticated user.
+func ShareNotesByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the note id from the url.
+		noteId := c.Param("id")
+
+		// Get the sender user id.
+		senderUserIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
+			logger.Log.Print("Error: No user id given for authentication.")
+			c.Abort()
+			return
+		}
+		senderUserId, ok := senderUserIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
+			logger.Log.Print("Error: Problem while converting user id from any to string.")
+			c.Abort()
+			return
+		}
+
+		// Get the receiver user id and the role to grant, viewer by default.
+		type receiver struct {
+			UserId string `json:"userId"`
+			Role   string `json:"role"`
+		}
+		var receiverObj receiver
+
+		err := c.BindJSON(&receiverObj)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No user id with whom the data is to be shared is given./Problem while trying to bind the data.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: No user id with whom the data is to be shared is given./Problem while trying to bind the data.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		receiverUserId := receiverObj.UserId
+
+		role := receiverObj.Role
+		if role == "" {
+			role = models.ViewerRole
+		}
+
+		if !helper.IsGrantableNoteRole(role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Role: %s cannot be granted.", role)})
+			logger.Log.Printf("Error: Role: %s cannot be granted.", role)
+			c.Abort()
+			return
+		}
+
+		// If the sender and receiver is same, sent bad status request.
+		if senderUserId == receiverUserId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Sender and receiver cannot be same."})
+			logger.Log.Print("Error: Sender and receiver cannot be same.")
+			c.Abort()
+			return
+		}
+
+		// Find whether the note present in the database or not.
+		foundNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while finding the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		// Only the owner can share the note.
+		isOwner, ok := checkNoteRole(c, foundNote, senderUserId, models.OwnerRole)
+		if !ok {
+			return
+		}
+		if !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not allowed to share the notes with note id: %s", senderUserId, noteId)})
+			logger.Log.Printf("Error: User with user id: %s is not allowed to share the notes with note id: %s", senderUserId, noteId)
+			c.Abort()
+			return
+		}
+
+		// Get the necessary receiver user details from the database.
+		receiverUser, err := database.StoreObject.GetUserById(c.Request.Context(), receiverUserId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Receiver does not exist in the database./Problem while decoding the found data.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Receiver does not exist in the database./Problem while decoding the found data.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Grant the role to the receiver, replacing any earlier grant.
+		grant := models.NoteAccess{
+			User_Id:    receiverUserId,
+			Email:      receiverUser.Email,
+			Role:       role,
+			Granted_By: senderUserId,
+			Granted_At: time.Now(),
+		}
+
+		accessList := helper.UpsertNoteAccess(foundNote.Access, grant)
+
+		updatedNote, err := database.StoreObject.SetNoteAccess(c.Request.Context(), noteId, foundNote.Version, accessList)
+		if err == database.ErrVersionConflict {
+			respondVersionConflict(c, noteId)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())})
+			logger.Log.Printf("Error: Problem while updating the access list of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedNote)
+		logger.Log.Printf("Message: Successfully shared note with note id: %s with user id: %s as role: %s", noteId, receiverUserId, role)
+	}
+}
+
+// GET /api/search?q=:query: search the notes of the authenticated user, best matches first, or with workspace= the notes of the workspace.
+// The query has words, "phrases", prefixes like word*, AND, OR, NOT or -word and parentheses.
+// With the bleve search engine words match with typos and stemmed, and the facets of the results are sent too.
+
+func SearchNotesByKeywords() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the user id.
+		userIdAny, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id given for authentication."})
+			logger.Log.Print("Error: No user id given for authentication.")
+			c.Abort()
+			return
+		}
+		userId, ok := userIdAny.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: Problem while converting user id from any to string."})
+			logger.Log.Print("Error: Problem while converting user id from any to string.")
+			c.Abort()
+			return
+		}
+
+		// Parse the query.
+		query, err := search.Parse(c.Query("q"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid search query.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid search query.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		// Only search the notes with every one of the tags, if any are given.
+		tags, err := helper.ParseTagFilter(c.Query("tag"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid tag filter.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid tag filter.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+		filter := &database.NoteFilter{Tags: tags}
+
+		// Only search the notes in the notebook and the notebooks below it, if one is given.
+		if notebookId := c.Query("notebook"); notebookId != "" {
+			filter.Notebook_Ids, err = notebookFilter(c, userId, notebookId)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid notebook filter.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Invalid notebook filter.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+		}
+
+		// Only search the notes of the workspace, if one is given, which the user has to be a member of.
+		filter.Workspace_Id, err = parseWorkspaceFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid workspace filter.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid workspace filter.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+		if filter.Workspace_Id != "" {
+			_, ok := findWorkspaceWithRole(c, filter.Workspace_Id, userId, models.WorkspaceViewerRole)
+			if !ok {
+				return
+			}
+		}
+
+		// Search through the index if there is one.
+		if searchindex.IndexObject != nil {
+			response, err := searchindex.IndexObject.Search(c.Request.Context(), userId, query, filter)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while searching the notes.\n\tError: %s", err.Error())})
+				logger.Log.Printf("Error: Problem while searching the notes.\n\tError: %s", err.Error())
+				c.Abort()
+				return
+			}
+
+			if len(response.Data) == 0 {
+				c.JSON(http.StatusOK, gin.H{"error": "Error: No such note contained the passed keywords in it."})
+				logger.Log.Println("Error: No such note contained the passed keywords in it.")
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusOK, response)
+			logger.Log.Printf("Message: Successfully find all the notes with the keyword: %s", query)
+			return
+		}
+
+		// Find the notes the user can view which match the query.
+		foundNotes, err := database.StoreObject.SearchNotes(c.Request.Context(), userId, query, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while searching the notes.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while searching the notes.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if len(foundNotes) == 0 {
+			c.JSON(http.StatusOK, gin.H{"error": "Error: No such note contained the passed keywords in it."})
+			logger.Log.Println("Error: No such note contained the passed keywords in it.")
+			c.Abort()
+			return
+		}
+
+		// Send the notes ranked by relevance, with the matches highlighted.
+		c.JSON(http.StatusOK, gin.H{"data": search.Rank(query, foundNotes)})
+		logger.Log.Printf("Message: Successfully find all the notes with the keyword: %s", query)
+	}
+}
+
+// Checks that the notebook exists and belongs to the user, responding when it does not.
+func checkNotebookOwned(c *gin.Context, userId string, notebookId string) bool {
+	_, err := helper.GetOwnedNotebook(c.Request.Context(), userId, notebookId)
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: No notebook with notebook id: %s found for the user.", notebookId)})
+		logger.Log.Printf("Error: No notebook with notebook id: %s found for user id: %s.", notebookId, userId)
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the notebook.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the notebook.\n\tError: %s", err.Error())
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// Checks that no note other than the given one has the unique header, responding with a conflict when one has.
+func checkUniqueHeaderFree(c *gin.Context, uniqueHeader string, noteId string) bool {
+	existingNote, err := database.StoreObject.GetNoteByUniqueHeader(c.Request.Context(), uniqueHeader)
+	if err == database.ErrNotFound || (err == nil && existingNote.ID.Hex() == noteId) {
+		return true
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the note with the same header.\n\tError: %s", err.Error())})
+		logger.Log.Printf("Error: Problem while finding the note with the same header.\n\tError: %s", err.Error())
+		c.Abort()
+		return false
+	}
+
+	respondDuplicateHeader(c)
+	return false
+}
+
+// Responds with a conflict to a note taking the unique header of another note.
+func respondDuplicateHeader(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"error": "Error: Same note already exists in the database."})
+	logger.Log.Printf("Error: Same note already exists in the database.")
+	c.Abort()
+}
+
+// Ids of the notebooks a listing or a search is narrowed down to: the notebook of the user and the ones below it.
+func notebookFilter(c *gin.Context, userId string, notebookId string) ([]string, error) {
+	notebookIds, err := helper.NotebookSubtree(c.Request.Context(), userId, notebookId)
+	if err == database.ErrNotFound {
+		return nil, fmt.Errorf("no such notebook: %s", notebookId)
+	}
+
+	return notebookIds, err
+}
+
+// Workspace a listing or a search is narrowed down to, empty if none is asked for.
+// It takes the place of the filter, and the notes of workspaces are never in notebooks.
+func parseWorkspaceFilter(c *gin.Context) (string, error) {
+	workspaceId := c.Query("workspace")
+	if workspaceId == "" {
+		return "", nil
+	}
+
+	if filter := c.Query("filter"); filter != "" && filter != "all" {
+		return "", fmt.Errorf("workspace cannot be combined with the filter: %s", filter)
+	}
+	if c.Query("notebook") != "" {
+		return "", fmt.Errorf("workspace cannot be combined with a notebook")
+	}
+
+	return workspaceId, nil
+}
+
+// Checks the If-Match header of the request against the current version of the note.
+// A missing header is accepted, a stale one gets status precondition failed with the current version.
+func checkIfMatch(c *gin.Context, note *models.NoteData) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || helper.MatchesETagStrongly(ifMatch, helper.NoteETag(note)) {
+		return true
+	}
+
+	c.Header("ETag", helper.NoteETag(note))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": fmt.Sprintf("Error: Notes with notes id: %s has been modified, current version is: %d", note.ID.Hex(), note.Version), "version": note.Version})
+	logger.Log.Printf("Error: Stale If-Match: %s for notes with notes id: %s at version: %d", ifMatch, note.ID.Hex(), note.Version)
+	c.Abort()
+	return false
+}
+
+// Responds to a change which lost the race against a concurrent change of the same note, with the version it lost to.
+func respondVersionConflict(c *gin.Context, noteId string) {
+	currentNote, err := database.StoreObject.GetNoteById(c.Request.Context(), noteId)
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": fmt.Sprintf("Error: Notes with notes id: %s has been modified or deleted concurrently.", noteId)})
+		logger.Log.Printf("Error: Notes with notes id: %s has been modified or deleted concurrently.", noteId)
+		c.Abort()
+		return
+	}
+
+	c.Header("ETag", helper.NoteETag(currentNote))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": fmt.Sprintf("Error: Notes with notes id: %s has been modified concurrently, current version is: %d", noteId, currentNote.Version), "version": currentNote.Version})
+	logger.Log.Printf("Error: Notes with notes id: %s has been modified concurrently, current version is: %d", noteId, currentNote.Version)
+	c.Abort()
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Response to every request for another verification mail which is not throttled, so that it does not tell
+// which emails have unverified accounts.
+const resendVerificationMessage = "Message: If an unverified account with the email exists, a mail to verify it has been sent."
+
+// GET /api/auth/verify?token=...: verify the email of an account with the mailed token.
+func VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No verification token present."})
+			logger.Log.Print("Error: No verification token present.")
+			c.Abort()
+			return
+		}
+
+		verifiedUser, err := helper.VerifyEmail(c.Request.Context(), token)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: Verification token is invalid or has expired."})
+			logger.Log.Print("Error: Verification token is invalid or has expired.")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while verifying the email.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while verifying the email.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Message: Email id: %s has been verified.", *verifiedUser.Email)})
+		logger.Log.Printf("Message: Successfully verified the email of user id: %s", verifiedUser.UserID)
+	}
+}
+
+// POST /api/auth/verify/resend: mail another token to verify the account with the email, at most once per resend interval.
+func ResendVerification() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.ResendVerificationRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		email := strings.TrimSpace(request.Email)
+		if email == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No email present."})
+			logger.Log.Print("Error: No email present.")
+			c.Abort()
+			return
+		}
+
+		foundUser, err := database.StoreObject.GetUserByEmail(c.Request.Context(), email)
+		if err == database.ErrNotFound || (err == nil && foundUser.Email_Verified) {
+			c.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+			logger.Log.Printf("Message: Verification mail requested for unknown or verified email: %s", email)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while finding the user with email: %s.\n\tError: %s", email, err.Error())
+			c.Abort()
+			return
+		}
+
+		retryAfter, err := helper.ResendEmailVerification(c.Request.Context(), foundUser)
+		if err == helper.ErrVerificationThrottled {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Error: A verification mail was sent recently. Try again in %d seconds.", seconds)})
+			logger.Log.Printf("Error: Verification mail to user id: %s throttled.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while resending the verification mail.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while resending the verification mail to user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": resendVerificationMessage})
+		logger.Log.Printf("Message: Successfully resent the verification mail to user id: %s", foundUser.UserID)
+	}
+}
@@ -0,0 +1,566 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Finds the workspace and checks that the user has at least the given role in it,
+// writing the error response if not.
+func findWorkspaceWithRole(c *gin.Context, workspaceId string, userId string, role string) (*models.Workspace, bool) {
+	foundWorkspace, err := database.StoreObject.GetWorkspaceById(c.Request.Context(), workspaceId)
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No workspace with id: %s found.", workspaceId)})
+		logger.Log.Printf("Error: No workspace with id: %s found.", workspaceId)
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the workspace with id: %s.\n\tError: %s", workspaceId, err.Error())})
+		logger.Log.Printf("Error: Problem while finding the workspace with id: %s.\n\tError: %s", workspaceId, err.Error())
+		c.Abort()
+		return nil, false
+	}
+
+	if !helper.HasWorkspaceRole(foundWorkspace, userId, role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: User with user id: %s needs the role: %s in the workspace with id: %s.", userId, role, workspaceId)})
+		logger.Log.Printf("Error: User with user id: %s does not have the role: %s in the workspace with id: %s.", userId, role, workspaceId)
+		c.Abort()
+		return nil, false
+	}
+
+	return foundWorkspace, true
+}
+
+// Writes the response for a change of a workspace which failed, telling apart a workspace changed meanwhile.
+func respondWorkspaceChangeError(c *gin.Context, workspaceId string, err error) {
+	if err == database.ErrVersionConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Error: Workspace with id: %s has been changed concurrently, try again.", workspaceId)})
+		logger.Log.Printf("Error: Workspace with id: %s has been changed concurrently.", workspaceId)
+		c.Abort()
+		return
+	}
+	if err == database.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No workspace with id: %s found.", workspaceId)})
+		logger.Log.Printf("Error: No workspace with id: %s found.", workspaceId)
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while changing the workspace with id: %s.\n\tError: %s", workspaceId, err.Error())})
+	logger.Log.Printf("Error: Problem while changing the workspace with id: %s.\n\tError: %s", workspaceId, err.Error())
+	c.Abort()
+}
+
+// POST /api/workspaces: create a workspace, the authenticated user becoming its owner.
+func CreateWorkspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		var request models.WorkspaceRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		name, err := helper.NormalizeWorkspaceName(request.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid workspace name.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid workspace name.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		createdWorkspace, err := helper.CreateWorkspace(c.Request.Context(), name, foundUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the workspace.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while creating the workspace of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"data": createdWorkspace})
+		logger.Log.Printf("Message: Successfully created workspace id: %s owned by user id: %s", createdWorkspace.ID.Hex(), foundUser.UserID)
+	}
+}
+
+// GET /api/workspaces: list the workspaces the authenticated user is a member of, by name.
+func GetWorkspaces() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundWorkspaces, err := database.StoreObject.ListWorkspaces(c.Request.Context(), userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the workspaces.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the workspaces.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": foundWorkspaces})
+		logger.Log.Printf("Message: Successfully responded with the workspaces of user id: %s", userId)
+	}
+}
+
+// GET /api/workspaces/:id: get a workspace with its members, for its members.
+func GetWorkspaceByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceViewerRole)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": foundWorkspace})
+		logger.Log.Printf("Message: Successfully responded with workspace id: %s to user id: %s", workspaceId, userId)
+	}
+}
+
+// PUT /api/workspaces/:id: rename a workspace, for its admins.
+func UpdateWorkspaceByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var request models.WorkspaceRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		name, err := helper.NormalizeWorkspaceName(request.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid workspace name.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Invalid workspace name.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return
+		}
+
+		updatedWorkspace, err := database.StoreObject.RenameWorkspace(c.Request.Context(), workspaceId, foundWorkspace.Version, name, time.Now())
+		if err != nil {
+			respondWorkspaceChangeError(c, workspaceId, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": updatedWorkspace})
+		logger.Log.Printf("Message: Successfully renamed workspace id: %s by user id: %s", workspaceId, userId)
+	}
+}
+
+// DELETE /api/workspaces/:id: delete a workspace along with its invitations, for its owner.
+// Only a workspace without notes, in the trash neither, can be deleted.
+func DeleteWorkspaceByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceOwnerRole)
+		if !ok {
+			return
+		}
+
+		// The store checks the workspace has no notes as it deletes it, so that none can be added in between.
+		err := database.StoreObject.DeleteWorkspace(c.Request.Context(), workspaceId, foundWorkspace.Version)
+		if err == database.ErrNotEmpty {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: The workspace still has notes, delete them and empty its trash first."})
+			logger.Log.Printf("Error: Workspace id: %s still has notes, it cannot be deleted.", workspaceId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			respondWorkspaceChangeError(c, workspaceId, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted."})
+		logger.Log.Printf("Message: Successfully deleted workspace id: %s by user id: %s", workspaceId, userId)
+	}
+}
+
+// PUT /api/workspaces/:id/members/:userId: change the role of a member, for the admins of the workspace.
+// The owner keeps their role, and nobody else can be made the owner.
+func SetWorkspaceMemberRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+		memberUserId := c.Param("userId")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var request models.WorkspaceMemberRoleRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !helper.IsAssignableWorkspaceRole(request.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Role: %s cannot be given, it has to be admin, member or viewer.", request.Role)})
+			logger.Log.Printf("Error: Role: %s cannot be given in a workspace.", request.Role)
+			c.Abort()
+			return
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return
+		}
+
+		index := slices.IndexFunc(foundWorkspace.Members, func(member models.WorkspaceMember) bool { return member.User_Id == memberUserId })
+		if index < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not a member of the workspace.", memberUserId)})
+			logger.Log.Printf("Error: User with user id: %s is not a member of workspace id: %s.", memberUserId, workspaceId)
+			c.Abort()
+			return
+		}
+
+		if foundWorkspace.Members[index].Role == models.WorkspaceOwnerRole {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The role of the owner of the workspace cannot be changed."})
+			logger.Log.Printf("Error: User with user id: %s tried to change the role of the owner of workspace id: %s.", userId, workspaceId)
+			c.Abort()
+			return
+		}
+
+		members := slices.Clone(foundWorkspace.Members)
+		members[index].Role = request.Role
+
+		updatedWorkspace, err := database.StoreObject.SetWorkspaceMembers(c.Request.Context(), workspaceId, foundWorkspace.Version, members)
+		if err != nil {
+			respondWorkspaceChangeError(c, workspaceId, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": updatedWorkspace})
+		logger.Log.Printf("Message: Successfully gave user id: %s the role: %s in workspace id: %s", memberUserId, request.Role, workspaceId)
+	}
+}
+
+// DELETE /api/workspaces/:id/members/:userId: remove a member from a workspace, for its admins,
+// or leave the workspace with the own user id. The owner cannot leave.
+func RemoveWorkspaceMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+		memberUserId := c.Param("userId")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		requiredRole := models.WorkspaceAdminRole
+		if memberUserId == userId {
+			requiredRole = models.WorkspaceViewerRole
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, requiredRole)
+		if !ok {
+			return
+		}
+
+		index := slices.IndexFunc(foundWorkspace.Members, func(member models.WorkspaceMember) bool { return member.User_Id == memberUserId })
+		if index < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: User with user id: %s is not a member of the workspace.", memberUserId)})
+			logger.Log.Printf("Error: User with user id: %s is not a member of workspace id: %s.", memberUserId, workspaceId)
+			c.Abort()
+			return
+		}
+
+		if foundWorkspace.Members[index].Role == models.WorkspaceOwnerRole {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The owner cannot leave the workspace, delete it instead."})
+			logger.Log.Printf("Error: User with user id: %s tried to remove the owner of workspace id: %s.", userId, workspaceId)
+			c.Abort()
+			return
+		}
+
+		members := slices.Delete(slices.Clone(foundWorkspace.Members), index, index+1)
+
+		updatedWorkspace, err := database.StoreObject.SetWorkspaceMembers(c.Request.Context(), workspaceId, foundWorkspace.Version, members)
+		if err != nil {
+			respondWorkspaceChangeError(c, workspaceId, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Member removed.", "data": updatedWorkspace})
+		logger.Log.Printf("Message: Successfully removed user id: %s from workspace id: %s by user id: %s", memberUserId, workspaceId, userId)
+	}
+}
+
+// POST /api/workspaces/:id/invitations: invite an email into a workspace with a role, member by default,
+// mailing the token to accept or decline the invitation. For the admins of the workspace.
+func CreateWorkspaceInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		var request models.WorkspaceInvitationRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Problem while binding the json.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while binding the json.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		email := strings.ToLower(strings.TrimSpace(request.Email))
+		if !helper.IsValidEmail(email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Invalid email: %s.", request.Email)})
+			logger.Log.Printf("Error: Invalid email: %s to invite into workspace id: %s.", request.Email, workspaceId)
+			c.Abort()
+			return
+		}
+
+		role := request.Role
+		if role == "" {
+			role = models.WorkspaceMemberRole
+		}
+		if !helper.IsAssignableWorkspaceRole(role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Error: Role: %s cannot be given, it has to be admin, member or viewer.", role)})
+			logger.Log.Printf("Error: Role: %s cannot be given in a workspace.", role)
+			c.Abort()
+			return
+		}
+
+		foundWorkspace, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return
+		}
+
+		for _, member := range foundWorkspace.Members {
+			if member.Email != nil && strings.EqualFold(*member.Email, email) {
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Error: %s is already a member of the workspace.", email)})
+				logger.Log.Printf("Error: %s is already a member of workspace id: %s.", email, workspaceId)
+				c.Abort()
+				return
+			}
+		}
+
+		invitation, err := helper.InviteToWorkspace(c.Request.Context(), foundWorkspace, email, role, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while creating the invitation.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while inviting %s into workspace id: %s.\n\tError: %s", email, workspaceId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Invitation mailed.", "data": invitation})
+		logger.Log.Printf("Message: Successfully invited %s into workspace id: %s as role: %s by user id: %s", email, workspaceId, role, userId)
+	}
+}
+
+// GET /api/workspaces/:id/invitations: list the pending invitations into a workspace, newest first, for its admins.
+func GetWorkspaceInvitations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return
+		}
+
+		invitations, err := database.StoreObject.ListWorkspaceInvitations(c.Request.Context(), workspaceId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while listing the invitations.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while listing the invitations into workspace id: %s.\n\tError: %s", workspaceId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": invitations})
+		logger.Log.Printf("Message: Successfully responded with the invitations into workspace id: %s", workspaceId)
+	}
+}
+
+// DELETE /api/workspaces/:id/invitations/:invitationId: withdraw an invitation into a workspace, for its admins.
+func DeleteWorkspaceInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("id")
+		invitationId := c.Param("invitationId")
+
+		userId := c.GetString("userId")
+		if userId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: No user id present."})
+			logger.Log.Print("Error: No user id present.")
+			c.Abort()
+			return
+		}
+
+		_, ok := findWorkspaceWithRole(c, workspaceId, userId, models.WorkspaceAdminRole)
+		if !ok {
+			return
+		}
+
+		err := database.StoreObject.DeleteWorkspaceInvitation(c.Request.Context(), workspaceId, invitationId)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Error: No invitation with id: %s found.", invitationId)})
+			logger.Log.Printf("Error: No invitation with id: %s found in workspace id: %s.", invitationId, workspaceId)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while withdrawing the invitation with id: %s.\n\tError: %s", invitationId, err.Error())})
+			logger.Log.Printf("Error: Problem while withdrawing the invitation with id: %s.\n\tError: %s", invitationId, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation withdrawn."})
+		logger.Log.Printf("Message: Successfully withdrew invitation id: %s into workspace id: %s by user id: %s", invitationId, workspaceId, userId)
+	}
+}
+
+// POST /api/invitations/accept: join the workspace of an invitation with its mailed token,
+// for the authenticated user with the invited email.
+func AcceptWorkspaceInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		foundUser, ok := authenticatedUser(c)
+		if !ok {
+			return
+		}
+
+		var request models.WorkspaceInvitationResponse
+		err := c.BindJSON(&request)
+		if err != nil || request.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: The token of the invitation is required."})
+			logger.Log.Print("Error: No invitation token given.")
+			c.Abort()
+			return
+		}
+
+		joinedWorkspace, err := helper.AcceptWorkspaceInvitation(c.Request.Context(), request.Token, foundUser)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: The invitation is invalid or has expired."})
+			logger.Log.Printf("Error: Invalid or expired invitation token used by user id: %s.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+		if err == helper.ErrInvitationEmailMismatch {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: The invitation is for another email."})
+			logger.Log.Printf("Error: User id: %s tried to accept an invitation for another email.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+		if err == database.ErrVersionConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error: The workspace has been changed concurrently, try again."})
+			logger.Log.Printf("Error: Workspace changed concurrently while user id: %s accepted an invitation.", foundUser.UserID)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while accepting the invitation.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while accepting the invitation of user id: %s.\n\tError: %s", foundUser.UserID, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted.", "data": joinedWorkspace})
+		logger.Log.Printf("Message: Successfully added user id: %s to workspace id: %s", foundUser.UserID, joinedWorkspace.ID.Hex())
+	}
+}
+
+// POST /api/invitations/decline: decline an invitation with its mailed token, no login needed.
+func DeclineWorkspaceInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.WorkspaceInvitationResponse
+		err := c.BindJSON(&request)
+		if err != nil || request.Token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error: The token of the invitation is required."})
+			logger.Log.Print("Error: No invitation token given.")
+			c.Abort()
+			return
+		}
+
+		invitation, err := helper.DeclineWorkspaceInvitation(c.Request.Context(), request.Token)
+		if err == database.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error: The invitation is invalid or has expired."})
+			logger.Log.Print("Error: Invalid or expired invitation token declined.")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while declining the invitation.\n\tError: %s", err.Error())})
+			logger.Log.Printf("Error: Problem while declining an invitation.\n\tError: %s", err.Error())
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation declined."})
+		logger.Log.Printf("Message: Successfully declined the invitation of %s into workspace id: %s", invitation.Email, invitation.Workspace_Id)
+	}
+}
@@ -1,46 +1,39 @@
-package database
-
-import (
-	"context"
-	"log"
-	"os"
-	"time"
-
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-type MongoDBObject struct {
-	Client *mongo.Client
-	Ctx    context.Context
-	Cancel context.CancelFunc
-}
-
-var MongoObject *MongoDBObject
-
-func init() {
-	MongoObject = GetDB()
-}
-
-func GetDB() *MongoDBObject {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		log.Fatalf("Error: Problem while loading the environment variables.\n\tError: %s", err)
-	}
-
-	MongoDB_URI := os.Getenv("MONGODB_URI")
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(10000))
-
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(MongoDB_URI))
-	if err != nil {
-		log.Fatalf("Error: Problem while connecting to the database.\n\tError: %s", err)
-	}
-
-	return &MongoDBObject{
-		Client: client,
-		Ctx:    ctx,
-		Cancel: cancel,
-	}
-}
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBObject struct {
+	Client *mongo.Client
+	Ctx    context.Context
+	Cancel context.CancelFunc
+	Config config.MongoConfig
+}
+
+// Connection used by the mongo store, set once it has been opened.
+var MongoObject *MongoDBObject
+
+func GetDB(mongoConfig config.MongoConfig) (*MongoDBObject, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(10000))
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoConfig.URI))
+	if err != nil {
+		logger.Log.Printf("Error: Problem while connecting to the database.\n\tError: %s", err)
+		cancel()
+		return nil, err
+	}
+
+	return &MongoDBObject{
+		Client: client,
+		Ctx:    ctx,
+		Cancel: cancel,
+		Config: mongoConfig,
+	}, nil
+}
@@ -1,57 +1,65 @@
-package database
-
-import (
-	"os"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
-)
-
-func getDatabase(mongoObject *MongoDBObject) (*mongo.Database, error) {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		logger.Log.Println("Error: Problem while loading environment variables.")
-		return nil, err
-	}
-
-	databaseName := os.Getenv("MONGODB_DATABASE_NAME")
-
-	return mongoObject.Client.Database(databaseName), nil
-}
-
-func (mongoObject *MongoDBObject) GetUserCollection() (*mongo.Collection, error) {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		logger.Log.Println("Error: Problem while loading environment variables.")
-		return nil, err
-	}
-
-	database, err := getDatabase(mongoObject)
-	if err != nil {
-		logger.Log.Println("Error: Problem while loading the database.")
-		return nil, err
-	}
-
-	userCollectionName := os.Getenv("USERS_COLLECTION")
-
-	return database.Collection(userCollectionName), nil
-}
-
-func (mongoObject *MongoDBObject) GetNoteCollection() (*mongo.Collection, error) {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		logger.Log.Println("Error: Problem while loading environment variables.")
-		return nil, err
-	}
-
-	database, err := getDatabase(mongoObject)
-	if err != nil {
-		logger.Log.Println("Error: Problem while loading the database.")
-		return nil, err
-	}
-
-	noteCollectionName := os.Getenv("NOTES_COLLECTION")
-
-	return database.Collection(noteCollectionName), nil
-}
+package database
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func getDatabase(mongoObject *MongoDBObject) *mongo.Database {
+	return mongoObject.Client.Database(mongoObject.Config.Database_Name)
+}
+
+func (mongoObject *MongoDBObject) GetUserCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Users_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetNoteCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Notes_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetRevokedTokenCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Revoked_Tokens_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetNoteRevisionCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Note_Revisions_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetNotebookCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Notebooks_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetSessionCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Sessions_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetPasswordResetCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Password_Resets_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetRecoveryCodeCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Recovery_Codes_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetAccessTokenCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Access_Tokens_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetLoginFailureCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Login_Failures_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetLockoutCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Lockouts_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetWorkspaceCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Workspaces_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetInvitationCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Invitations_Collection), nil
+}
+
+func (mongoObject *MongoDBObject) GetShareLinkCollection() (*mongo.Collection, error) {
+	return getDatabase(mongoObject).Collection(mongoObject.Config.Share_Links_Collection), nil
+}
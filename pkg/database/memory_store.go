@@ -0,0 +1,1762 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+)
+
+// Store keeping everything in process memory, for running the API without a database.
+// Everything is lost when the process exits.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	users         map[string]*models.UserDataServer     // by user id
+	sessions      map[string]models.Session             // by hex session id
+	resets        map[string]models.PasswordReset       // by token hash
+	recoveryCodes map[string]map[string]bool            // hashes of the codes by user id
+	accessTokens  map[string]models.AccessToken         // by hex token id
+	loginFailures map[string]models.LoginFailures       // by key
+	lockouts      []models.Lockout                      // oldest first
+	notes         map[string]*models.NoteData           // by hex note id
+	notebooks     map[string]*models.Notebook           // by hex notebook id
+	workspaces    map[string]*models.Workspace          // by hex workspace id
+	invitations   map[string]models.WorkspaceInvitation // by hex invitation id
+	shareLinks    map[string]models.ShareLink           // by hex link id
+	noteRevisions map[string][]models.NoteRevision      // by hex note id, oldest first
+	revokedTokens map[string]models.RevokedToken        // by token id
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:         make(map[string]*models.UserDataServer),
+		sessions:      make(map[string]models.Session),
+		resets:        make(map[string]models.PasswordReset),
+		recoveryCodes: make(map[string]map[string]bool),
+		accessTokens:  make(map[string]models.AccessToken),
+		loginFailures: make(map[string]models.LoginFailures),
+		notes:         make(map[string]*models.NoteData),
+		notebooks:     make(map[string]*models.Notebook),
+		workspaces:    make(map[string]*models.Workspace),
+		invitations:   make(map[string]models.WorkspaceInvitation),
+		shareLinks:    make(map[string]models.ShareLink),
+		noteRevisions: make(map[string][]models.NoteRevision),
+		revokedTokens: make(map[string]models.RevokedToken),
+	}
+}
+
+func (store *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// The copy helpers below make sure that callers never share memory with the stored documents.
+
+func copyString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+
+	copied := *value
+	return &copied
+}
+
+func copyBool(value *bool) *bool {
+	if value == nil {
+		return nil
+	}
+
+	copied := *value
+	return &copied
+}
+
+func copyUser(user *models.UserDataServer) *models.UserDataServer {
+	copied := *user
+	copied.First_Name = copyString(user.First_Name)
+	copied.Last_Name = copyString(user.Last_Name)
+	copied.Password = copyString(user.Password)
+	copied.Email = copyString(user.Email)
+
+	return &copied
+}
+
+func copyNote(note *models.NoteData) *models.NoteData {
+	copied := *note
+	copied.User_Id = copyString(note.User_Id)
+	copied.Header = copyString(note.Header)
+	copied.Unique_Header = copyString(note.Unique_Header)
+	copied.Email = copyString(note.Email)
+	copied.Data = copyString(note.Data)
+	copied.Sharable = copyBool(note.Sharable)
+	copied.Notebook_Id = copyString(note.Notebook_Id)
+	copied.Workspace_Id = copyString(note.Workspace_Id)
+
+	if note.Deleted_At != nil {
+		deletedAt := *note.Deleted_At
+		copied.Deleted_At = &deletedAt
+	}
+
+	if note.Tags != nil {
+		copied.Tags = append([]string{}, note.Tags...)
+	}
+
+	if note.Access != nil {
+		copied.Access = make([]models.NoteAccess, len(note.Access))
+		for i, access := range note.Access {
+			access.Email = copyString(access.Email)
+			copied.Access[i] = access
+		}
+	}
+
+	return &copied
+}
+
+func copyNotebook(notebook *models.Notebook) *models.Notebook {
+	copied := *notebook
+	copied.Parent_Id = copyString(notebook.Parent_Id)
+	copied.Ancestors = append([]string{}, notebook.Ancestors...)
+
+	return &copied
+}
+
+func copyWorkspace(workspace *models.Workspace) *models.Workspace {
+	copied := *workspace
+	copied.Members = make([]models.WorkspaceMember, len(workspace.Members))
+	for i, member := range workspace.Members {
+		member.Email = copyString(member.Email)
+		copied.Members[i] = member
+	}
+
+	return &copied
+}
+
+func copyNoteRevision(revision *models.NoteRevision) *models.NoteRevision {
+	copied := *revision
+	copied.Header = copyString(revision.Header)
+	copied.Data = copyString(revision.Data)
+
+	if revision.Restored_From != nil {
+		restoredFrom := *revision.Restored_From
+		copied.Restored_From = &restoredFrom
+	}
+
+	return &copied
+}
+
+// Whether the user owns the note outside of workspaces.
+func isNoteOwned(note *models.NoteData, userId string) bool {
+	return note.User_Id != nil && *note.User_Id == userId && note.Workspace_Id == nil
+}
+
+// Same rule as the mongo filter on the viewable notes.
+func isNoteViewable(note *models.NoteData, userId string) bool {
+	if isNoteOwned(note, userId) {
+		return true
+	}
+
+	if note.Sharable != nil && *note.Sharable {
+		return true
+	}
+
+	for _, access := range note.Access {
+		if access.User_Id == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Notes are listed in the order they were created, like a mongo collection scan.
+func sortNotes(notes []models.NoteData) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		if !notes[i].Created_At.Equal(notes[j].Created_At) {
+			return notes[i].Created_At.Before(notes[j].Created_At)
+		}
+
+		return notes[i].ID.Hex() < notes[j].ID.Hex()
+	})
+}
+
+func (store *MemoryStore) CreateUser(ctx context.Context, user *models.UserDataServer) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, storedUser := range store.users {
+		if *storedUser.Email == *user.Email {
+			return ErrDuplicate
+		}
+	}
+
+	if _, exists := store.users[user.UserID]; exists {
+		return ErrDuplicate
+	}
+
+	store.users[user.UserID] = copyUser(user)
+	return nil
+}
+
+func (store *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*models.UserDataServer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, storedUser := range store.users {
+		if *storedUser.Email == email {
+			return copyUser(storedUser), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) GetUserById(ctx context.Context, userId string) (*models.UserDataServer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return copyUser(storedUser), nil
+}
+
+func (store *MemoryStore) UpdateLastLogin(ctx context.Context, userId string, lastLogin time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return nil
+	}
+
+	storedUser.Last_Login = lastLogin
+	return nil
+}
+
+func (store *MemoryStore) UpdatePassword(ctx context.Context, userId string, password string, updatedAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return ErrNotFound
+	}
+
+	storedUser.Password = &password
+	storedUser.Updated_At = updatedAt
+	return nil
+}
+
+func (store *MemoryStore) SetVerificationToken(ctx context.Context, userId string, tokenHash string, sentAt time.Time, expiresAt time.Time, sentBefore time.Time) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists || storedUser.Email_Verified || storedUser.Verification_Sent_At.After(sentBefore) {
+		return false, nil
+	}
+
+	storedUser.Verification_Token_Hash = tokenHash
+	storedUser.Verification_Sent_At = sentAt
+	storedUser.Verification_Expires_At = expiresAt
+	return true, nil
+}
+
+func (store *MemoryStore) VerifyEmail(ctx context.Context, tokenHash string, verifiedAt time.Time) (*models.UserDataServer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, storedUser := range store.users {
+		if storedUser.Email_Verified || storedUser.Verification_Token_Hash != tokenHash || storedUser.Verification_Expires_At.Before(verifiedAt) {
+			continue
+		}
+
+		storedUser.Email_Verified = true
+		storedUser.Verification_Token_Hash = ""
+		storedUser.Updated_At = verifiedAt
+		return copyUser(storedUser), nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) RevokeAllTokens(ctx context.Context, userId string, revokedBefore time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return nil
+	}
+
+	storedUser.Revoked_Before = revokedBefore
+
+	for sessionId, storedSession := range store.sessions {
+		if storedSession.User_Id == userId {
+			delete(store.sessions, sessionId)
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) ListUsers(ctx context.Context, query *UserListQuery) ([]models.UserDataServer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	search := strings.ToLower(query.Search)
+
+	users := []models.UserDataServer{}
+	for _, storedUser := range store.users {
+		if storedUser.UserID <= query.After || (query.Role != "" && storedUser.Role != query.Role) {
+			continue
+		}
+
+		if search != "" && !slices.ContainsFunc([]*string{storedUser.Email, storedUser.First_Name, storedUser.Last_Name}, func(value *string) bool {
+			return strings.Contains(strings.ToLower(stringValue(value)), search)
+		}) {
+			continue
+		}
+
+		users = append(users, *copyUser(storedUser))
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+	}
+
+	return users, nil
+}
+
+func (store *MemoryStore) SetUserRole(ctx context.Context, userId string, role string, updatedAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return ErrNotFound
+	}
+
+	storedUser.Role = role
+	storedUser.Updated_At = updatedAt
+	return nil
+}
+
+func (store *MemoryStore) SetUserDisabled(ctx context.Context, userId string, disabled bool, updatedAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return ErrNotFound
+	}
+
+	storedUser.Disabled = disabled
+	storedUser.Updated_At = updatedAt
+	return nil
+}
+
+func (store *MemoryStore) CreateSession(ctx context.Context, session *models.Session) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the sessions which have expired, like the TTL index does in mongo.
+	now := time.Now()
+	for sessionId, storedSession := range store.sessions {
+		if storedSession.Expires_At.Before(now) {
+			delete(store.sessions, sessionId)
+		}
+	}
+
+	if _, exists := store.sessions[session.ID.Hex()]; exists {
+		return ErrDuplicate
+	}
+
+	store.sessions[session.ID.Hex()] = *session
+	return nil
+}
+
+func (store *MemoryStore) GetSessionById(ctx context.Context, sessionId string) (*models.Session, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedSession, exists := store.sessions[sessionId]
+	if !exists || storedSession.Expires_At.Before(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	return &storedSession, nil
+}
+
+func (store *MemoryStore) ListSessions(ctx context.Context, userId string) ([]models.Session, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	sessions := []models.Session{}
+	for _, storedSession := range store.sessions {
+		if storedSession.User_Id == userId && !storedSession.Expires_At.Before(now) {
+			sessions = append(sessions, storedSession)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Last_Used_At.After(sessions[j].Last_Used_At)
+	})
+
+	return sessions, nil
+}
+
+func (store *MemoryStore) RotateSessionToken(ctx context.Context, sessionId string, currentTokenHash string, newTokenHash string, ipAddress string, lastUsedAt time.Time, expiresAt time.Time) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedSession, exists := store.sessions[sessionId]
+	if !exists || storedSession.Refresh_Token_Hash != currentTokenHash {
+		return false, nil
+	}
+
+	storedSession.Refresh_Token_Hash = newTokenHash
+	storedSession.IP_Address = ipAddress
+	storedSession.Last_Used_At = lastUsedAt
+	storedSession.Expires_At = expiresAt
+	store.sessions[sessionId] = storedSession
+	return true, nil
+}
+
+func (store *MemoryStore) DeleteSession(ctx context.Context, sessionId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, exists := store.sessions[sessionId]; !exists {
+		return ErrNotFound
+	}
+
+	delete(store.sessions, sessionId)
+	return nil
+}
+
+func (store *MemoryStore) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the resets which have expired, like the TTL index does in mongo.
+	now := time.Now()
+	for tokenHash, storedReset := range store.resets {
+		if storedReset.Expires_At.Before(now) {
+			delete(store.resets, tokenHash)
+		}
+	}
+
+	if _, exists := store.resets[reset.Token_Hash]; exists {
+		return ErrDuplicate
+	}
+
+	store.resets[reset.Token_Hash] = *reset
+	return nil
+}
+
+func (store *MemoryStore) TakePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedReset, exists := store.resets[tokenHash]
+	if !exists || storedReset.Expires_At.Before(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	delete(store.resets, tokenHash)
+	return &storedReset, nil
+}
+
+func (store *MemoryStore) DeletePasswordResets(ctx context.Context, userId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for tokenHash, storedReset := range store.resets {
+		if storedReset.User_Id == userId {
+			delete(store.resets, tokenHash)
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) SetTOTPSecret(ctx context.Context, userId string, secret string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists || storedUser.TOTP_Enabled {
+		return false, nil
+	}
+
+	storedUser.TOTP_Secret = secret
+	return true, nil
+}
+
+func (store *MemoryStore) EnableTOTP(ctx context.Context, userId string, step int64) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists || storedUser.TOTP_Enabled || storedUser.TOTP_Secret == "" {
+		return false, nil
+	}
+
+	storedUser.TOTP_Enabled = true
+	storedUser.TOTP_Last_Step = step
+	return true, nil
+}
+
+func (store *MemoryStore) DisableTOTP(ctx context.Context, userId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists {
+		return ErrNotFound
+	}
+
+	storedUser.TOTP_Enabled = false
+	storedUser.TOTP_Secret = ""
+	storedUser.TOTP_Last_Step = 0
+	delete(store.recoveryCodes, userId)
+	return nil
+}
+
+func (store *MemoryStore) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedUser, exists := store.users[userId]
+	if !exists || step <= storedUser.TOTP_Last_Step {
+		return false, nil
+	}
+
+	storedUser.TOTP_Last_Step = step
+	return true, nil
+}
+
+func (store *MemoryStore) SetRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	codes := make(map[string]bool)
+	for _, codeHash := range codeHashes {
+		codes[codeHash] = true
+	}
+
+	store.recoveryCodes[userId] = codes
+	return nil
+}
+
+func (store *MemoryStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if !store.recoveryCodes[userId][codeHash] {
+		return false, nil
+	}
+
+	delete(store.recoveryCodes[userId], codeHash)
+	return true, nil
+}
+
+func (store *MemoryStore) CountRecoveryCodes(ctx context.Context, userId string) (int, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return len(store.recoveryCodes[userId]), nil
+}
+
+func accessTokenExpired(accessToken models.AccessToken, now time.Time) bool {
+	return accessToken.Expires_At != nil && accessToken.Expires_At.Before(now)
+}
+
+func (store *MemoryStore) CreateAccessToken(ctx context.Context, accessToken *models.AccessToken) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the tokens which have expired, like the TTL index does in mongo.
+	now := time.Now()
+	for tokenId, existing := range store.accessTokens {
+		if accessTokenExpired(existing, now) {
+			delete(store.accessTokens, tokenId)
+		}
+	}
+
+	for _, existing := range store.accessTokens {
+		if existing.Token_Hash == accessToken.Token_Hash {
+			return ErrDuplicate
+		}
+	}
+
+	stored := *accessToken
+	stored.Scopes = slices.Clone(accessToken.Scopes)
+	store.accessTokens[accessToken.ID.Hex()] = stored
+	return nil
+}
+
+func (store *MemoryStore) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	for _, accessToken := range store.accessTokens {
+		if accessToken.Token_Hash == tokenHash && !accessTokenExpired(accessToken, now) {
+			accessToken.Scopes = slices.Clone(accessToken.Scopes)
+			return &accessToken, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) ListAccessTokens(ctx context.Context, userId string) ([]models.AccessToken, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	accessTokens := []models.AccessToken{}
+	for _, accessToken := range store.accessTokens {
+		if accessToken.User_Id == userId && !accessTokenExpired(accessToken, now) {
+			accessToken.Scopes = slices.Clone(accessToken.Scopes)
+			accessTokens = append(accessTokens, accessToken)
+		}
+	}
+
+	sort.Slice(accessTokens, func(i, j int) bool {
+		return accessTokens[i].ID.Hex() > accessTokens[j].ID.Hex()
+	})
+
+	return accessTokens, nil
+}
+
+func (store *MemoryStore) SetAccessTokenLastUsed(ctx context.Context, tokenId string, lastUsedAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	accessToken, ok := store.accessTokens[tokenId]
+	if !ok {
+		return ErrNotFound
+	}
+
+	accessToken.Last_Used_At = &lastUsedAt
+	store.accessTokens[tokenId] = accessToken
+	return nil
+}
+
+func (store *MemoryStore) DeleteAccessToken(ctx context.Context, userId string, tokenId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	accessToken, ok := store.accessTokens[tokenId]
+	if !ok || accessToken.User_Id != userId {
+		return ErrNotFound
+	}
+
+	delete(store.accessTokens, tokenId)
+	return nil
+}
+
+func (store *MemoryStore) DeleteAccessTokens(ctx context.Context, userId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for tokenId, accessToken := range store.accessTokens {
+		if accessToken.User_Id == userId {
+			delete(store.accessTokens, tokenId)
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) RecordLoginFailure(ctx context.Context, key string, failedAt time.Time, forgetBefore time.Time, expiresAt time.Time) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the failures which have expired anyway, like the TTL index does in mongo.
+	for otherKey, failures := range store.loginFailures {
+		if !failures.Expires_At.After(failedAt) {
+			delete(store.loginFailures, otherKey)
+		}
+	}
+
+	failures := store.loginFailures[key]
+	if failures.Last_Failure_At.Before(forgetBefore) {
+		failures.Failures = 0
+	}
+
+	failures.Key = key
+	failures.Failures++
+	failures.Last_Failure_At = failedAt
+	failures.Expires_At = expiresAt
+	store.loginFailures[key] = failures
+
+	return failures.Failures, nil
+}
+
+func (store *MemoryStore) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	failures, ok := store.loginFailures[key]
+	if !ok || !failures.Expires_At.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	return &failures, nil
+}
+
+func (store *MemoryStore) ClearLoginFailures(ctx context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.loginFailures, key)
+	return nil
+}
+
+func (store *MemoryStore) CreateLockout(ctx context.Context, lockout *models.Lockout) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.lockouts = append(store.lockouts, *lockout)
+	return nil
+}
+
+func (store *MemoryStore) ListLockouts(ctx context.Context, limit int) ([]models.Lockout, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	lockouts := make([]models.Lockout, 0, min(limit, len(store.lockouts)))
+	for i := len(store.lockouts) - 1; i >= 0 && len(lockouts) < limit; i-- {
+		lockouts = append(lockouts, store.lockouts[i])
+	}
+
+	return lockouts, nil
+}
+
+func (store *MemoryStore) RevokeToken(ctx context.Context, revokedToken *models.RevokedToken) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the entries of tokens which have expired anyway, like the TTL index does in mongo.
+	now := time.Now()
+	for tokenId, storedToken := range store.revokedTokens {
+		if storedToken.Expires_At.Before(now) {
+			delete(store.revokedTokens, tokenId)
+		}
+	}
+
+	store.revokedTokens[revokedToken.Token_Id] = *revokedToken
+	return nil
+}
+
+func (store *MemoryStore) ConsumeToken(ctx context.Context, revokedToken *models.RevokedToken) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, exists := store.revokedTokens[revokedToken.Token_Id]; exists {
+		return false, nil
+	}
+
+	store.revokedTokens[revokedToken.Token_Id] = *revokedToken
+	return true, nil
+}
+
+func (store *MemoryStore) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	_, exists := store.revokedTokens[tokenId]
+	return exists, nil
+}
+
+func (store *MemoryStore) CreateNote(ctx context.Context, note *models.NoteData) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, storedNote := range store.notes {
+		if storedNote.Unique_Header != nil && note.Unique_Header != nil && *storedNote.Unique_Header == *note.Unique_Header {
+			return ErrDuplicate
+		}
+	}
+
+	if _, exists := store.notes[note.ID.Hex()]; exists {
+		return ErrDuplicate
+	}
+
+	store.notes[note.ID.Hex()] = copyNote(note)
+	return nil
+}
+
+func (store *MemoryStore) GetNoteById(ctx context.Context, noteId string) (*models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedNote, exists := store.notes[noteId]
+	if !exists || storedNote.Deleted_At != nil {
+		return nil, ErrNotFound
+	}
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) GetNoteByUniqueHeader(ctx context.Context, uniqueHeader string) (*models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, storedNote := range store.notes {
+		if storedNote.Unique_Header != nil && *storedNote.Unique_Header == uniqueHeader {
+			return copyNote(storedNote), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Collects copies of the notes out of the trash matching the condition, in creation order.
+func (store *MemoryStore) filterNotes(match func(note *models.NoteData) bool) []models.NoteData {
+	foundNotes := []models.NoteData{}
+
+	for _, storedNote := range store.notes {
+		if storedNote.Deleted_At == nil && match(storedNote) {
+			foundNotes = append(foundNotes, *copyNote(storedNote))
+		}
+	}
+
+	sortNotes(foundNotes)
+	return foundNotes
+}
+
+// Whether the note is in the scope of a listing for the user, or in the workspace of the filter if it has one.
+func isNoteInScope(note *models.NoteData, userId string, scope string, filter *NoteFilter) bool {
+	if filter.Workspace_Id != "" {
+		return stringValue(note.Workspace_Id) == filter.Workspace_Id
+	}
+
+	switch scope {
+	case OwnedNotesScope:
+		return isNoteOwned(note, userId)
+	case SharedNotesScope:
+		for _, access := range note.Access {
+			if access.User_Id == userId {
+				return true
+			}
+		}
+		return false
+	case PublicNotesScope:
+		return note.Sharable != nil && *note.Sharable
+	default:
+		return isNoteViewable(note, userId)
+	}
+}
+
+// Compares the positions of two notes in a listing sorted ascending by the given field.
+func compareNoteCursors(a *NoteCursor, b *NoteCursor, sortBy string) int {
+	if sortBy == SortByHeader {
+		if a.Header != b.Header {
+			return strings.Compare(a.Header, b.Header)
+		}
+	} else if !a.Time.Equal(b.Time) {
+		return a.Time.Compare(b.Time)
+	}
+
+	return strings.Compare(a.Id, b.Id)
+}
+
+func (store *MemoryStore) ListAllNotes(ctx context.Context, afterId string, limit int) ([]models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	foundNotes := store.filterNotes(func(note *models.NoteData) bool {
+		return note.ID.Hex() > afterId
+	})
+
+	sort.Slice(foundNotes, func(i, j int) bool {
+		return foundNotes[i].ID.Hex() < foundNotes[j].ID.Hex()
+	})
+
+	if len(foundNotes) > limit {
+		foundNotes = foundNotes[:limit]
+	}
+
+	return foundNotes, nil
+}
+
+func (store *MemoryStore) ListNotes(ctx context.Context, query *NoteListQuery) ([]models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	foundNotes := store.filterNotes(func(note *models.NoteData) bool {
+		return isNoteInScope(note, query.User_Id, query.Scope, &query.Filter) && hasAllTags(note.Tags, query.Filter.Tags) &&
+			isInNotebooks(note, query.Filter.Notebook_Ids)
+	})
+
+	// Order the notes by the sort field, and the note id on ties.
+	compare := func(a *models.NoteData, b *models.NoteData) int {
+		result := compareNoteCursors(NoteCursorOf(a, query.Sort_By), NoteCursorOf(b, query.Sort_By), query.Sort_By)
+		if query.Descending {
+			return -result
+		}
+		return result
+	}
+
+	sort.SliceStable(foundNotes, func(i, j int) bool {
+		return compare(&foundNotes[i], &foundNotes[j]) < 0
+	})
+
+	page := []models.NoteData{}
+
+	for _, note := range foundNotes {
+		if query.After != nil {
+			result := compareNoteCursors(NoteCursorOf(&note, query.Sort_By), query.After, query.Sort_By)
+			if query.Descending {
+				result = -result
+			}
+			if result <= 0 {
+				continue
+			}
+		}
+
+		if query.Limit > 0 && len(page) == query.Limit {
+			break
+		}
+
+		page = append(page, note)
+	}
+
+	return page, nil
+}
+
+func (store *MemoryStore) SearchNotes(ctx context.Context, userId string, query *search.Query, filter *NoteFilter) ([]models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.filterNotes(func(note *models.NoteData) bool {
+		return isNoteInScope(note, userId, AllNotesScope, filter) && hasAllTags(note.Tags, filter.Tags) && isInNotebooks(note, filter.Notebook_Ids) &&
+			query.Matches(stringValue(note.Header), stringValue(note.Data))
+	}), nil
+}
+
+func (store *MemoryStore) ListTags(ctx context.Context, userId string) ([]models.TagCount, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	counts := map[string]int{}
+
+	for _, note := range store.notes {
+		if isNoteOwned(note, userId) && note.Deleted_At == nil {
+			for _, tag := range note.Tags {
+				counts[tag]++
+			}
+		}
+	}
+
+	tagCounts := []models.TagCount{}
+	for tag, count := range counts {
+		tagCounts = append(tagCounts, models.TagCount{Tag: tag, Count: count})
+	}
+
+	sort.Slice(tagCounts, func(i, j int) bool {
+		if tagCounts[i].Count != tagCounts[j].Count {
+			return tagCounts[i].Count > tagCounts[j].Count
+		}
+
+		return tagCounts[i].Tag < tagCounts[j].Tag
+	})
+
+	return tagCounts, nil
+}
+
+func (store *MemoryStore) ReplaceTags(ctx context.Context, userId string, oldTags []string, newTag string) ([]models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	changedNotes := []models.NoteData{}
+	updatedAt := time.Now()
+
+	for _, storedNote := range store.notes {
+		if !isNoteOwned(storedNote, userId) || storedNote.Deleted_At != nil {
+			continue
+		}
+
+		if !hasAnyTag(storedNote.Tags, oldTags) {
+			continue
+		}
+
+		storedNote.Tags = replaceTags(storedNote.Tags, oldTags, newTag)
+		storedNote.Updated_At = updatedAt
+		storedNote.Version++
+
+		changedNotes = append(changedNotes, *copyNote(storedNote))
+	}
+
+	sortNotes(changedNotes)
+	return changedNotes, nil
+}
+
+// Gets the stored note for a change, if it is still at the given version.
+func (store *MemoryStore) noteAtVersion(noteId string, version int64) (*models.NoteData, error) {
+	storedNote, exists := store.notes[noteId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if storedNote.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	return storedNote, nil
+}
+
+func (store *MemoryStore) UpdateNote(ctx context.Context, noteId string, version int64, update *NoteUpdate) (*models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNote, err := store.noteAtVersion(noteId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Unique_Header != nil {
+		for id, otherNote := range store.notes {
+			if id != noteId && stringValue(otherNote.Unique_Header) == *update.Unique_Header {
+				return nil, ErrDuplicate
+			}
+		}
+	}
+
+	if update.Header != nil {
+		storedNote.Header = copyString(update.Header)
+	}
+
+	if update.Unique_Header != nil {
+		storedNote.Unique_Header = copyString(update.Unique_Header)
+	}
+
+	if update.Data != nil {
+		storedNote.Data = copyString(update.Data)
+	}
+
+	if update.Sharable != nil {
+		storedNote.Sharable = copyBool(update.Sharable)
+	}
+
+	if update.Notebook_Id != nil {
+		storedNote.Notebook_Id = nil
+		if *update.Notebook_Id != "" {
+			storedNote.Notebook_Id = copyString(update.Notebook_Id)
+		}
+	}
+
+	if update.Tags != nil {
+		storedNote.Tags = append([]string{}, (*update.Tags)...)
+	}
+
+	storedNote.Updated_At = update.Updated_At
+	storedNote.Version++
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) SetNoteAccess(ctx context.Context, noteId string, version int64, accessList []models.NoteAccess) (*models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNote, err := store.noteAtVersion(noteId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	storedNote.Access = copyNote(&models.NoteData{Access: accessList}).Access
+	storedNote.Updated_At = time.Now()
+	storedNote.Version++
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) DeleteNote(ctx context.Context, noteId string, version int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, err := store.noteAtVersion(noteId, version)
+	if err != nil {
+		return err
+	}
+
+	delete(store.notes, noteId)
+	return nil
+}
+
+func (store *MemoryStore) TrashNote(ctx context.Context, noteId string, version int64, deletedAt time.Time) (*models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNote, err := store.noteAtVersion(noteId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	trashedUniqueHeader := TrashedUniqueHeader(noteId)
+	storedNote.Unique_Header = &trashedUniqueHeader
+	storedNote.Deleted_At = &deletedAt
+	storedNote.Version++
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) RestoreNote(ctx context.Context, noteId string, version int64, uniqueHeader string, notebookId *string) (*models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNote, err := store.noteAtVersion(noteId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, otherNote := range store.notes {
+		if id != noteId && stringValue(otherNote.Unique_Header) == uniqueHeader {
+			return nil, ErrDuplicate
+		}
+	}
+
+	storedNote.Unique_Header = &uniqueHeader
+	storedNote.Notebook_Id = copyString(notebookId)
+	storedNote.Deleted_At = nil
+	storedNote.Version++
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) GetTrashedNote(ctx context.Context, noteId string) (*models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedNote, exists := store.notes[noteId]
+	if !exists || storedNote.Deleted_At == nil {
+		return nil, ErrNotFound
+	}
+
+	return copyNote(storedNote), nil
+}
+
+func (store *MemoryStore) ListTrash(ctx context.Context, userId string) ([]models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.trashedNotes(func(note *models.NoteData) bool { return isNoteOwned(note, userId) }), nil
+}
+
+func (store *MemoryStore) ListWorkspaceTrash(ctx context.Context, workspaceId string) ([]models.NoteData, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.trashedNotes(func(note *models.NoteData) bool { return stringValue(note.Workspace_Id) == workspaceId }), nil
+}
+
+// Collects copies of the notes in the trash matching the condition, the last deleted first.
+func (store *MemoryStore) trashedNotes(match func(note *models.NoteData) bool) []models.NoteData {
+	trashedNotes := []models.NoteData{}
+
+	for _, storedNote := range store.notes {
+		if storedNote.Deleted_At != nil && match(storedNote) {
+			trashedNotes = append(trashedNotes, *copyNote(storedNote))
+		}
+	}
+
+	sort.Slice(trashedNotes, func(i, j int) bool {
+		if !trashedNotes[i].Deleted_At.Equal(*trashedNotes[j].Deleted_At) {
+			return trashedNotes[i].Deleted_At.After(*trashedNotes[j].Deleted_At)
+		}
+
+		return trashedNotes[i].ID.Hex() > trashedNotes[j].ID.Hex()
+	})
+
+	return trashedNotes
+}
+
+func (store *MemoryStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var purged int64
+
+	for id, storedNote := range store.notes {
+		if storedNote.Deleted_At != nil && storedNote.Deleted_At.Before(deletedBefore) {
+			delete(store.notes, id)
+			delete(store.noteRevisions, id)
+			store.deleteNoteShareLinks(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Whether the parent of the user holds a notebook with the name, other than the given one.
+func (store *MemoryStore) hasSiblingNamed(userId string, parentId *string, name string, notebookId string) bool {
+	for id, storedNotebook := range store.notebooks {
+		if id != notebookId && storedNotebook.User_Id == userId && stringValue(storedNotebook.Parent_Id) == stringValue(parentId) && storedNotebook.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (store *MemoryStore) CreateNotebook(ctx context.Context, notebook *models.Notebook) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, exists := store.notebooks[notebook.ID.Hex()]; exists {
+		return ErrDuplicate
+	}
+
+	if store.hasSiblingNamed(notebook.User_Id, notebook.Parent_Id, notebook.Name, "") {
+		return ErrDuplicate
+	}
+
+	store.notebooks[notebook.ID.Hex()] = copyNotebook(notebook)
+	return nil
+}
+
+func (store *MemoryStore) GetNotebookById(ctx context.Context, notebookId string) (*models.Notebook, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedNotebook, exists := store.notebooks[notebookId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return copyNotebook(storedNotebook), nil
+}
+
+func (store *MemoryStore) ListNotebooks(ctx context.Context, userId string) ([]models.Notebook, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	foundNotebooks := []models.Notebook{}
+
+	for _, storedNotebook := range store.notebooks {
+		if storedNotebook.User_Id == userId {
+			foundNotebooks = append(foundNotebooks, *copyNotebook(storedNotebook))
+		}
+	}
+
+	sortNotebooks(foundNotebooks)
+	return foundNotebooks, nil
+}
+
+func (store *MemoryStore) UpdateNotebook(ctx context.Context, notebookId string, update *NotebookUpdate) (*models.Notebook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNotebook, exists := store.notebooks[notebookId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	name, parentId, ancestors := storedNotebook.Name, storedNotebook.Parent_Id, storedNotebook.Ancestors
+
+	if update.Name != nil {
+		name = *update.Name
+	}
+
+	if update.Parent_Id != nil {
+		parentId, ancestors = nil, []string{}
+
+		if *update.Parent_Id != "" {
+			parent, exists := store.notebooks[*update.Parent_Id]
+			if !exists {
+				return nil, ErrNotFound
+			}
+			parentId, ancestors = copyString(update.Parent_Id), append(append([]string{}, parent.Ancestors...), parent.ID.Hex())
+		}
+	}
+
+	if store.hasSiblingNamed(storedNotebook.User_Id, parentId, name, notebookId) {
+		return nil, ErrDuplicate
+	}
+
+	// The notebooks below it move along.
+	if update.Parent_Id != nil {
+		for _, descendant := range store.notebooks {
+			if slices.Contains(descendant.Ancestors, notebookId) {
+				descendant.Ancestors = movedAncestors(descendant.Ancestors, notebookId, ancestors)
+			}
+		}
+	}
+
+	storedNotebook.Name, storedNotebook.Parent_Id, storedNotebook.Ancestors = name, parentId, ancestors
+	storedNotebook.Updated_At = update.Updated_At
+
+	return copyNotebook(storedNotebook), nil
+}
+
+func (store *MemoryStore) DeleteNotebook(ctx context.Context, notebookId string, cascade bool) ([]models.NoteData, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedNotebook, exists := store.notebooks[notebookId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	changedNotes := []models.NoteData{}
+
+	if cascade {
+		subtree := map[string]bool{notebookId: true}
+		for id, descendant := range store.notebooks {
+			if slices.Contains(descendant.Ancestors, notebookId) {
+				subtree[id] = true
+			}
+		}
+
+		deletedAt := time.Now()
+
+		for id, storedNote := range store.notes {
+			if storedNote.Notebook_Id == nil || !subtree[*storedNote.Notebook_Id] {
+				continue
+			}
+
+			// The notes go to the trash outside of notebooks, where they come back to when restored.
+			storedNote.Notebook_Id = nil
+			storedNote.Version++
+
+			if storedNote.Deleted_At == nil {
+				trashedUniqueHeader := TrashedUniqueHeader(id)
+				storedNote.Unique_Header = &trashedUniqueHeader
+				storedNote.Deleted_At = &deletedAt
+				changedNotes = append(changedNotes, *copyNote(storedNote))
+			}
+		}
+
+		for id := range subtree {
+			delete(store.notebooks, id)
+		}
+
+		sortNotes(changedNotes)
+		return changedNotes, nil
+	}
+
+	// Check that nothing moving up clashes with what is already in the parent, before moving anything.
+	uniqueHeaders := map[string]string{}
+
+	for id, child := range store.notebooks {
+		if stringValue(child.Parent_Id) == notebookId && store.hasSiblingNamed(child.User_Id, storedNotebook.Parent_Id, child.Name, id) {
+			return nil, ErrDuplicate
+		}
+	}
+
+	for id, storedNote := range store.notes {
+		if stringValue(storedNote.Notebook_Id) != notebookId {
+			continue
+		}
+
+		// Notes in the trash move along, keeping their trashed unique header.
+		if storedNote.Deleted_At != nil {
+			uniqueHeaders[id] = stringValue(storedNote.Unique_Header)
+			continue
+		}
+
+		uniqueHeader := UniqueHeader(stringValue(storedNote.User_Id), storedNotebook.Parent_Id, stringValue(storedNote.Header))
+		for _, otherNote := range store.notes {
+			if stringValue(otherNote.Unique_Header) == uniqueHeader {
+				return nil, ErrDuplicate
+			}
+		}
+
+		uniqueHeaders[id] = uniqueHeader
+	}
+
+	for _, descendant := range store.notebooks {
+		if stringValue(descendant.Parent_Id) == notebookId {
+			descendant.Parent_Id = copyString(storedNotebook.Parent_Id)
+		}
+		descendant.Ancestors = reparentedAncestors(descendant.Ancestors, notebookId)
+	}
+
+	updatedAt := time.Now()
+
+	for id, uniqueHeader := range uniqueHeaders {
+		storedNote := store.notes[id]
+		storedNote.Notebook_Id = copyString(storedNotebook.Parent_Id)
+		storedNote.Unique_Header = copyString(&uniqueHeader)
+		storedNote.Updated_At = updatedAt
+		storedNote.Version++
+
+		changedNotes = append(changedNotes, *copyNote(storedNote))
+	}
+
+	delete(store.notebooks, notebookId)
+	sortNotes(changedNotes)
+	return changedNotes, nil
+}
+
+func (store *MemoryStore) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, exists := store.workspaces[workspace.ID.Hex()]; exists {
+		return ErrDuplicate
+	}
+
+	store.workspaces[workspace.ID.Hex()] = copyWorkspace(workspace)
+	return nil
+}
+
+func (store *MemoryStore) GetWorkspaceById(ctx context.Context, workspaceId string) (*models.Workspace, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	storedWorkspace, exists := store.workspaces[workspaceId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return copyWorkspace(storedWorkspace), nil
+}
+
+func (store *MemoryStore) ListWorkspaces(ctx context.Context, userId string) ([]models.Workspace, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	workspaces := []models.Workspace{}
+
+	for _, storedWorkspace := range store.workspaces {
+		if slices.ContainsFunc(storedWorkspace.Members, func(member models.WorkspaceMember) bool { return member.User_Id == userId }) {
+			workspaces = append(workspaces, *copyWorkspace(storedWorkspace))
+		}
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Name != workspaces[j].Name {
+			return workspaces[i].Name < workspaces[j].Name
+		}
+
+		return workspaces[i].ID.Hex() < workspaces[j].ID.Hex()
+	})
+
+	return workspaces, nil
+}
+
+// Gets the stored workspace for a change, if it is still at the given version.
+func (store *MemoryStore) workspaceAtVersion(workspaceId string, version int64) (*models.Workspace, error) {
+	storedWorkspace, exists := store.workspaces[workspaceId]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	if storedWorkspace.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	return storedWorkspace, nil
+}
+
+func (store *MemoryStore) RenameWorkspace(ctx context.Context, workspaceId string, version int64, name string, updatedAt time.Time) (*models.Workspace, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedWorkspace, err := store.workspaceAtVersion(workspaceId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	storedWorkspace.Name = name
+	storedWorkspace.Updated_At = updatedAt
+	storedWorkspace.Version++
+
+	return copyWorkspace(storedWorkspace), nil
+}
+
+func (store *MemoryStore) SetWorkspaceMembers(ctx context.Context, workspaceId string, version int64, members []models.WorkspaceMember) (*models.Workspace, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	storedWorkspace, err := store.workspaceAtVersion(workspaceId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	storedWorkspace.Members = copyWorkspace(&models.Workspace{Members: members}).Members
+	storedWorkspace.Updated_At = time.Now()
+	storedWorkspace.Version++
+
+	return copyWorkspace(storedWorkspace), nil
+}
+
+func (store *MemoryStore) DeleteWorkspace(ctx context.Context, workspaceId string, version int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, err := store.workspaceAtVersion(workspaceId, version)
+	if err != nil {
+		return err
+	}
+
+	for _, note := range store.notes {
+		if stringValue(note.Workspace_Id) == workspaceId {
+			return ErrNotEmpty
+		}
+	}
+
+	delete(store.workspaces, workspaceId)
+
+	for invitationId, invitation := range store.invitations {
+		if invitation.Workspace_Id == workspaceId {
+			delete(store.invitations, invitationId)
+		}
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) CreateWorkspaceInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for invitationId, storedInvitation := range store.invitations {
+		if storedInvitation.Workspace_Id == invitation.Workspace_Id && storedInvitation.Email == invitation.Email {
+			delete(store.invitations, invitationId)
+		}
+	}
+
+	store.invitations[invitation.ID.Hex()] = *invitation
+	return nil
+}
+
+func (store *MemoryStore) GetWorkspaceInvitationByHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	for _, invitation := range store.invitations {
+		if invitation.Token_Hash == tokenHash && invitation.Expires_At.After(now) {
+			return &invitation, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) ListWorkspaceInvitations(ctx context.Context, workspaceId string) ([]models.WorkspaceInvitation, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	invitations := []models.WorkspaceInvitation{}
+
+	now := time.Now()
+	for _, invitation := range store.invitations {
+		if invitation.Workspace_Id == workspaceId && invitation.Expires_At.After(now) {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].Created_At.Equal(invitations[j].Created_At) {
+			return invitations[i].Created_At.After(invitations[j].Created_At)
+		}
+
+		return invitations[i].ID.Hex() > invitations[j].ID.Hex()
+	})
+
+	return invitations, nil
+}
+
+func (store *MemoryStore) DeleteWorkspaceInvitation(ctx context.Context, workspaceId string, invitationId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	invitation, ok := store.invitations[invitationId]
+	if !ok || invitation.Workspace_Id != workspaceId {
+		return ErrNotFound
+	}
+
+	delete(store.invitations, invitationId)
+	return nil
+}
+
+func shareLinkExpired(link models.ShareLink, now time.Time) bool {
+	return link.Expires_At != nil && link.Expires_At.Before(now)
+}
+
+func (store *MemoryStore) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Drop the links which have expired, like the TTL index does in mongo.
+	now := time.Now()
+	for linkId, existing := range store.shareLinks {
+		if shareLinkExpired(existing, now) {
+			delete(store.shareLinks, linkId)
+		}
+	}
+
+	for _, existing := range store.shareLinks {
+		if existing.Token_Hash == link.Token_Hash {
+			return ErrDuplicate
+		}
+	}
+
+	store.shareLinks[link.ID.Hex()] = *link
+	return nil
+}
+
+func (store *MemoryStore) GetShareLinkByHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	for _, link := range store.shareLinks {
+		if link.Token_Hash == tokenHash && !shareLinkExpired(link, now) {
+			return &link, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) ListShareLinks(ctx context.Context, noteId string) ([]models.ShareLink, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	links := []models.ShareLink{}
+	for _, link := range store.shareLinks {
+		if link.Note_Id == noteId && !shareLinkExpired(link, now) {
+			links = append(links, link)
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID.Hex() > links[j].ID.Hex()
+	})
+
+	return links, nil
+}
+
+func (store *MemoryStore) RecordShareLinkView(ctx context.Context, linkId string, viewedAt time.Time) (*models.ShareLink, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	link, ok := store.shareLinks[linkId]
+	if !ok || shareLinkExpired(link, viewedAt) || (link.Max_Views != nil && link.Views >= *link.Max_Views) {
+		return nil, ErrNotFound
+	}
+
+	link.Views++
+	link.Last_Viewed_At = &viewedAt
+	store.shareLinks[linkId] = link
+	return &link, nil
+}
+
+func (store *MemoryStore) DeleteShareLink(ctx context.Context, noteId string, linkId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	link, ok := store.shareLinks[linkId]
+	if !ok || link.Note_Id != noteId {
+		return ErrNotFound
+	}
+
+	delete(store.shareLinks, linkId)
+	return nil
+}
+
+func (store *MemoryStore) DeleteNoteShareLinks(ctx context.Context, noteId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deleteNoteShareLinks(noteId)
+	return nil
+}
+
+// Deletes the links of the note, with the lock held.
+func (store *MemoryStore) deleteNoteShareLinks(noteId string) {
+	for linkId, link := range store.shareLinks {
+		if link.Note_Id == noteId {
+			delete(store.shareLinks, linkId)
+		}
+	}
+}
+
+func (store *MemoryStore) CreateNoteRevision(ctx context.Context, revision *models.NoteRevision) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, storedRevision := range store.noteRevisions[revision.Note_Id] {
+		if storedRevision.Revision == revision.Revision {
+			return ErrDuplicate
+		}
+	}
+
+	revisions := append(store.noteRevisions[revision.Note_Id], *copyNoteRevision(revision))
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	store.noteRevisions[revision.Note_Id] = revisions
+
+	return nil
+}
+
+func (store *MemoryStore) GetLatestNoteRevisionNumber(ctx context.Context, noteId string) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	revisions := store.noteRevisions[noteId]
+	if len(revisions) == 0 {
+		return 0, nil
+	}
+
+	return revisions[len(revisions)-1].Revision, nil
+}
+
+func (store *MemoryStore) GetNoteRevision(ctx context.Context, noteId string, revision int64) (*models.NoteRevision, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, storedRevision := range store.noteRevisions[noteId] {
+		if storedRevision.Revision == revision {
+			return copyNoteRevision(&storedRevision), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryStore) ListNoteRevisions(ctx context.Context, noteId string) ([]models.NoteRevision, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	foundRevisions := []models.NoteRevision{}
+
+	for _, storedRevision := range store.noteRevisions[noteId] {
+		foundRevision := copyNoteRevision(&storedRevision)
+		foundRevision.Data = nil
+		foundRevisions = append(foundRevisions, *foundRevision)
+	}
+
+	return foundRevisions, nil
+}
+
+func (store *MemoryStore) DeleteNoteRevisions(ctx context.Context, noteId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.noteRevisions, noteId)
+	return nil
+}
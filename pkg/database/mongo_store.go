@@ -0,0 +1,2471 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store keeping everything in MongoDB collections.
+type MongoStore struct {
+	mongoObject *MongoDBObject
+}
+
+func NewMongoStore(mongoConfig config.MongoConfig) (*MongoStore, error) {
+	mongoObject, err := GetDB(mongoConfig)
+	if err != nil {
+		return nil, err
+	}
+	MongoObject = mongoObject
+
+	store := &MongoStore{mongoObject: mongoObject}
+
+	err = store.createIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (store *MongoStore) Close(ctx context.Context) error {
+	defer store.mongoObject.Cancel()
+
+	return store.mongoObject.Client.Disconnect(ctx)
+}
+
+// Creates the indexes the store relies on, once at startup.
+func (store *MongoStore) createIndexes() error {
+	revokedTokenCollection, err := store.mongoObject.GetRevokedTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	// The TTL index on expiresAt lets the database drop entries once the token has expired anyway.
+	_, err = revokedTokenCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the revoked token collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return err
+	}
+
+	// The TTL index on expiresAt lets the database drop sessions once their refresh token has expired.
+	_, err = sessionCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "lastUsedAt", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the session collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	passwordResetCollection, err := store.mongoObject.GetPasswordResetCollection()
+	if err != nil {
+		return err
+	}
+
+	// Reset tokens are found by their hash, and dropped by the database once they have expired.
+	_, err = passwordResetCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the password reset collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	recoveryCodeCollection, err := store.mongoObject.GetRecoveryCodeCollection()
+	if err != nil {
+		return err
+	}
+
+	// Recovery codes are found by the user and their hash.
+	_, err = recoveryCodeCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "codeHash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the recovery code collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	// Access tokens are found by their hash or listed by the user, and dropped by the database once they have expired.
+	// Tokens without an expiry have no expiresAt, which the TTL index leaves alone.
+	_, err = accessTokenCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the access token collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	loginFailureCollection, err := store.mongoObject.GetLoginFailureCollection()
+	if err != nil {
+		return err
+	}
+
+	// Failed logins are kept by their key, and dropped by the database once they are forgotten.
+	_, err = loginFailureCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the login failure collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	lockoutCollection, err := store.mongoObject.GetLockoutCollection()
+	if err != nil {
+		return err
+	}
+
+	// Lockouts are listed newest first.
+	_, err = lockoutCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "lockedAt", Value: -1}},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the lockout collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return err
+	}
+
+	// Refresh tokens used to be stored in plain on the users, sessions keep only their hashes now.
+	_, err = userCollection.UpdateMany(store.mongoObject.Ctx,
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "refreshToken", Value: bson.D{{Key: "$exists", Value: true}}}},
+			bson.D{{Key: "tokenFamily", Value: bson.D{{Key: "$exists", Value: true}}}},
+		}}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "refreshToken", Value: ""}, {Key: "tokenFamily", Value: ""}}}})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while removing the old refresh tokens of the users.\n\tError: %s", err.Error())
+		return err
+	}
+
+	// Users from before email verification count as verified.
+	_, err = userCollection.UpdateMany(store.mongoObject.Ctx,
+		bson.D{{Key: "emailVerified", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "emailVerified", Value: true}}}})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while marking the users from before email verification verified.\n\tError: %s", err.Error())
+		return err
+	}
+
+	// Users from before roles are plain users.
+	_, err = userCollection.UpdateMany(store.mongoObject.Ctx,
+		bson.D{{Key: "role", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: models.UserRole}}}})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while giving the users from before roles the user role.\n\tError: %s", err.Error())
+		return err
+	}
+
+	// Verification tokens are found by their hash, which only unverified users have.
+	_, err = userCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "verificationTokenHash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the user collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return err
+	}
+
+	// Keeps revision numbers unique per note.
+	_, err = noteRevisionCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "noteId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating index on the note revision collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return err
+	}
+
+	// Serve the scopes of the note listing, and its sort orders with the id breaking ties.
+	var noteIndexes []mongo.IndexModel
+	for _, key := range []string{"userId", "access.userId", "sharable", "workspaceId"} {
+		noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: key, Value: 1}}})
+	}
+
+	// Serve the tag filters and the tags of a user.
+	noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: "tags", Value: 1}}})
+	noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: "notebookId", Value: 1}}})
+	noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "tags", Value: 1}}})
+
+	// Serve the trash of a user and its purge.
+	noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: "deletedAt", Value: 1}}})
+	for _, sortBy := range []string{SortByCreatedAt, SortByUpdatedAt, SortByHeader} {
+		noteIndexes = append(noteIndexes, mongo.IndexModel{Keys: bson.D{{Key: sortBy, Value: 1}, {Key: "_id", Value: 1}}})
+	}
+
+	// The text index of the search, a match in the header weighing three times as much.
+	// Without a language words are neither stemmed nor left out as stop words, like the search package does.
+	noteIndexes = append(noteIndexes, mongo.IndexModel{
+		Keys:    bson.D{{Key: "header", Value: "text"}, {Key: "notesData", Value: "text"}},
+		Options: options.Index().SetName("notesSearch").SetWeights(bson.D{{Key: "header", Value: 3}, {Key: "notesData", Value: 1}}).SetDefaultLanguage("none"),
+	})
+
+	// Keep two notes from getting the same unique header even when they are written at the same time.
+	// Every note has the header, trashed ones their own, the filter only leaves out notes made before it existed.
+	noteIndexes = append(noteIndexes, mongo.IndexModel{
+		Keys:    bson.D{{Key: "uniqueHeader", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{{Key: "uniqueHeader", Value: bson.D{{Key: "$type", Value: "string"}}}}),
+	})
+
+	_, err = noteCollection.Indexes().CreateMany(store.mongoObject.Ctx, noteIndexes)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the note collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return err
+	}
+
+	// Keeps the names of the notebooks unique among their siblings, and finds the notebooks below one.
+	_, err = notebookCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "parentId", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "ancestors", Value: 1}},
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the notebook collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return err
+	}
+
+	// Finds the workspaces of a member.
+	_, err = workspaceCollection.Indexes().CreateOne(store.mongoObject.Ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "members.userId", Value: 1}},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the workspace collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return err
+	}
+
+	// Invitations are found by their hash, kept one per email in a workspace, and dropped by the database once they have expired.
+	_, err = invitationCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "workspaceId", Value: 1}, {Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the invitation collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return err
+	}
+
+	// Links are found by their hash, listed by their note, and dropped by the database once they have expired.
+	_, err = shareLinkCollection.Indexes().CreateMany(store.mongoObject.Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "noteId", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating indexes on the share link collection.\n\tError: %s", err.Error())
+		return err
+	}
+
+	// The search used to create this index on every request, it only slows down writes now.
+	_, err = noteCollection.Indexes().DropOne(store.mongoObject.Ctx, "notesData_1")
+	if err != nil && !isMongoIndexNotFound(err) {
+		logger.Log.Printf("Error: Problem while dropping the old index on the notes data.\n\tError: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Reports whether dropping an index failed only because there is no such index.
+func isMongoIndexNotFound(err error) bool {
+	var commandError mongo.CommandError
+	return errors.As(err, &commandError) && (commandError.Code == 27 || commandError.Name == "IndexNotFound")
+}
+
+// Converts the "no documents" error of the driver to the one of the store.
+func mongoError(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+// Filter matching the note only while it is still at the given version.
+// Notes created before versions existed have no version field and count as version 0.
+func noteVersionFilter(version int64) bson.E {
+	if version == 0 {
+		return bson.E{Key: "version", Value: bson.D{{Key: "$in", Value: bson.A{0, nil}}}}
+	}
+
+	return bson.E{Key: "version", Value: version}
+}
+
+// Condition matching the notes out of the trash, the notes from before the trash have no deletedAt field at all.
+var liveNoteCondition = bson.E{Key: "deletedAt", Value: nil}
+
+// Adds the conditions of the filter to the ones of a query.
+func withNoteFilter(conditions bson.A, filter *NoteFilter) bson.A {
+	if len(filter.Tags) > 0 {
+		conditions = append(conditions, bson.D{{Key: "tags", Value: bson.D{{Key: "$all", Value: filter.Tags}}}})
+	}
+
+	if filter.Notebook_Ids != nil {
+		conditions = append(conditions, bson.D{{Key: "notebookId", Value: bson.D{{Key: "$in", Value: filter.Notebook_Ids}}}})
+	}
+
+	return conditions
+}
+
+// Filter matching the notes of the user outside of workspaces, the notes from before workspaces have no workspaceId field at all.
+func ownedNotesFilter(userId string) bson.D {
+	return bson.D{{Key: "userId", Value: userId}, {Key: "workspaceId", Value: nil}}
+}
+
+// Filter matching the notes in the scope of a listing, or every note of the workspace the filter asks for.
+func noteScopeFilter(userId string, scope string, filter *NoteFilter) bson.D {
+	if filter.Workspace_Id != "" {
+		return bson.D{{Key: "workspaceId", Value: filter.Workspace_Id}}
+	}
+
+	switch scope {
+	case OwnedNotesScope:
+		return ownedNotesFilter(userId)
+	case SharedNotesScope:
+		return bson.D{{Key: "access.userId", Value: userId}}
+	case PublicNotesScope:
+		return bson.D{{Key: "sharable", Value: true}}
+	default:
+		return viewableNotesFilter(userId)
+	}
+}
+
+// Filter matching every note the user can view.
+func viewableNotesFilter(userId string) bson.D {
+	return bson.D{
+		{Key: "$or", Value: bson.A{
+			ownedNotesFilter(userId),
+			bson.D{{Key: "sharable", Value: true}},
+			bson.D{{Key: "access.userId", Value: userId}},
+		}},
+	}
+}
+
+func (store *MongoStore) findUser(ctx context.Context, filter bson.D) (*models.UserDataServer, error) {
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	var foundUser models.UserDataServer
+
+	err = userCollection.FindOne(ctx, filter).Decode(&foundUser)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundUser, nil
+}
+
+func (store *MongoStore) updateUser(ctx context.Context, filter bson.D, updateObj primitive.D) (*mongo.UpdateResult, error) {
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create an update context.
+	ctxUpdate, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return userCollection.UpdateOne(ctxUpdate, filter, updateObj)
+}
+
+func (store *MongoStore) CreateUser(ctx context.Context, user *models.UserDataServer) error {
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return err
+	}
+
+	// Find whether user with the same email address exists in the collection already or not.
+	_, err = store.GetUserByEmail(ctx, *user.Email)
+	if err == nil {
+		return ErrDuplicate
+	}
+	if err != ErrNotFound {
+		return err
+	}
+
+	_, err = userCollection.InsertOne(ctx, user)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetUserByEmail(ctx context.Context, email string) (*models.UserDataServer, error) {
+	return store.findUser(ctx, bson.D{{Key: "email", Value: email}})
+}
+
+func (store *MongoStore) GetUserById(ctx context.Context, userId string) (*models.UserDataServer, error) {
+	return store.findUser(ctx, bson.D{{Key: "userId", Value: userId}})
+}
+
+func (store *MongoStore) UpdateLastLogin(ctx context.Context, userId string, lastLogin time.Time) error {
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{{Key: "lastLogin", Value: lastLogin}},
+		},
+	}
+
+	_, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	return err
+}
+
+func (store *MongoStore) UpdatePassword(ctx context.Context, userId string, password string, updatedAt time.Time) error {
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{
+				{Key: "password", Value: password},
+				{Key: "updatedAt", Value: updatedAt},
+			},
+		},
+	}
+
+	result, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) SetVerificationToken(ctx context.Context, userId string, tokenHash string, sentAt time.Time, expiresAt time.Time, sentBefore time.Time) (bool, error) {
+	filter := bson.D{
+		{Key: "userId", Value: userId},
+		{Key: "emailVerified", Value: false},
+		{Key: "verificationSentAt", Value: bson.D{{Key: "$lte", Value: sentBefore}}},
+	}
+
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{
+				{Key: "verificationTokenHash", Value: tokenHash},
+				{Key: "verificationSentAt", Value: sentAt},
+				{Key: "verificationExpiresAt", Value: expiresAt},
+			},
+		},
+	}
+
+	result, err := store.updateUser(ctx, filter, updateObj)
+	if err != nil {
+		return false, mongoError(err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+func (store *MongoStore) VerifyEmail(ctx context.Context, tokenHash string, verifiedAt time.Time) (*models.UserDataServer, error) {
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{
+		{Key: "verificationTokenHash", Value: tokenHash},
+		{Key: "emailVerified", Value: false},
+		{Key: "verificationExpiresAt", Value: bson.D{{Key: "$gte", Value: verifiedAt}}},
+	}
+
+	updateObj := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "emailVerified", Value: true}, {Key: "updatedAt", Value: verifiedAt}}},
+		{Key: "$unset", Value: bson.D{{Key: "verificationTokenHash", Value: ""}}},
+	}
+
+	var foundUser models.UserDataServer
+	err = userCollection.FindOneAndUpdate(ctx, filter, updateObj, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&foundUser)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundUser, nil
+}
+
+func (store *MongoStore) RevokeAllTokens(ctx context.Context, userId string, revokedBefore time.Time) error {
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{{Key: "revokedBefore", Value: revokedBefore}},
+		},
+	}
+
+	_, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	if err != nil {
+		return err
+	}
+
+	// The sessions go after the timestamp is set, so that a failure in between leaves no token of them usable.
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = sessionCollection.DeleteMany(ctx, bson.D{{Key: "userId", Value: userId}})
+	return err
+}
+
+func (store *MongoStore) ListUsers(ctx context.Context, query *UserListQuery) ([]models.UserDataServer, error) {
+	userCollection, err := store.mongoObject.GetUserCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "userId", Value: bson.D{{Key: "$gt", Value: query.After}}}}
+
+	if query.Role != "" {
+		filter = append(filter, bson.E{Key: "role", Value: query.Role})
+	}
+
+	if query.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Search), Options: "i"}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "email", Value: pattern}},
+			bson.D{{Key: "firstName", Value: pattern}},
+			bson.D{{Key: "lastName", Value: pattern}},
+		}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "userId", Value: 1}}).SetLimit(int64(query.Limit))
+
+	cursor, err := userCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundUsers := []models.UserDataServer{}
+
+	err = cursor.All(ctx, &foundUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundUsers, nil
+}
+
+func (store *MongoStore) SetUserRole(ctx context.Context, userId string, role string, updatedAt time.Time) error {
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{
+				{Key: "role", Value: role},
+				{Key: "updatedAt", Value: updatedAt},
+			},
+		},
+	}
+
+	result, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) SetUserDisabled(ctx context.Context, userId string, disabled bool, updatedAt time.Time) error {
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{
+				{Key: "disabled", Value: disabled},
+				{Key: "updatedAt", Value: updatedAt},
+			},
+		},
+	}
+
+	result, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) CreateSession(ctx context.Context, session *models.Session) error {
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = sessionCollection.InsertOne(ctx, session)
+	return mongoError(err)
+}
+
+// Condition matching the sessions which have not expired, the TTL monitor removes the others only once a minute.
+func liveSessionCondition() bson.E {
+	return bson.E{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: time.Now()}}}
+}
+
+func (store *MongoStore) GetSessionById(ctx context.Context, sessionId string) (*models.Session, error) {
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionIdPrimitive, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var foundSession models.Session
+
+	err = sessionCollection.FindOne(ctx, bson.D{{Key: "_id", Value: sessionIdPrimitive}, liveSessionCondition()}).Decode(&foundSession)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundSession, nil
+}
+
+func (store *MongoStore) ListSessions(ctx context.Context, userId string) ([]models.Session, error) {
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "lastUsedAt", Value: -1}})
+
+	cursor, err := sessionCollection.Find(ctx, bson.D{{Key: "userId", Value: userId}, liveSessionCondition()}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundSessions := []models.Session{}
+
+	err = cursor.All(ctx, &foundSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundSessions, nil
+}
+
+func (store *MongoStore) RotateSessionToken(ctx context.Context, sessionId string, currentTokenHash string, newTokenHash string, ipAddress string, lastUsedAt time.Time, expiresAt time.Time) (bool, error) {
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return false, err
+	}
+
+	sessionIdPrimitive, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return false, nil
+	}
+
+	filter := bson.D{{Key: "_id", Value: sessionIdPrimitive}, {Key: "refreshTokenHash", Value: currentTokenHash}}
+
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: primitive.D{
+				{Key: "refreshTokenHash", Value: newTokenHash},
+				{Key: "ipAddress", Value: ipAddress},
+				{Key: "lastUsedAt", Value: lastUsedAt},
+				{Key: "expiresAt", Value: expiresAt},
+			},
+		},
+	}
+
+	result, err := sessionCollection.UpdateOne(ctx, filter, updateObj)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+func (store *MongoStore) DeleteSession(ctx context.Context, sessionId string) error {
+	sessionCollection, err := store.mongoObject.GetSessionCollection()
+	if err != nil {
+		return err
+	}
+
+	sessionIdPrimitive, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	result, err := sessionCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: sessionIdPrimitive}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	passwordResetCollection, err := store.mongoObject.GetPasswordResetCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = passwordResetCollection.InsertOne(ctx, reset)
+	return mongoError(err)
+}
+
+func (store *MongoStore) TakePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	passwordResetCollection, err := store.mongoObject.GetPasswordResetCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{
+		{Key: "tokenHash", Value: tokenHash},
+		{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: time.Now()}}},
+	}
+
+	var foundReset models.PasswordReset
+
+	// Deleting it as it is found lets only one of concurrent requests have the reset.
+	err = passwordResetCollection.FindOneAndDelete(ctx, filter).Decode(&foundReset)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundReset, nil
+}
+
+func (store *MongoStore) DeletePasswordResets(ctx context.Context, userId string) error {
+	passwordResetCollection, err := store.mongoObject.GetPasswordResetCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = passwordResetCollection.DeleteMany(ctx, bson.D{{Key: "userId", Value: userId}})
+	return err
+}
+
+// Runs the update of the user, reporting whether it changed the user.
+func (store *MongoStore) updateUserIf(ctx context.Context, filter bson.D, updateObj primitive.D) (bool, error) {
+	result, err := store.updateUser(ctx, filter, updateObj)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+func (store *MongoStore) SetTOTPSecret(ctx context.Context, userId string, secret string) (bool, error) {
+	// Users from before TOTP have no totpEnabled field.
+	filter := bson.D{
+		{Key: "userId", Value: userId},
+		{Key: "totpEnabled", Value: bson.D{{Key: "$ne", Value: true}}},
+	}
+
+	return store.updateUserIf(ctx, filter, primitive.D{{Key: "$set", Value: primitive.D{{Key: "totpSecret", Value: secret}}}})
+}
+
+func (store *MongoStore) EnableTOTP(ctx context.Context, userId string, step int64) (bool, error) {
+	filter := bson.D{
+		{Key: "userId", Value: userId},
+		{Key: "totpEnabled", Value: bson.D{{Key: "$ne", Value: true}}},
+		{Key: "totpSecret", Value: bson.D{{Key: "$exists", Value: true}}},
+	}
+
+	updateObj := primitive.D{
+		{Key: "$set", Value: primitive.D{{Key: "totpEnabled", Value: true}, {Key: "totpLastStep", Value: step}}},
+	}
+
+	return store.updateUserIf(ctx, filter, updateObj)
+}
+
+func (store *MongoStore) DisableTOTP(ctx context.Context, userId string) error {
+	updateObj := primitive.D{
+		{Key: "$set", Value: primitive.D{{Key: "totpEnabled", Value: false}, {Key: "totpLastStep", Value: 0}}},
+		{Key: "$unset", Value: primitive.D{{Key: "totpSecret", Value: ""}}},
+	}
+
+	result, err := store.updateUser(ctx, bson.D{{Key: "userId", Value: userId}}, updateObj)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return store.SetRecoveryCodes(ctx, userId, nil)
+}
+
+func (store *MongoStore) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+	filter := bson.D{
+		{Key: "userId", Value: userId},
+		{Key: "totpLastStep", Value: bson.D{{Key: "$lt", Value: step}}},
+	}
+
+	return store.updateUserIf(ctx, filter, primitive.D{{Key: "$set", Value: primitive.D{{Key: "totpLastStep", Value: step}}}})
+}
+
+func (store *MongoStore) SetRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error {
+	recoveryCodeCollection, err := store.mongoObject.GetRecoveryCodeCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = recoveryCodeCollection.DeleteMany(ctx, bson.D{{Key: "userId", Value: userId}})
+	if err != nil {
+		return err
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := []interface{}{}
+	for _, codeHash := range codeHashes {
+		codes = append(codes, models.RecoveryCode{ID: primitive.NewObjectID(), User_Id: userId, Code_Hash: codeHash})
+	}
+
+	_, err = recoveryCodeCollection.InsertMany(ctx, codes)
+	return mongoError(err)
+}
+
+func (store *MongoStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+	recoveryCodeCollection, err := store.mongoObject.GetRecoveryCodeCollection()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := recoveryCodeCollection.DeleteOne(ctx, bson.D{{Key: "userId", Value: userId}, {Key: "codeHash", Value: codeHash}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+func (store *MongoStore) CountRecoveryCodes(ctx context.Context, userId string) (int, error) {
+	recoveryCodeCollection, err := store.mongoObject.GetRecoveryCodeCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := recoveryCodeCollection.CountDocuments(ctx, bson.D{{Key: "userId", Value: userId}})
+	return int(count), err
+}
+
+// Matches the access tokens which have not expired, those without an expiry never do.
+func liveAccessTokenCondition() bson.E {
+	return bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: time.Now()}}}},
+	}}
+}
+
+func (store *MongoStore) CreateAccessToken(ctx context.Context, accessToken *models.AccessToken) error {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = accessTokenCollection.InsertOne(ctx, accessToken)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	var foundAccessToken models.AccessToken
+
+	err = accessTokenCollection.FindOne(ctx, bson.D{{Key: "tokenHash", Value: tokenHash}, liveAccessTokenCondition()}).Decode(&foundAccessToken)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &foundAccessToken, nil
+}
+
+func (store *MongoStore) ListAccessTokens(ctx context.Context, userId string) ([]models.AccessToken, error) {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	cursor, err := accessTokenCollection.Find(ctx, bson.D{{Key: "userId", Value: userId}, liveAccessTokenCondition()}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundAccessTokens := []models.AccessToken{}
+
+	err = cursor.All(ctx, &foundAccessTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundAccessTokens, nil
+}
+
+func (store *MongoStore) SetAccessTokenLastUsed(ctx context.Context, tokenId string, lastUsedAt time.Time) error {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	tokenIdPrimitive, err := primitive.ObjectIDFromHex(tokenId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	result, err := accessTokenCollection.UpdateByID(ctx, tokenIdPrimitive, bson.D{{Key: "$set", Value: bson.D{{Key: "lastUsedAt", Value: lastUsedAt}}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) DeleteAccessToken(ctx context.Context, userId string, tokenId string) error {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	tokenIdPrimitive, err := primitive.ObjectIDFromHex(tokenId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	result, err := accessTokenCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: tokenIdPrimitive}, {Key: "userId", Value: userId}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) DeleteAccessTokens(ctx context.Context, userId string) error {
+	accessTokenCollection, err := store.mongoObject.GetAccessTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = accessTokenCollection.DeleteMany(ctx, bson.D{{Key: "userId", Value: userId}})
+	return err
+}
+
+func (store *MongoStore) RecordLoginFailure(ctx context.Context, key string, failedAt time.Time, forgetBefore time.Time, expiresAt time.Time) (int, error) {
+	loginFailureCollection, err := store.mongoObject.GetLoginFailureCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	// Counting in an update pipeline keeps concurrent failures from being lost. A missing last failure
+	// sorts before any time, so the count of a new key starts from one.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "failures", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$gte", Value: bson.A{"$lastFailureAt", forgetBefore}}},
+				bson.D{{Key: "$add", Value: bson.A{"$failures", 1}}},
+				1,
+			}}}},
+			{Key: "lastFailureAt", Value: failedAt},
+			{Key: "expiresAt", Value: expiresAt},
+		}}},
+	}
+
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var failures models.LoginFailures
+
+	err = loginFailureCollection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: key}}, update, updateOptions).Decode(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	return failures.Failures, nil
+}
+
+func (store *MongoStore) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	loginFailureCollection, err := store.mongoObject.GetLoginFailureCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: time.Now()}}},
+	}
+
+	var failures models.LoginFailures
+
+	err = loginFailureCollection.FindOne(ctx, filter).Decode(&failures)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &failures, nil
+}
+
+func (store *MongoStore) ClearLoginFailures(ctx context.Context, key string) error {
+	loginFailureCollection, err := store.mongoObject.GetLoginFailureCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = loginFailureCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+	return err
+}
+
+func (store *MongoStore) CreateLockout(ctx context.Context, lockout *models.Lockout) error {
+	lockoutCollection, err := store.mongoObject.GetLockoutCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = lockoutCollection.InsertOne(ctx, lockout)
+	return mongoError(err)
+}
+
+func (store *MongoStore) ListLockouts(ctx context.Context, limit int) ([]models.Lockout, error) {
+	lockoutCollection, err := store.mongoObject.GetLockoutCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "lockedAt", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := lockoutCollection.Find(ctx, bson.D{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundLockouts := []models.Lockout{}
+
+	err = cursor.All(ctx, &foundLockouts)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundLockouts, nil
+}
+
+func (store *MongoStore) RevokeToken(ctx context.Context, revokedToken *models.RevokedToken) error {
+	revokedTokenCollection, err := store.mongoObject.GetRevokedTokenCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = revokedTokenCollection.InsertOne(ctx, revokedToken)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (store *MongoStore) ConsumeToken(ctx context.Context, revokedToken *models.RevokedToken) (bool, error) {
+	revokedTokenCollection, err := store.mongoObject.GetRevokedTokenCollection()
+	if err != nil {
+		return false, err
+	}
+
+	// The unique index on tokenId lets only one of concurrent inserts of the same token succeed.
+	_, err = revokedTokenCollection.InsertOne(ctx, revokedToken)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (store *MongoStore) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	revokedTokenCollection, err := store.mongoObject.GetRevokedTokenCollection()
+	if err != nil {
+		return false, err
+	}
+
+	// Expired entries may linger till the TTL monitor removes them, but they deny nothing valid.
+	err = revokedTokenCollection.FindOne(ctx, bson.D{{Key: "tokenId", Value: tokenId}}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (store *MongoStore) findNote(ctx context.Context, filter bson.D) (*models.NoteData, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	var foundNote models.NoteData
+
+	err = noteCollection.FindOne(ctx, filter).Decode(&foundNote)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundNote, nil
+}
+
+func (store *MongoStore) findNotes(ctx context.Context, filter bson.D, findOptions ...*options.FindOptions) ([]models.NoteData, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a cursor over the matching documents.
+	cursor, err := noteCollection.Find(ctx, filter, findOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundNotes := []models.NoteData{}
+
+	err = cursor.All(ctx, &foundNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundNotes, nil
+}
+
+// Applies the update to the note while it is at the given version, increasing the version.
+func (store *MongoStore) updateNote(ctx context.Context, noteId string, version int64, updateMiniObj primitive.D) (*models.NoteData, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	noteIdPrimitive, err := primitive.ObjectIDFromHex(noteId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: noteIdPrimitive}, noteVersionFilter(version)}
+
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: updateMiniObj,
+		},
+		{
+			Key: "$inc", Value: primitive.D{{Key: "version", Value: 1}},
+		},
+	}
+
+	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedNote models.NoteData
+
+	err = noteCollection.FindOneAndUpdate(ctx, filter, updateObj, options).Decode(&updatedNote)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.missingOrConflict(ctx, noteIdPrimitive)
+	}
+	if err != nil {
+		// The unique index on the header turns a clash with another note into a duplicate key error.
+		return nil, mongoError(err)
+	}
+
+	return &updatedNote, nil
+}
+
+// Tells apart a note which no longer exists from one which is at another version.
+func (store *MongoStore) missingOrConflict(ctx context.Context, noteIdPrimitive primitive.ObjectID) error {
+	_, err := store.findNote(ctx, bson.D{{Key: "_id", Value: noteIdPrimitive}})
+	if err == nil {
+		return ErrVersionConflict
+	}
+
+	return err
+}
+
+func (store *MongoStore) CreateNote(ctx context.Context, note *models.NoteData) error {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return err
+	}
+
+	// Find the note if already present with the same unique header.
+	_, err = store.GetNoteByUniqueHeader(ctx, *note.Unique_Header)
+	if err == nil {
+		return ErrDuplicate
+	}
+	if err != ErrNotFound {
+		return err
+	}
+
+	// A note inserted with the header since is caught by the unique index.
+	_, err = noteCollection.InsertOne(ctx, note)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetNoteById(ctx context.Context, noteId string) (*models.NoteData, error) {
+	noteIdPrimitive, err := primitive.ObjectIDFromHex(noteId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	return store.findNote(ctx, bson.D{{Key: "_id", Value: noteIdPrimitive}, liveNoteCondition})
+}
+
+func (store *MongoStore) GetNoteByUniqueHeader(ctx context.Context, uniqueHeader string) (*models.NoteData, error) {
+	return store.findNote(ctx, bson.D{{Key: "uniqueHeader", Value: uniqueHeader}})
+}
+
+func (store *MongoStore) ListAllNotes(ctx context.Context, afterId string, limit int) ([]models.NoteData, error) {
+	filter := bson.D{liveNoteCondition}
+
+	if afterId != "" {
+		afterIdPrimitive, err := primitive.ObjectIDFromHex(afterId)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: afterIdPrimitive}}})
+	}
+
+	return store.findNotes(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+}
+
+func (store *MongoStore) ListNotes(ctx context.Context, query *NoteListQuery) ([]models.NoteData, error) {
+	scopeFilter := noteScopeFilter(query.User_Id, query.Scope, &query.Filter)
+
+	conditions := withNoteFilter(bson.A{scopeFilter, bson.D{liveNoteCondition}}, &query.Filter)
+
+	direction, comparison := 1, "$gt"
+	if query.Descending {
+		direction, comparison = -1, "$lt"
+	}
+
+	// Continue after the cursor: further along the sort field, or at the same value with a further id.
+	if query.After != nil {
+		afterId, err := primitive.ObjectIDFromHex(query.After.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		var afterValue interface{} = query.After.Time
+		if query.Sort_By == SortByHeader {
+			afterValue = query.After.Header
+		}
+
+		conditions = append(conditions, bson.D{
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: query.Sort_By, Value: bson.D{{Key: comparison, Value: afterValue}}}},
+				bson.D{{Key: query.Sort_By, Value: afterValue}, {Key: "_id", Value: bson.D{{Key: comparison, Value: afterId}}}},
+			}},
+		})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: query.Sort_By, Value: direction}, {Key: "_id", Value: direction}})
+
+	if query.Limit > 0 {
+		findOptions.SetLimit(int64(query.Limit))
+	}
+
+	// Leave out the fields the caller does not need, but keep what the cursor and the entity tag are made of.
+	if query.Fields != nil {
+		projection := bson.D{{Key: "version", Value: 1}, {Key: query.Sort_By, Value: 1}}
+		for _, field := range query.Fields {
+			if field != "version" && field != query.Sort_By {
+				projection = append(projection, bson.E{Key: field, Value: 1})
+			}
+		}
+		findOptions.SetProjection(projection)
+	}
+
+	return store.findNotes(ctx, bson.D{{Key: "$and", Value: conditions}}, findOptions)
+}
+
+func (store *MongoStore) SearchNotes(ctx context.Context, userId string, query *search.Query, noteFilter *NoteFilter) ([]models.NoteData, error) {
+	filter := bson.D{{Key: "$and", Value: withNoteFilter(bson.A{noteScopeFilter(userId, AllNotesScope, noteFilter), bson.D{liveNoteCondition}}, noteFilter)}}
+
+	// Only the notes containing every word the query requires are looked up in the text index,
+	// and then matched against the whole query.
+	if textSearch := mongoTextSearch(query.RequiredPhrases()); textSearch != "" {
+		filter = bson.D{
+			{Key: "$text", Value: bson.D{{Key: "$search", Value: textSearch}}},
+			{Key: "$and", Value: bson.A{filter}},
+		}
+	}
+
+	candidateNotes, err := store.findNotes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	foundNotes := []models.NoteData{}
+	for _, note := range candidateNotes {
+		if query.Matches(stringValue(note.Header), stringValue(note.Data)) {
+			foundNotes = append(foundNotes, note)
+		}
+	}
+
+	return foundNotes, nil
+}
+
+// Text search string requiring the whole words of the phrases, each quoted as a phrase of its own,
+// as the text index has no prefix search and matches phrases on the raw text.
+func mongoTextSearch(phrases []search.Phrase) string {
+	var quotedWords []string
+
+	for _, phrase := range phrases {
+		words := phrase.Words
+		if phrase.Prefix {
+			words = words[:len(words)-1]
+		}
+
+		for _, word := range words {
+			quotedWords = append(quotedWords, `"`+word+`"`)
+		}
+	}
+
+	return strings.Join(quotedWords, " ")
+}
+
+func (store *MongoStore) UpdateNote(ctx context.Context, noteId string, version int64, update *NoteUpdate) (*models.NoteData, error) {
+	// Make the update object.
+	var updateMiniObj primitive.D
+
+	if update.Header != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "header", Value: update.Header})
+	}
+
+	if update.Unique_Header != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "uniqueHeader", Value: update.Unique_Header})
+	}
+
+	if update.Data != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "notesData", Value: update.Data})
+	}
+
+	if update.Sharable != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "sharable", Value: update.Sharable})
+	}
+
+	if update.Notebook_Id != nil {
+		var notebookId *string
+		if *update.Notebook_Id != "" {
+			notebookId = update.Notebook_Id
+		}
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "notebookId", Value: notebookId})
+	}
+
+	if update.Tags != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "tags", Value: update.Tags})
+	}
+
+	updateMiniObj = append(updateMiniObj, bson.E{Key: "updatedAt", Value: update.Updated_At})
+
+	return store.updateNote(ctx, noteId, version, updateMiniObj)
+}
+
+func (store *MongoStore) ListTags(ctx context.Context, userId string) ([]models.TagCount, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: append(ownedNotesFilter(userId), liveNoteCondition)}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$tags"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := noteCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tagCounts := []models.TagCount{}
+
+	err = cursor.All(ctx, &tagCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return tagCounts, nil
+}
+
+// Times a note changed by someone else meanwhile is read again before replacing its tags gives up.
+const replaceTagsAttempts = 3
+
+func (store *MongoStore) ReplaceTags(ctx context.Context, userId string, oldTags []string, newTag string) ([]models.NoteData, error) {
+	filter := append(ownedNotesFilter(userId),
+		bson.E{Key: "tags", Value: bson.D{{Key: "$in", Value: oldTags}}},
+		liveNoteCondition,
+	)
+
+	foundNotes, err := store.findNotes(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	changedNotes := []models.NoteData{}
+	updatedAt := time.Now()
+
+	// The sorted tags are worked out here, every note is changed only while it is at the version they were worked out from.
+	for _, note := range foundNotes {
+		noteId := note.ID.Hex()
+
+		for attempt := 1; ; attempt++ {
+			if !hasAnyTag(note.Tags, oldTags) {
+				break
+			}
+
+			updateMiniObj := primitive.D{
+				{Key: "tags", Value: replaceTags(note.Tags, oldTags, newTag)},
+				{Key: "updatedAt", Value: updatedAt},
+			}
+
+			updatedNote, err := store.updateNote(ctx, noteId, note.Version, updateMiniObj)
+			if err == nil {
+				changedNotes = append(changedNotes, *updatedNote)
+				break
+			}
+			if err == ErrNotFound {
+				break
+			}
+			if err != ErrVersionConflict || attempt == replaceTagsAttempts {
+				return changedNotes, err
+			}
+
+			reloadedNote, err := store.GetNoteById(ctx, noteId)
+			if err == ErrNotFound {
+				break
+			}
+			if err != nil {
+				return changedNotes, err
+			}
+			note = *reloadedNote
+		}
+	}
+
+	return changedNotes, nil
+}
+
+func (store *MongoStore) SetNoteAccess(ctx context.Context, noteId string, version int64, accessList []models.NoteAccess) (*models.NoteData, error) {
+	updateMiniObj := primitive.D{
+		{Key: "access", Value: accessList},
+		{Key: "updatedAt", Value: time.Now()},
+	}
+
+	return store.updateNote(ctx, noteId, version, updateMiniObj)
+}
+
+func (store *MongoStore) DeleteNote(ctx context.Context, noteId string, version int64) error {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return err
+	}
+
+	noteIdPrimitive, err := primitive.ObjectIDFromHex(noteId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: noteIdPrimitive}, noteVersionFilter(version)}
+
+	deleteResult, err := noteCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return store.missingOrConflict(ctx, noteIdPrimitive)
+	}
+
+	return nil
+}
+
+func (store *MongoStore) TrashNote(ctx context.Context, noteId string, version int64, deletedAt time.Time) (*models.NoteData, error) {
+	updateMiniObj := primitive.D{
+		{Key: "uniqueHeader", Value: TrashedUniqueHeader(noteId)},
+		{Key: "deletedAt", Value: deletedAt},
+	}
+
+	return store.updateNote(ctx, noteId, version, updateMiniObj)
+}
+
+func (store *MongoStore) RestoreNote(ctx context.Context, noteId string, version int64, uniqueHeader string, notebookId *string) (*models.NoteData, error) {
+	// Find the note if already present with the same unique header.
+	_, err := store.GetNoteByUniqueHeader(ctx, uniqueHeader)
+	if err == nil {
+		return nil, ErrDuplicate
+	}
+	if err != ErrNotFound {
+		return nil, err
+	}
+
+	updateMiniObj := primitive.D{
+		{Key: "uniqueHeader", Value: uniqueHeader},
+		{Key: "notebookId", Value: notebookId},
+		{Key: "deletedAt", Value: nil},
+	}
+
+	return store.updateNote(ctx, noteId, version, updateMiniObj)
+}
+
+func (store *MongoStore) GetTrashedNote(ctx context.Context, noteId string) (*models.NoteData, error) {
+	noteIdPrimitive, err := primitive.ObjectIDFromHex(noteId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	return store.findNote(ctx, bson.D{{Key: "_id", Value: noteIdPrimitive}, {Key: "deletedAt", Value: bson.D{{Key: "$ne", Value: nil}}}})
+}
+
+func (store *MongoStore) ListTrash(ctx context.Context, userId string) ([]models.NoteData, error) {
+	filter := append(ownedNotesFilter(userId), bson.E{Key: "deletedAt", Value: bson.D{{Key: "$ne", Value: nil}}})
+
+	return store.findNotes(ctx, filter, options.Find().SetSort(bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}}))
+}
+
+func (store *MongoStore) ListWorkspaceTrash(ctx context.Context, workspaceId string) ([]models.NoteData, error) {
+	filter := bson.D{{Key: "workspaceId", Value: workspaceId}, {Key: "deletedAt", Value: bson.D{{Key: "$ne", Value: nil}}}}
+
+	return store.findNotes(ctx, filter, options.Find().SetSort(bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}}))
+}
+
+func (store *MongoStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	// A missing or null deletedAt never compares less than a time, so only notes in the trash match.
+	purgeFilter := bson.D{{Key: "deletedAt", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}}
+
+	purgedNotes, err := store.findNotes(ctx, purgeFilter, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+
+	// One note at a time, so that the revisions of a note restored meanwhile stay.
+	for _, note := range purgedNotes {
+		deleteResult, err := noteCollection.DeleteOne(ctx, append(bson.D{{Key: "_id", Value: note.ID}}, purgeFilter...))
+		if err != nil {
+			return purged, err
+		}
+		if deleteResult.DeletedCount == 0 {
+			continue
+		}
+
+		_, err = noteRevisionCollection.DeleteMany(ctx, bson.D{{Key: "noteId", Value: note.ID.Hex()}})
+		if err != nil {
+			return purged, err
+		}
+
+		_, err = shareLinkCollection.DeleteMany(ctx, bson.D{{Key: "noteId", Value: note.ID.Hex()}})
+		if err != nil {
+			return purged, err
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (store *MongoStore) findNotebooks(ctx context.Context, filter bson.D) ([]models.Notebook, error) {
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := notebookCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundNotebooks := []models.Notebook{}
+
+	err = cursor.All(ctx, &foundNotebooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundNotebooks, nil
+}
+
+func (store *MongoStore) CreateNotebook(ctx context.Context, notebook *models.Notebook) error {
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = notebookCollection.InsertOne(ctx, notebook)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetNotebookById(ctx context.Context, notebookId string) (*models.Notebook, error) {
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	notebookIdPrimitive, err := primitive.ObjectIDFromHex(notebookId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var foundNotebook models.Notebook
+
+	err = notebookCollection.FindOne(ctx, bson.D{{Key: "_id", Value: notebookIdPrimitive}}).Decode(&foundNotebook)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundNotebook, nil
+}
+
+func (store *MongoStore) ListNotebooks(ctx context.Context, userId string) ([]models.Notebook, error) {
+	foundNotebooks, err := store.findNotebooks(ctx, bson.D{{Key: "userId", Value: userId}})
+	if err != nil {
+		return nil, err
+	}
+
+	sortNotebooks(foundNotebooks)
+	return foundNotebooks, nil
+}
+
+func (store *MongoStore) UpdateNotebook(ctx context.Context, notebookId string, update *NotebookUpdate) (*models.Notebook, error) {
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	foundNotebook, err := store.GetNotebookById(ctx, notebookId)
+	if err != nil {
+		return nil, err
+	}
+
+	updateMiniObj := primitive.D{{Key: "updatedAt", Value: update.Updated_At}}
+
+	if update.Name != nil {
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "name", Value: *update.Name})
+	}
+
+	var ancestors []string
+
+	if update.Parent_Id != nil {
+		var parentId *string
+		ancestors = []string{}
+
+		if *update.Parent_Id != "" {
+			parent, err := store.GetNotebookById(ctx, *update.Parent_Id)
+			if err != nil {
+				return nil, err
+			}
+			parentId, ancestors = update.Parent_Id, append(parent.Ancestors, parent.ID.Hex())
+		}
+
+		updateMiniObj = append(updateMiniObj, bson.E{Key: "parentId", Value: parentId}, bson.E{Key: "ancestors", Value: ancestors})
+	}
+
+	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedNotebook models.Notebook
+
+	err = notebookCollection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: foundNotebook.ID}}, bson.D{{Key: "$set", Value: updateMiniObj}}, options).Decode(&updatedNotebook)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	// The notebooks below it move along.
+	if update.Parent_Id != nil {
+		descendants, err := store.findNotebooks(ctx, bson.D{{Key: "ancestors", Value: notebookId}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, descendant := range descendants {
+			_, err = notebookCollection.UpdateOne(ctx, bson.D{{Key: "_id", Value: descendant.ID}},
+				bson.D{{Key: "$set", Value: bson.D{{Key: "ancestors", Value: movedAncestors(descendant.Ancestors, notebookId, ancestors)}}}})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &updatedNotebook, nil
+}
+
+func (store *MongoStore) DeleteNotebook(ctx context.Context, notebookId string, cascade bool) ([]models.NoteData, error) {
+	notebookCollection, err := store.mongoObject.GetNotebookCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	foundNotebook, err := store.GetNotebookById(ctx, notebookId)
+	if err != nil {
+		return nil, err
+	}
+
+	if cascade {
+		descendants, err := store.findNotebooks(ctx, bson.D{{Key: "ancestors", Value: notebookId}})
+		if err != nil {
+			return nil, err
+		}
+
+		subtreeIds := bson.A{foundNotebook.ID}
+		notebookIds := []string{notebookId}
+		for _, descendant := range descendants {
+			subtreeIds = append(subtreeIds, descendant.ID)
+			notebookIds = append(notebookIds, descendant.ID.Hex())
+		}
+
+		notes, err := store.findNotes(ctx, bson.D{{Key: "notebookId", Value: bson.D{{Key: "$in", Value: notebookIds}}}})
+		if err != nil {
+			return nil, err
+		}
+
+		// Notes first, so that a failure never leaves notes in a notebook which no longer exists.
+		// They go to the trash outside of notebooks, where they come back to when restored.
+		deletedAt := time.Now()
+		trashedNotes := []models.NoteData{}
+
+		for _, note := range notes {
+			updateMiniObj := primitive.D{{Key: "notebookId", Value: nil}}
+			if note.Deleted_At == nil {
+				updateMiniObj = append(updateMiniObj,
+					primitive.E{Key: "uniqueHeader", Value: TrashedUniqueHeader(note.ID.Hex())},
+					primitive.E{Key: "deletedAt", Value: deletedAt})
+			}
+
+			updateObj := primitive.D{
+				{Key: "$set", Value: updateMiniObj},
+				{Key: "$inc", Value: primitive.D{{Key: "version", Value: 1}}},
+			}
+
+			var trashedNote models.NoteData
+			err = noteCollection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: note.ID}}, updateObj, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&trashedNote)
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if note.Deleted_At == nil {
+				trashedNotes = append(trashedNotes, trashedNote)
+			}
+		}
+
+		_, err = notebookCollection.DeleteMany(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: subtreeIds}}}})
+		if err != nil {
+			return nil, err
+		}
+
+		return trashedNotes, nil
+	}
+
+	// Check that nothing moving up clashes with what is already in the parent, before moving anything.
+	children, err := store.findNotebooks(ctx, bson.D{{Key: "parentId", Value: notebookId}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		count, err := notebookCollection.CountDocuments(ctx, bson.D{
+			{Key: "userId", Value: child.User_Id},
+			{Key: "parentId", Value: foundNotebook.Parent_Id},
+			{Key: "name", Value: child.Name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrDuplicate
+		}
+	}
+
+	notes, err := store.findNotes(ctx, bson.D{{Key: "notebookId", Value: notebookId}}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueHeaders := make([]string, len(notes))
+	for i, note := range notes {
+		// Notes in the trash move along, keeping their trashed unique header.
+		if note.Deleted_At != nil {
+			uniqueHeaders[i] = stringValue(note.Unique_Header)
+			continue
+		}
+
+		uniqueHeaders[i] = UniqueHeader(stringValue(note.User_Id), foundNotebook.Parent_Id, stringValue(note.Header))
+
+		_, err = store.GetNoteByUniqueHeader(ctx, uniqueHeaders[i])
+		if err == nil {
+			return nil, ErrDuplicate
+		}
+		if err != ErrNotFound {
+			return nil, err
+		}
+	}
+
+	_, err = notebookCollection.UpdateMany(ctx, bson.D{{Key: "parentId", Value: notebookId}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "parentId", Value: foundNotebook.Parent_Id}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = notebookCollection.UpdateMany(ctx, bson.D{{Key: "ancestors", Value: notebookId}},
+		bson.D{{Key: "$pull", Value: bson.D{{Key: "ancestors", Value: notebookId}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	movedNotes := []models.NoteData{}
+	updatedAt := time.Now()
+
+	for i, note := range notes {
+		updateMiniObj := primitive.D{
+			{Key: "notebookId", Value: foundNotebook.Parent_Id},
+			{Key: "uniqueHeader", Value: uniqueHeaders[i]},
+			{Key: "updatedAt", Value: updatedAt},
+		}
+
+		movedNote, err := store.updateNote(ctx, note.ID.Hex(), note.Version, updateMiniObj)
+		if err != nil {
+			return movedNotes, err
+		}
+
+		movedNotes = append(movedNotes, *movedNote)
+	}
+
+	_, err = notebookCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: foundNotebook.ID}})
+	if err != nil {
+		return movedNotes, err
+	}
+
+	return movedNotes, nil
+}
+
+func (store *MongoStore) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = workspaceCollection.InsertOne(ctx, workspace)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetWorkspaceById(ctx context.Context, workspaceId string) (*models.Workspace, error) {
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceIdPrimitive, err := primitive.ObjectIDFromHex(workspaceId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var foundWorkspace models.Workspace
+
+	err = workspaceCollection.FindOne(ctx, bson.D{{Key: "_id", Value: workspaceIdPrimitive}}).Decode(&foundWorkspace)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundWorkspace, nil
+}
+
+func (store *MongoStore) ListWorkspaces(ctx context.Context, userId string) ([]models.Workspace, error) {
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := workspaceCollection.Find(ctx, bson.D{{Key: "members.userId", Value: userId}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workspaces := []models.Workspace{}
+
+	err = cursor.All(ctx, &workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// Applies the update to the workspace while it is at the given version, increasing the version.
+func (store *MongoStore) updateWorkspace(ctx context.Context, workspaceId string, version int64, updateMiniObj primitive.D) (*models.Workspace, error) {
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceIdPrimitive, err := primitive.ObjectIDFromHex(workspaceId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: workspaceIdPrimitive}, {Key: "version", Value: version}}
+
+	updateObj := primitive.D{
+		{
+			Key: "$set", Value: updateMiniObj,
+		},
+		{
+			Key: "$inc", Value: primitive.D{{Key: "version", Value: 1}},
+		},
+	}
+
+	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedWorkspace models.Workspace
+
+	err = workspaceCollection.FindOneAndUpdate(ctx, filter, updateObj, options).Decode(&updatedWorkspace)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.workspaceMissingOrConflict(ctx, workspaceId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedWorkspace, nil
+}
+
+// Tells apart a workspace which no longer exists from one which is at another version.
+func (store *MongoStore) workspaceMissingOrConflict(ctx context.Context, workspaceId string) error {
+	_, err := store.GetWorkspaceById(ctx, workspaceId)
+	if err == nil {
+		return ErrVersionConflict
+	}
+
+	return err
+}
+
+func (store *MongoStore) RenameWorkspace(ctx context.Context, workspaceId string, version int64, name string, updatedAt time.Time) (*models.Workspace, error) {
+	return store.updateWorkspace(ctx, workspaceId, version, primitive.D{{Key: "name", Value: name}, {Key: "updatedAt", Value: updatedAt}})
+}
+
+func (store *MongoStore) SetWorkspaceMembers(ctx context.Context, workspaceId string, version int64, members []models.WorkspaceMember) (*models.Workspace, error) {
+	return store.updateWorkspace(ctx, workspaceId, version, primitive.D{{Key: "members", Value: members}, {Key: "updatedAt", Value: time.Now()}})
+}
+
+func (store *MongoStore) DeleteWorkspace(ctx context.Context, workspaceId string, version int64) error {
+	workspaceCollection, err := store.mongoObject.GetWorkspaceCollection()
+	if err != nil {
+		return err
+	}
+
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return err
+	}
+
+	workspaceIdPrimitive, err := primitive.ObjectIDFromHex(workspaceId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	empty, err := store.isWorkspaceEmpty(ctx, workspaceId)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return ErrNotEmpty
+	}
+
+	var deletedWorkspace models.Workspace
+
+	err = workspaceCollection.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: workspaceIdPrimitive}, {Key: "version", Value: version}}).Decode(&deletedWorkspace)
+	if err == mongo.ErrNoDocuments {
+		return store.workspaceMissingOrConflict(ctx, workspaceId)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Without a transaction a note may have been added between the check and the delete,
+	// in which case the workspace is put back as it was.
+	empty, err = store.isWorkspaceEmpty(ctx, workspaceId)
+	if err == nil && !empty {
+		_, err = workspaceCollection.InsertOne(ctx, deletedWorkspace)
+		if err == nil {
+			return ErrNotEmpty
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = invitationCollection.DeleteMany(ctx, bson.D{{Key: "workspaceId", Value: workspaceId}})
+	return err
+}
+
+// Whether the workspace holds no notes, neither live ones nor ones in the trash.
+func (store *MongoStore) isWorkspaceEmpty(ctx context.Context, workspaceId string) (bool, error) {
+	noteCollection, err := store.mongoObject.GetNoteCollection()
+	if err != nil {
+		return false, err
+	}
+
+	count, err := noteCollection.CountDocuments(ctx, bson.D{{Key: "workspaceId", Value: workspaceId}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func (store *MongoStore) CreateWorkspaceInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error {
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = invitationCollection.DeleteMany(ctx, bson.D{{Key: "workspaceId", Value: invitation.Workspace_Id}, {Key: "email", Value: invitation.Email}})
+	if err != nil {
+		return err
+	}
+
+	_, err = invitationCollection.InsertOne(ctx, invitation)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetWorkspaceInvitationByHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error) {
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	// The TTL index drops expired invitations only now and then, so they are left out here too.
+	filter := bson.D{{Key: "tokenHash", Value: tokenHash}, {Key: "expiresAt", Value: bson.D{{Key: "$gt", Value: time.Now()}}}}
+
+	var foundInvitation models.WorkspaceInvitation
+
+	err = invitationCollection.FindOne(ctx, filter).Decode(&foundInvitation)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundInvitation, nil
+}
+
+func (store *MongoStore) ListWorkspaceInvitations(ctx context.Context, workspaceId string) ([]models.WorkspaceInvitation, error) {
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "workspaceId", Value: workspaceId}, {Key: "expiresAt", Value: bson.D{{Key: "$gt", Value: time.Now()}}}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := invitationCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.WorkspaceInvitation{}
+
+	err = cursor.All(ctx, &invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (store *MongoStore) DeleteWorkspaceInvitation(ctx context.Context, workspaceId string, invitationId string) error {
+	invitationCollection, err := store.mongoObject.GetInvitationCollection()
+	if err != nil {
+		return err
+	}
+
+	invitationIdPrimitive, err := primitive.ObjectIDFromHex(invitationId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	deleteResult, err := invitationCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: invitationIdPrimitive}, {Key: "workspaceId", Value: workspaceId}})
+	if err != nil {
+		return err
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// The TTL index drops expired links only now and then, so they are left out here too.
+func liveShareLinkCondition(now time.Time) bson.D {
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: now}}}},
+	}}}
+}
+
+func (store *MongoStore) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = shareLinkCollection.InsertOne(ctx, link)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetShareLinkByHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := append(bson.D{{Key: "tokenHash", Value: tokenHash}}, liveShareLinkCondition(time.Now())...)
+
+	var foundLink models.ShareLink
+
+	err = shareLinkCollection.FindOne(ctx, filter).Decode(&foundLink)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundLink, nil
+}
+
+func (store *MongoStore) ListShareLinks(ctx context.Context, noteId string) ([]models.ShareLink, error) {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := append(bson.D{{Key: "noteId", Value: noteId}}, liveShareLinkCondition(time.Now())...)
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	cursor, err := shareLinkCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	links := []models.ShareLink{}
+
+	err = cursor.All(ctx, &links)
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (store *MongoStore) RecordShareLinkView(ctx context.Context, linkId string, viewedAt time.Time) (*models.ShareLink, error) {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	linkIdPrimitive, err := primitive.ObjectIDFromHex(linkId)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	// Checking the views left and counting the view in one update keeps concurrent views within the limit.
+	filter := bson.D{
+		{Key: "_id", Value: linkIdPrimitive},
+		{Key: "$and", Value: bson.A{
+			liveShareLinkCondition(viewedAt),
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "maxViews", Value: bson.D{{Key: "$exists", Value: false}}}},
+				bson.D{{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{"$views", "$maxViews"}}}}},
+			}}},
+		}},
+	}
+	updateObj := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "views", Value: 1}}},
+		{Key: "$set", Value: bson.D{{Key: "lastViewedAt", Value: viewedAt}}},
+	}
+
+	var updatedLink models.ShareLink
+
+	err = shareLinkCollection.FindOneAndUpdate(ctx, filter, updateObj, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedLink)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &updatedLink, nil
+}
+
+func (store *MongoStore) DeleteShareLink(ctx context.Context, noteId string, linkId string) error {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return err
+	}
+
+	linkIdPrimitive, err := primitive.ObjectIDFromHex(linkId)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	deleteResult, err := shareLinkCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: linkIdPrimitive}, {Key: "noteId", Value: noteId}})
+	if err != nil {
+		return err
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoStore) DeleteNoteShareLinks(ctx context.Context, noteId string) error {
+	shareLinkCollection, err := store.mongoObject.GetShareLinkCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = shareLinkCollection.DeleteMany(ctx, bson.D{{Key: "noteId", Value: noteId}})
+	return err
+}
+
+func (store *MongoStore) CreateNoteRevision(ctx context.Context, revision *models.NoteRevision) error {
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = noteRevisionCollection.InsertOne(ctx, revision)
+	return mongoError(err)
+}
+
+func (store *MongoStore) GetLatestNoteRevisionNumber(ctx context.Context, noteId string) (int64, error) {
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.D{{Key: "noteId", Value: noteId}}
+	options := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}}).SetProjection(bson.D{{Key: "revision", Value: 1}})
+
+	var latestRevision models.NoteRevision
+
+	err = noteRevisionCollection.FindOne(ctx, filter, options).Decode(&latestRevision)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return latestRevision.Revision, nil
+}
+
+func (store *MongoStore) GetNoteRevision(ctx context.Context, noteId string, revision int64) (*models.NoteRevision, error) {
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "noteId", Value: noteId}, {Key: "revision", Value: revision}}
+
+	var foundRevision models.NoteRevision
+
+	err = noteRevisionCollection.FindOne(ctx, filter).Decode(&foundRevision)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &foundRevision, nil
+}
+
+func (store *MongoStore) ListNoteRevisions(ctx context.Context, noteId string) ([]models.NoteRevision, error) {
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "noteId", Value: noteId}}
+	options := options.Find().SetSort(bson.D{{Key: "revision", Value: 1}}).SetProjection(bson.D{{Key: "notesData", Value: 0}})
+
+	cursor, err := noteRevisionCollection.Find(ctx, filter, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	foundRevisions := []models.NoteRevision{}
+
+	err = cursor.All(ctx, &foundRevisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundRevisions, nil
+}
+
+func (store *MongoStore) DeleteNoteRevisions(ctx context.Context, noteId string) error {
+	noteRevisionCollection, err := store.mongoObject.GetNoteRevisionCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = noteRevisionCollection.DeleteMany(ctx, bson.D{{Key: "noteId", Value: noteId}})
+	return err
+}
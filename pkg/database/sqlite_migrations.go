@@ -0,0 +1,389 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+)
+
+// Schema changes of the sqlite store, applied in order and each only once.
+// Released migrations must never be edited, add a new one instead.
+var sqliteMigrations = []string{
+	// 1: users, notes with their access lists, revisions and revoked tokens.
+	`
+	CREATE TABLE users (
+		id             TEXT PRIMARY KEY,
+		user_id        TEXT NOT NULL UNIQUE,
+		first_name     TEXT,
+		last_name      TEXT,
+		password       TEXT,
+		email          TEXT UNIQUE,
+		created_at     TEXT NOT NULL,
+		updated_at     TEXT NOT NULL,
+		last_login     TEXT NOT NULL,
+		refresh_token  TEXT,
+		token_family   TEXT,
+		revoked_before TEXT NOT NULL
+	);
+
+	CREATE TABLE notes (
+		seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+		id            TEXT NOT NULL UNIQUE,
+		user_id       TEXT,
+		header        TEXT,
+		unique_header TEXT,
+		email         TEXT,
+		notes_data    TEXT,
+		sharable      INTEGER,
+		version       INTEGER NOT NULL DEFAULT 0,
+		created_at    TEXT NOT NULL,
+		updated_at    TEXT NOT NULL
+	);
+
+	CREATE INDEX notes_user_id ON notes (user_id);
+	CREATE INDEX notes_unique_header ON notes (unique_header);
+
+	CREATE TABLE note_access (
+		note_id    TEXT NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		user_id    TEXT NOT NULL,
+		email      TEXT,
+		role       TEXT NOT NULL,
+		granted_by TEXT NOT NULL,
+		granted_at TEXT NOT NULL,
+		PRIMARY KEY (note_id, user_id)
+	);
+
+	CREATE INDEX note_access_user_id ON note_access (user_id);
+
+	CREATE TABLE note_revisions (
+		id            TEXT PRIMARY KEY,
+		note_id       TEXT NOT NULL,
+		revision      INTEGER NOT NULL,
+		author_id     TEXT NOT NULL,
+		header        TEXT,
+		notes_data    TEXT,
+		content_hash  TEXT NOT NULL,
+		restored_from INTEGER,
+		created_at    TEXT NOT NULL,
+		UNIQUE (note_id, revision)
+	);
+
+	CREATE TABLE revoked_tokens (
+		token_id   TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		revoked_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+
+	CREATE INDEX revoked_tokens_expires_at ON revoked_tokens (expires_at);
+	`,
+
+	// 2: full text index over the notes data, kept in sync by triggers.
+	// The trigram tokenizer lets the index answer substring searches, like the mongo regex does.
+	`
+	CREATE VIRTUAL TABLE notes_fts USING fts5 (
+		notes_data,
+		content = 'notes',
+		content_rowid = 'seq',
+		tokenize = 'trigram'
+	);
+
+	INSERT INTO notes_fts (rowid, notes_data) SELECT seq, notes_data FROM notes;
+
+	CREATE TRIGGER notes_fts_insert AFTER INSERT ON notes BEGIN
+		INSERT INTO notes_fts (rowid, notes_data) VALUES (new.seq, new.notes_data);
+	END;
+
+	CREATE TRIGGER notes_fts_delete AFTER DELETE ON notes BEGIN
+		INSERT INTO notes_fts (notes_fts, rowid, notes_data) VALUES ('delete', old.seq, old.notes_data);
+	END;
+
+	CREATE TRIGGER notes_fts_update AFTER UPDATE OF notes_data ON notes BEGIN
+		INSERT INTO notes_fts (notes_fts, rowid, notes_data) VALUES ('delete', old.seq, old.notes_data);
+		INSERT INTO notes_fts (rowid, notes_data) VALUES (new.seq, new.notes_data);
+	END;
+	`,
+
+	// 3: indexes for the sort orders of the note listing.
+	`
+	CREATE INDEX notes_created_at ON notes (created_at, id);
+	CREATE INDEX notes_updated_at ON notes (updated_at, id);
+	CREATE INDEX notes_header ON notes (COALESCE(header, ''), id);
+	CREATE INDEX notes_sharable ON notes (sharable);
+	`,
+
+	// 4: word index over the header and the notes data for the search, replacing the trigram index.
+	// Words are split and case folded like the search package does, and also lose their diacritics,
+	// so that the index finds every note a query can match.
+	`
+	DROP TRIGGER notes_fts_insert;
+	DROP TRIGGER notes_fts_delete;
+	DROP TRIGGER notes_fts_update;
+	DROP TABLE notes_fts;
+
+	CREATE VIRTUAL TABLE notes_search USING fts5 (
+		header,
+		notes_data,
+		content = 'notes',
+		content_rowid = 'seq',
+		tokenize = 'unicode61 remove_diacritics 2',
+		prefix = '2 3'
+	);
+
+	INSERT INTO notes_search (rowid, header, notes_data) SELECT seq, header, notes_data FROM notes;
+
+	CREATE TRIGGER notes_search_insert AFTER INSERT ON notes BEGIN
+		INSERT INTO notes_search (rowid, header, notes_data) VALUES (new.seq, new.header, new.notes_data);
+	END;
+
+	CREATE TRIGGER notes_search_delete AFTER DELETE ON notes BEGIN
+		INSERT INTO notes_search (notes_search, rowid, header, notes_data) VALUES ('delete', old.seq, old.header, old.notes_data);
+	END;
+
+	CREATE TRIGGER notes_search_update AFTER UPDATE OF header, notes_data ON notes BEGIN
+		INSERT INTO notes_search (notes_search, rowid, header, notes_data) VALUES ('delete', old.seq, old.header, old.notes_data);
+		INSERT INTO notes_search (rowid, header, notes_data) VALUES (new.seq, new.header, new.notes_data);
+	END;
+	`,
+
+	// 5: tags of the notes.
+	`
+	CREATE TABLE note_tags (
+		note_id TEXT NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+		tag     TEXT NOT NULL,
+		PRIMARY KEY (note_id, tag)
+	);
+
+	CREATE INDEX note_tags_tag ON note_tags (tag);
+	`,
+
+	// 6: notebooks, holding notes and other notebooks.
+	// The ancestors are the ids of the notebooks above, from the top down, each followed by a slash.
+	`
+	CREATE TABLE notebooks (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		parent_id  TEXT REFERENCES notebooks (id),
+		ancestors  TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE UNIQUE INDEX notebooks_name ON notebooks (user_id, COALESCE(parent_id, ''), name);
+	CREATE INDEX notebooks_parent_id ON notebooks (parent_id);
+
+	ALTER TABLE notes ADD COLUMN notebook_id TEXT REFERENCES notebooks (id);
+
+	CREATE INDEX notes_notebook_id ON notes (notebook_id);
+	`,
+
+	// 7: the trash, notes in it have the time they were deleted at.
+	`
+	ALTER TABLE notes ADD COLUMN deleted_at TEXT;
+
+	CREATE INDEX notes_deleted_at ON notes (deleted_at);
+	`,
+	// 8: sessions with hashed refresh tokens, in place of the plain refresh token of the users.
+	`
+	CREATE TABLE sessions (
+		id                 TEXT PRIMARY KEY,
+		user_id            TEXT NOT NULL,
+		refresh_token_hash TEXT NOT NULL,
+		user_agent         TEXT NOT NULL,
+		ip_address         TEXT NOT NULL,
+		created_at         TEXT NOT NULL,
+		last_used_at       TEXT NOT NULL,
+		expires_at         TEXT NOT NULL
+	);
+
+	CREATE INDEX sessions_user_id ON sessions (user_id);
+	CREATE INDEX sessions_expires_at ON sessions (expires_at);
+
+	ALTER TABLE users DROP COLUMN refresh_token;
+	ALTER TABLE users DROP COLUMN token_family;
+	`,
+	// 9: requests to reset forgotten passwords.
+	`
+	CREATE TABLE password_resets (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+
+	CREATE INDEX password_resets_user_id ON password_resets (user_id);
+	CREATE INDEX password_resets_expires_at ON password_resets (expires_at);
+	`,
+	// 10: email verification, the accounts from before it count as verified.
+	`
+	ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE users ADD COLUMN verification_token_hash TEXT;
+	ALTER TABLE users ADD COLUMN verification_sent_at TEXT NOT NULL DEFAULT '0001-01-01T00:00:00.000000000Z';
+	ALTER TABLE users ADD COLUMN verification_expires_at TEXT NOT NULL DEFAULT '0001-01-01T00:00:00.000000000Z';
+
+	CREATE UNIQUE INDEX users_verification_token_hash ON users (verification_token_hash);
+	`,
+	// 11: TOTP second factor, with hashed one-time recovery codes.
+	`
+	ALTER TABLE users ADD COLUMN totp_secret TEXT;
+	ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+	CREATE TABLE recovery_codes (
+		user_id   TEXT NOT NULL,
+		code_hash TEXT NOT NULL,
+		PRIMARY KEY (user_id, code_hash)
+	);
+	`,
+	// 12: failed logins per email and ip address, and the lockouts they caused.
+	`
+	CREATE TABLE login_failures (
+		key             TEXT PRIMARY KEY,
+		failures        INTEGER NOT NULL,
+		last_failure_at TEXT NOT NULL,
+		expires_at      TEXT NOT NULL
+	);
+
+	CREATE INDEX login_failures_expires_at ON login_failures (expires_at);
+
+	CREATE TABLE lockouts (
+		id           TEXT PRIMARY KEY,
+		kind         TEXT NOT NULL,
+		email        TEXT,
+		ip_address   TEXT NOT NULL,
+		failures     INTEGER NOT NULL,
+		locked_at    TEXT NOT NULL,
+		locked_until TEXT NOT NULL
+	);
+
+	CREATE INDEX lockouts_locked_at ON lockouts (locked_at);
+	`,
+	// 13: personal access tokens, with their scopes as a JSON array.
+	`
+	CREATE TABLE access_tokens (
+		id           TEXT PRIMARY KEY,
+		user_id      TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		token_hash   TEXT NOT NULL UNIQUE,
+		scopes       TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		expires_at   TEXT,
+		last_used_at TEXT
+	);
+
+	CREATE INDEX access_tokens_user_id ON access_tokens (user_id);
+	`,
+	// 14: roles of the users, the accounts from before them are plain users, and disabled accounts.
+	`
+	ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+	ALTER TABLE users ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0;
+	`,
+	// 15: workspaces with their members, the invitations into them and the notes they own.
+	`
+	CREATE TABLE workspaces (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		version    INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE workspace_members (
+		workspace_id TEXT NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+		position     INTEGER NOT NULL,
+		user_id      TEXT NOT NULL,
+		email        TEXT,
+		role         TEXT NOT NULL,
+		joined_at    TEXT NOT NULL,
+		PRIMARY KEY (workspace_id, user_id)
+	);
+
+	CREATE INDEX workspace_members_user_id ON workspace_members (user_id);
+
+	CREATE TABLE workspace_invitations (
+		id           TEXT PRIMARY KEY,
+		workspace_id TEXT NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+		email        TEXT NOT NULL,
+		role         TEXT NOT NULL,
+		token_hash   TEXT NOT NULL UNIQUE,
+		invited_by   TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		expires_at   TEXT NOT NULL,
+		UNIQUE (workspace_id, email)
+	);
+
+	ALTER TABLE notes ADD COLUMN workspace_id TEXT;
+
+	CREATE INDEX notes_workspace_id ON notes (workspace_id);
+	`,
+	// 16: links opening notes without an account, going with their notes.
+	`
+	CREATE TABLE share_links (
+		id             TEXT PRIMARY KEY,
+		note_id        TEXT NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+		role           TEXT NOT NULL,
+		token_hash     TEXT NOT NULL UNIQUE,
+		password_hash  TEXT,
+		max_views      INTEGER,
+		views          INTEGER NOT NULL DEFAULT 0,
+		created_by     TEXT NOT NULL,
+		created_at     TEXT NOT NULL,
+		expires_at     TEXT,
+		last_viewed_at TEXT
+	);
+
+	CREATE INDEX share_links_note_id ON share_links (note_id);
+	`,
+}
+
+// Brings the schema of the database up to date, recording every applied migration.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating the schema migrations table.\n\tError: %s", err.Error())
+		return err
+	}
+
+	var currentVersion int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&currentVersion)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while reading the schema version.\n\tError: %s", err.Error())
+		return err
+	}
+
+	for i := currentVersion; i < len(sqliteMigrations); i++ {
+		version := i + 1
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, sqliteMigrations[i])
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, formatSQLiteTime(time.Now()))
+		}
+		if err != nil {
+			tx.Rollback()
+			logger.Log.Printf("Error: Problem while applying schema migration: %d.\n\tError: %s", version, err.Error())
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+
+		logger.Log.Printf("Message: Applied schema migration: %d.", version)
+	}
+
+	return nil
+}
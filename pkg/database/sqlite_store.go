@@ -0,0 +1,2344 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Store keeping everything in an embedded SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// Times are kept as fixed width UTC text, so that they sort and compare as strings.
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Opens the database file at the given path and brings its schema up to date.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		logger.Log.Printf("Error: Problem while opening the sqlite database.\n\tError: %s", err.Error())
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and a private in-memory database lives on one connection only.
+	db.SetMaxOpenConns(1)
+
+	err = migrateSQLite(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (store *SQLiteStore) Close(ctx context.Context) error {
+	return store.db.Close()
+}
+
+// Either the database or a transaction on it.
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Runs the function in a transaction, committing it if the function succeeds.
+func (store *SQLiteStore) withTx(ctx context.Context, run func(tx *sql.Tx) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = run(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Converts the errors of the driver to the ones of the store.
+func sqliteError(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_CONSTRAINT {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+func formatSQLiteTime(value time.Time) string {
+	return value.UTC().Format(sqliteTimeFormat)
+}
+
+func parseSQLiteTime(value string) (time.Time, error) {
+	return time.Parse(sqliteTimeFormat, value)
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: *value, Valid: true}
+}
+
+// Empty strings are stored as NULL, for unique columns which most rows leave empty.
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// Array of the values as JSON, for matching a column against a list of any length with json_each.
+func sqliteJSONArray(values []string) string {
+	content, _ := json.Marshal(values)
+	return string(content)
+}
+
+func stringPointer(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.String
+}
+
+const sqliteUserColumns = `id, user_id, first_name, last_name, password, email, created_at, updated_at, last_login, revoked_before,
+	email_verified, verification_token_hash, verification_sent_at, verification_expires_at, totp_secret, totp_enabled, totp_last_step, role, disabled`
+
+func scanSQLiteUser(row interface{ Scan(...any) error }) (*models.UserDataServer, error) {
+	var user models.UserDataServer
+	var id string
+	var firstName, lastName, password, email, verificationTokenHash, totpSecret sql.NullString
+	var createdAt, updatedAt, lastLogin, revokedBefore, verificationSentAt, verificationExpiresAt string
+
+	err := row.Scan(&id, &user.UserID, &firstName, &lastName, &password, &email, &createdAt, &updatedAt, &lastLogin, &revokedBefore,
+		&user.Email_Verified, &verificationTokenHash, &verificationSentAt, &verificationExpiresAt, &totpSecret, &user.TOTP_Enabled, &user.TOTP_Last_Step,
+		&user.Role, &user.Disabled)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	user.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.First_Name = stringPointer(firstName)
+	user.Last_Name = stringPointer(lastName)
+	user.Password = stringPointer(password)
+	user.Email = stringPointer(email)
+	user.Verification_Token_Hash = verificationTokenHash.String
+	user.TOTP_Secret = totpSecret.String
+
+	for _, field := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{createdAt, &user.Created_At},
+		{updatedAt, &user.Updated_At},
+		{lastLogin, &user.Last_Login},
+		{revokedBefore, &user.Revoked_Before},
+		{verificationSentAt, &user.Verification_Sent_At},
+		{verificationExpiresAt, &user.Verification_Expires_At},
+	} {
+		*field.target, err = parseSQLiteTime(field.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+func (store *SQLiteStore) findUser(ctx context.Context, condition string, args ...any) (*models.UserDataServer, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteUserColumns+` FROM users WHERE `+condition, args...)
+	return scanSQLiteUser(row)
+}
+
+func (store *SQLiteStore) CreateUser(ctx context.Context, user *models.UserDataServer) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Find whether user with the same email address exists already or not.
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE email = ?`, nullString(user.Email)).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO users (`+sqliteUserColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			user.ID.Hex(), user.UserID, nullString(user.First_Name), nullString(user.Last_Name), nullString(user.Password), nullString(user.Email),
+			formatSQLiteTime(user.Created_At), formatSQLiteTime(user.Updated_At), formatSQLiteTime(user.Last_Login), formatSQLiteTime(user.Revoked_Before),
+			user.Email_Verified, nullableString(user.Verification_Token_Hash), formatSQLiteTime(user.Verification_Sent_At), formatSQLiteTime(user.Verification_Expires_At),
+			nullableString(user.TOTP_Secret), user.TOTP_Enabled, user.TOTP_Last_Step, user.Role, user.Disabled)
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) GetUserByEmail(ctx context.Context, email string) (*models.UserDataServer, error) {
+	return store.findUser(ctx, `email = ?`, email)
+}
+
+func (store *SQLiteStore) GetUserById(ctx context.Context, userId string) (*models.UserDataServer, error) {
+	return store.findUser(ctx, `user_id = ?`, userId)
+}
+
+func (store *SQLiteStore) UpdateLastLogin(ctx context.Context, userId string, lastLogin time.Time) error {
+	_, err := store.db.ExecContext(ctx, `UPDATE users SET last_login = ? WHERE user_id = ?`, formatSQLiteTime(lastLogin), userId)
+	return err
+}
+
+func (store *SQLiteStore) UpdatePassword(ctx context.Context, userId string, password string, updatedAt time.Time) error {
+	result, err := store.db.ExecContext(ctx, `UPDATE users SET password = ?, updated_at = ? WHERE user_id = ?`, password, formatSQLiteTime(updatedAt), userId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) SetVerificationToken(ctx context.Context, userId string, tokenHash string, sentAt time.Time, expiresAt time.Time, sentBefore time.Time) (bool, error) {
+	result, err := store.db.ExecContext(ctx, `UPDATE users SET verification_token_hash = ?, verification_sent_at = ?, verification_expires_at = ?
+		WHERE user_id = ? AND email_verified = 0 AND verification_sent_at <= ?`,
+		tokenHash, formatSQLiteTime(sentAt), formatSQLiteTime(expiresAt), userId, formatSQLiteTime(sentBefore))
+	if err != nil {
+		return false, sqliteError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (store *SQLiteStore) VerifyEmail(ctx context.Context, tokenHash string, verifiedAt time.Time) (*models.UserDataServer, error) {
+	row := store.db.QueryRowContext(ctx, `UPDATE users SET email_verified = 1, verification_token_hash = NULL, updated_at = ?
+		WHERE verification_token_hash = ? AND email_verified = 0 AND verification_expires_at >= ?
+		RETURNING `+sqliteUserColumns, formatSQLiteTime(verifiedAt), tokenHash, formatSQLiteTime(verifiedAt))
+	return scanSQLiteUser(row)
+}
+
+func (store *SQLiteStore) RevokeAllTokens(ctx context.Context, userId string, revokedBefore time.Time) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE users SET revoked_before = ? WHERE user_id = ?`, formatSQLiteTime(revokedBefore), userId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userId)
+		return err
+	})
+}
+
+func (store *SQLiteStore) ListUsers(ctx context.Context, query *UserListQuery) ([]models.UserDataServer, error) {
+	condition, args := `user_id > ?`, []any{query.After}
+
+	if query.Role != "" {
+		condition, args = condition+` AND role = ?`, append(args, query.Role)
+	}
+
+	// LIKE ignores the case of ASCII letters, the escapes keep the wildcards of the search literal.
+	if query.Search != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query.Search) + "%"
+		condition += ` AND (email LIKE ? ESCAPE '\' OR first_name LIKE ? ESCAPE '\' OR last_name LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteUserColumns+` FROM users WHERE `+condition+` ORDER BY user_id LIMIT ?`, append(args, query.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.UserDataServer{}
+	for rows.Next() {
+		user, err := scanSQLiteUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (store *SQLiteStore) SetUserRole(ctx context.Context, userId string, role string, updatedAt time.Time) error {
+	changed, err := store.execChanged(ctx, `UPDATE users SET role = ?, updated_at = ? WHERE user_id = ?`, role, formatSQLiteTime(updatedAt), userId)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) SetUserDisabled(ctx context.Context, userId string, disabled bool, updatedAt time.Time) error {
+	changed, err := store.execChanged(ctx, `UPDATE users SET disabled = ?, updated_at = ? WHERE user_id = ?`, disabled, formatSQLiteTime(updatedAt), userId)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const sqliteSessionColumns = `id, user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at`
+
+func scanSQLiteSession(row interface{ Scan(...any) error }) (*models.Session, error) {
+	var session models.Session
+	var id string
+	var createdAt, lastUsedAt, expiresAt string
+
+	err := row.Scan(&id, &session.User_Id, &session.Refresh_Token_Hash, &session.User_Agent, &session.IP_Address, &createdAt, &lastUsedAt, &expiresAt)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	session.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{createdAt, &session.Created_At},
+		{lastUsedAt, &session.Last_Used_At},
+		{expiresAt, &session.Expires_At},
+	} {
+		*field.target, err = parseSQLiteTime(field.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &session, nil
+}
+
+func (store *SQLiteStore) CreateSession(ctx context.Context, session *models.Session) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the sessions which have expired, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, formatSQLiteTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO sessions (`+sqliteSessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			session.ID.Hex(), session.User_Id, session.Refresh_Token_Hash, session.User_Agent, session.IP_Address,
+			formatSQLiteTime(session.Created_At), formatSQLiteTime(session.Last_Used_At), formatSQLiteTime(session.Expires_At))
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) GetSessionById(ctx context.Context, sessionId string) (*models.Session, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteSessionColumns+` FROM sessions WHERE id = ? AND expires_at >= ?`,
+		sessionId, formatSQLiteTime(time.Now()))
+	return scanSQLiteSession(row)
+}
+
+func (store *SQLiteStore) ListSessions(ctx context.Context, userId string) ([]models.Session, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteSessionColumns+` FROM sessions WHERE user_id = ? AND expires_at >= ? ORDER BY last_used_at DESC`,
+		userId, formatSQLiteTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSQLiteSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (store *SQLiteStore) RotateSessionToken(ctx context.Context, sessionId string, currentTokenHash string, newTokenHash string, ipAddress string, lastUsedAt time.Time, expiresAt time.Time) (bool, error) {
+	result, err := store.db.ExecContext(ctx, `UPDATE sessions SET refresh_token_hash = ?, ip_address = ?, last_used_at = ?, expires_at = ? WHERE id = ? AND refresh_token_hash = ?`,
+		newTokenHash, ipAddress, formatSQLiteTime(lastUsedAt), formatSQLiteTime(expiresAt), sessionId, currentTokenHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (store *SQLiteStore) DeleteSession(ctx context.Context, sessionId string) error {
+	result, err := store.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, sessionId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the resets which have expired, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at < ?`, formatSQLiteTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO password_resets (id, user_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+			reset.ID.Hex(), reset.User_Id, reset.Token_Hash, formatSQLiteTime(reset.Created_At), formatSQLiteTime(reset.Expires_At))
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) TakePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	var id, createdAt, expiresAt string
+
+	// Deleting and reading it in one statement lets only one of concurrent requests have the reset.
+	err := store.db.QueryRowContext(ctx, `DELETE FROM password_resets WHERE token_hash = ? AND expires_at >= ?
+		RETURNING id, user_id, token_hash, created_at, expires_at`, tokenHash, formatSQLiteTime(time.Now())).
+		Scan(&id, &reset.User_Id, &reset.Token_Hash, &createdAt, &expiresAt)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	reset.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reset.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	reset.Expires_At, err = parseSQLiteTime(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+func (store *SQLiteStore) DeletePasswordResets(ctx context.Context, userId string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = ?`, userId)
+	return err
+}
+
+// Runs the statement, reporting whether it changed any row.
+func (store *SQLiteStore) execChanged(ctx context.Context, query string, args ...any) (bool, error) {
+	result, err := store.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (store *SQLiteStore) SetTOTPSecret(ctx context.Context, userId string, secret string) (bool, error) {
+	return store.execChanged(ctx, `UPDATE users SET totp_secret = ? WHERE user_id = ? AND totp_enabled = 0`, secret, userId)
+}
+
+func (store *SQLiteStore) EnableTOTP(ctx context.Context, userId string, step int64) (bool, error) {
+	return store.execChanged(ctx, `UPDATE users SET totp_enabled = 1, totp_last_step = ?
+		WHERE user_id = ? AND totp_enabled = 0 AND totp_secret IS NOT NULL`, step, userId)
+}
+
+func (store *SQLiteStore) DisableTOTP(ctx context.Context, userId string) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled = 0, totp_secret = NULL, totp_last_step = 0 WHERE user_id = ?`, userId)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userId)
+		return err
+	})
+}
+
+func (store *SQLiteStore) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+	return store.execChanged(ctx, `UPDATE users SET totp_last_step = ? WHERE user_id = ? AND totp_last_step < ?`, step, userId, step)
+}
+
+func (store *SQLiteStore) SetRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userId)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range codeHashes {
+			_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userId, codeHash)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (store *SQLiteStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+	return store.execChanged(ctx, `DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?`, userId, codeHash)
+}
+
+func (store *SQLiteStore) CountRecoveryCodes(ctx context.Context, userId string) (int, error) {
+	var count int
+	err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM recovery_codes WHERE user_id = ?`, userId).Scan(&count)
+	return count, err
+}
+
+const sqliteAccessTokenColumns = `id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at`
+
+// Tokens without an expiry never expire.
+const sqliteLiveAccessTokenCondition = `(expires_at IS NULL OR expires_at >= ?)`
+
+func scanSQLiteAccessToken(row interface{ Scan(...any) error }) (*models.AccessToken, error) {
+	var accessToken models.AccessToken
+	var id, scopes, createdAt string
+	var expiresAt, lastUsedAt sql.NullString
+
+	err := row.Scan(&id, &accessToken.User_Id, &accessToken.Name, &accessToken.Token_Hash, &scopes, &createdAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(scopes), &accessToken.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		parsedExpiresAt, err := parseSQLiteTime(expiresAt.String)
+		if err != nil {
+			return nil, err
+		}
+		accessToken.Expires_At = &parsedExpiresAt
+	}
+
+	if lastUsedAt.Valid {
+		parsedLastUsedAt, err := parseSQLiteTime(lastUsedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		accessToken.Last_Used_At = &parsedLastUsedAt
+	}
+
+	return &accessToken, nil
+}
+
+func (store *SQLiteStore) CreateAccessToken(ctx context.Context, accessToken *models.AccessToken) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the tokens which have expired, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM access_tokens WHERE expires_at < ?`, formatSQLiteTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		var expiresAt sql.NullString
+		if accessToken.Expires_At != nil {
+			expiresAt = sql.NullString{String: formatSQLiteTime(*accessToken.Expires_At), Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			accessToken.ID.Hex(), accessToken.User_Id, accessToken.Name, accessToken.Token_Hash, sqliteJSONArray(accessToken.Scopes),
+			formatSQLiteTime(accessToken.Created_At), expiresAt)
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteAccessTokenColumns+` FROM access_tokens WHERE token_hash = ? AND `+sqliteLiveAccessTokenCondition,
+		tokenHash, formatSQLiteTime(time.Now()))
+
+	accessToken, err := scanSQLiteAccessToken(row)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	return accessToken, nil
+}
+
+func (store *SQLiteStore) ListAccessTokens(ctx context.Context, userId string) ([]models.AccessToken, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteAccessTokenColumns+` FROM access_tokens WHERE user_id = ? AND `+sqliteLiveAccessTokenCondition+`
+		ORDER BY id DESC`, userId, formatSQLiteTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accessTokens := []models.AccessToken{}
+	for rows.Next() {
+		accessToken, err := scanSQLiteAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		accessTokens = append(accessTokens, *accessToken)
+	}
+
+	return accessTokens, rows.Err()
+}
+
+func (store *SQLiteStore) SetAccessTokenLastUsed(ctx context.Context, tokenId string, lastUsedAt time.Time) error {
+	changed, err := store.execChanged(ctx, `UPDATE access_tokens SET last_used_at = ? WHERE id = ?`, formatSQLiteTime(lastUsedAt), tokenId)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) DeleteAccessToken(ctx context.Context, userId string, tokenId string) error {
+	deleted, err := store.execChanged(ctx, `DELETE FROM access_tokens WHERE id = ? AND user_id = ?`, tokenId, userId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) DeleteAccessTokens(ctx context.Context, userId string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM access_tokens WHERE user_id = ?`, userId)
+	return err
+}
+
+func (store *SQLiteStore) RecordLoginFailure(ctx context.Context, key string, failedAt time.Time, forgetBefore time.Time, expiresAt time.Time) (int, error) {
+	var failures int
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the failures which have expired, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM login_failures WHERE expires_at < ?`, formatSQLiteTime(failedAt))
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, `INSERT INTO login_failures (key, failures, last_failure_at, expires_at) VALUES (?, 1, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN login_failures.last_failure_at < ? THEN 1 ELSE login_failures.failures + 1 END,
+				last_failure_at = excluded.last_failure_at,
+				expires_at = excluded.expires_at
+			RETURNING failures`,
+			key, formatSQLiteTime(failedAt), formatSQLiteTime(expiresAt), formatSQLiteTime(forgetBefore)).Scan(&failures)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (store *SQLiteStore) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	failures := models.LoginFailures{Key: key}
+	var lastFailureAt, expiresAt string
+
+	err := store.db.QueryRowContext(ctx, `SELECT failures, last_failure_at, expires_at FROM login_failures WHERE key = ? AND expires_at >= ?`,
+		key, formatSQLiteTime(time.Now())).Scan(&failures.Failures, &lastFailureAt, &expiresAt)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	failures.Last_Failure_At, err = parseSQLiteTime(lastFailureAt)
+	if err != nil {
+		return nil, err
+	}
+
+	failures.Expires_At, err = parseSQLiteTime(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failures, nil
+}
+
+func (store *SQLiteStore) ClearLoginFailures(ctx context.Context, key string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM login_failures WHERE key = ?`, key)
+	return err
+}
+
+func (store *SQLiteStore) CreateLockout(ctx context.Context, lockout *models.Lockout) error {
+	_, err := store.db.ExecContext(ctx, `INSERT INTO lockouts (id, kind, email, ip_address, failures, locked_at, locked_until) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		lockout.ID.Hex(), lockout.Kind, nullableString(lockout.Email), lockout.IP_Address, lockout.Failures,
+		formatSQLiteTime(lockout.Locked_At), formatSQLiteTime(lockout.Locked_Until))
+	return sqliteError(err)
+}
+
+func (store *SQLiteStore) ListLockouts(ctx context.Context, limit int) ([]models.Lockout, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT id, kind, email, ip_address, failures, locked_at, locked_until FROM lockouts
+		ORDER BY locked_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := []models.Lockout{}
+	for rows.Next() {
+		var lockout models.Lockout
+		var id, lockedAt, lockedUntil string
+		var email sql.NullString
+
+		err = rows.Scan(&id, &lockout.Kind, &email, &lockout.IP_Address, &lockout.Failures, &lockedAt, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		lockout.ID, err = primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		lockout.Email = email.String
+
+		lockout.Locked_At, err = parseSQLiteTime(lockedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		lockout.Locked_Until, err = parseSQLiteTime(lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		lockouts = append(lockouts, lockout)
+	}
+
+	return lockouts, rows.Err()
+}
+
+func (store *SQLiteStore) RevokeToken(ctx context.Context, revokedToken *models.RevokedToken) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the entries of tokens which have expired anyway, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < ?`, formatSQLiteTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO revoked_tokens (token_id, user_id, revoked_at, expires_at) VALUES (?, ?, ?, ?)`,
+			revokedToken.Token_Id, revokedToken.User_Id, formatSQLiteTime(revokedToken.Revoked_At), formatSQLiteTime(revokedToken.Expires_At))
+		return err
+	})
+}
+
+func (store *SQLiteStore) ConsumeToken(ctx context.Context, revokedToken *models.RevokedToken) (bool, error) {
+	// The token id is the primary key, so only one of concurrent inserts of the same token adds a row.
+	result, err := store.db.ExecContext(ctx, `INSERT OR IGNORE INTO revoked_tokens (token_id, user_id, revoked_at, expires_at) VALUES (?, ?, ?, ?)`,
+		revokedToken.Token_Id, revokedToken.User_Id, formatSQLiteTime(revokedToken.Revoked_At), formatSQLiteTime(revokedToken.Expires_At))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (store *SQLiteStore) IsTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	var count int
+	err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM revoked_tokens WHERE token_id = ?`, tokenId).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+const sqliteNoteColumns = `notes.id, notes.user_id, notes.header, notes.unique_header, notes.email, notes.notes_data, notes.sharable, notes.notebook_id, notes.version, notes.created_at, notes.updated_at, notes.deleted_at, notes.workspace_id`
+
+// Condition matching the notes out of the trash.
+const sqliteLiveNoteCondition = `notes.deleted_at IS NULL`
+
+// Condition matching the notes the user owns outside of workspaces.
+const sqliteOwnedNotesCondition = `notes.user_id = ? AND notes.workspace_id IS NULL`
+
+// Condition matching every note the user can view, same rule as the mongo filter.
+const sqliteViewableNotesCondition = `((` + sqliteOwnedNotesCondition + `) OR notes.sharable = 1 OR EXISTS (
+	SELECT 1 FROM note_access WHERE note_access.note_id = notes.id AND note_access.user_id = ?
+))`
+
+func scanSQLiteNote(row interface{ Scan(...any) error }) (*models.NoteData, error) {
+	var note models.NoteData
+	var id string
+	var userId, header, uniqueHeader, email, data, notebookId, workspaceId sql.NullString
+	var sharable sql.NullBool
+	var createdAt, updatedAt string
+	var deletedAt sql.NullString
+
+	err := row.Scan(&id, &userId, &header, &uniqueHeader, &email, &data, &sharable, &notebookId, &note.Version, &createdAt, &updatedAt, &deletedAt, &workspaceId)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	note.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	note.User_Id = stringPointer(userId)
+	note.Header = stringPointer(header)
+	note.Unique_Header = stringPointer(uniqueHeader)
+	note.Email = stringPointer(email)
+	note.Data = stringPointer(data)
+	note.Notebook_Id = stringPointer(notebookId)
+	note.Workspace_Id = stringPointer(workspaceId)
+
+	if sharable.Valid {
+		note.Sharable = &sharable.Bool
+	}
+
+	note.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Updated_At, err = parseSQLiteTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if deletedAt.Valid {
+		parsedDeletedAt, err := parseSQLiteTime(deletedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		note.Deleted_At = &parsedDeletedAt
+	}
+
+	return &note, nil
+}
+
+// Placeholders for a list of the given number of parameters.
+func sqlitePlaceholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// Ids of the notes, split so that a query with each part stays well below the limit SQLite puts on the number of parameters.
+func sqliteNoteIdChunks(notes []models.NoteData) [][]any {
+	const chunkSize = 500
+
+	var chunks [][]any
+
+	for start := 0; start < len(notes); start += chunkSize {
+		end := min(start+chunkSize, len(notes))
+
+		ids := make([]any, 0, end-start)
+		for _, note := range notes[start:end] {
+			ids = append(ids, note.ID.Hex())
+		}
+
+		chunks = append(chunks, ids)
+	}
+
+	return chunks
+}
+
+// Fills in the access lists of the notes, which live in their own table.
+func loadSQLiteNoteAccess(ctx context.Context, querier sqliteQuerier, notes []models.NoteData) error {
+	byId := make(map[string]*models.NoteData, len(notes))
+	for i := range notes {
+		notes[i].Access = []models.NoteAccess{}
+		byId[notes[i].ID.Hex()] = &notes[i]
+	}
+
+	for _, args := range sqliteNoteIdChunks(notes) {
+		rows, err := querier.QueryContext(ctx, `SELECT note_id, user_id, email, role, granted_by, granted_at FROM note_access
+			WHERE note_id IN (`+sqlitePlaceholders(len(args))+`) ORDER BY note_id, position`, args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var noteId, grantedAt string
+			var email sql.NullString
+			var access models.NoteAccess
+
+			err = rows.Scan(&noteId, &access.User_Id, &email, &access.Role, &access.Granted_By, &grantedAt)
+			if err == nil {
+				access.Granted_At, err = parseSQLiteTime(grantedAt)
+			}
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			access.Email = stringPointer(email)
+			byId[noteId].Access = append(byId[noteId].Access, access)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fills in the tags of the notes, which live in their own table.
+func loadSQLiteNoteTags(ctx context.Context, querier sqliteQuerier, notes []models.NoteData) error {
+	byId := make(map[string]*models.NoteData, len(notes))
+	for i := range notes {
+		notes[i].Tags = []string{}
+		byId[notes[i].ID.Hex()] = &notes[i]
+	}
+
+	for _, args := range sqliteNoteIdChunks(notes) {
+		rows, err := querier.QueryContext(ctx, `SELECT note_id, tag FROM note_tags
+			WHERE note_id IN (`+sqlitePlaceholders(len(args))+`) ORDER BY note_id, tag`, args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var noteId, tag string
+
+			err = rows.Scan(&noteId, &tag)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			byId[noteId].Tags = append(byId[noteId].Tags, tag)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) findNotes(ctx context.Context, querier sqliteQuerier, condition string, args ...any) ([]models.NoteData, error) {
+	return store.selectNotes(ctx, querier, sqliteNoteColumns, condition+` ORDER BY notes.seq`, args...)
+}
+
+// Selects the notes matching the clause, which holds the condition and anything after it.
+func (store *SQLiteStore) selectNotes(ctx context.Context, querier sqliteQuerier, columns string, clause string, args ...any) ([]models.NoteData, error) {
+	rows, err := querier.QueryContext(ctx, `SELECT `+columns+` FROM notes WHERE `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	foundNotes := []models.NoteData{}
+
+	for rows.Next() {
+		foundNote, err := scanSQLiteNote(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		foundNotes = append(foundNotes, *foundNote)
+	}
+
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows have to be closed first, the store only has a single connection.
+	err = loadSQLiteNoteAccess(ctx, querier, foundNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = loadSQLiteNoteTags(ctx, querier, foundNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundNotes, nil
+}
+
+func (store *SQLiteStore) findNote(ctx context.Context, querier sqliteQuerier, condition string, args ...any) (*models.NoteData, error) {
+	foundNotes, err := store.findNotes(ctx, querier, condition, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(foundNotes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &foundNotes[0], nil
+}
+
+func insertSQLiteNoteAccess(ctx context.Context, tx *sql.Tx, noteId string, accessList []models.NoteAccess) error {
+	for position, access := range accessList {
+		_, err := tx.ExecContext(ctx, `INSERT INTO note_access (note_id, position, user_id, email, role, granted_by, granted_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			noteId, position, access.User_Id, nullString(access.Email), access.Role, access.Granted_By, formatSQLiteTime(access.Granted_At))
+		if err != nil {
+			return sqliteError(err)
+		}
+	}
+
+	return nil
+}
+
+func insertSQLiteNoteTags(ctx context.Context, tx *sql.Tx, noteId string, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.ExecContext(ctx, `INSERT INTO note_tags (note_id, tag) VALUES (?, ?)`, noteId, tag)
+		if err != nil {
+			return sqliteError(err)
+		}
+	}
+
+	return nil
+}
+
+// Adds the conditions of the filter to the condition of a query.
+func sqliteNoteFilterCondition(condition string, args []any, filter *NoteFilter) (string, []any) {
+	for _, tag := range filter.Tags {
+		condition = `(` + condition + `) AND EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id AND note_tags.tag = ?)`
+		args = append(args, tag)
+	}
+
+	if filter.Notebook_Ids != nil {
+		condition = `(` + condition + `) AND notes.notebook_id IN (SELECT value FROM json_each(?))`
+		args = append(args, sqliteJSONArray(filter.Notebook_Ids))
+	}
+
+	return condition, args
+}
+
+// Applies the change to the note while it is at the given version, increasing the version,
+// and returns the changed note.
+func (store *SQLiteStore) updateNote(ctx context.Context, noteId string, version int64, assignments []string, args []any, change func(tx *sql.Tx) error) (*models.NoteData, error) {
+	var updatedNote *models.NoteData
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		assignments = append(assignments, `version = version + 1`)
+		args = append(args, noteId, version)
+
+		result, err := tx.ExecContext(ctx, `UPDATE notes SET `+strings.Join(assignments, ", ")+` WHERE id = ? AND version = ?`, args...)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			// Tell apart a note which no longer exists from one which is at another version.
+			_, err = store.findNote(ctx, tx, `notes.id = ?`, noteId)
+			if err == nil {
+				return ErrVersionConflict
+			}
+			return err
+		}
+
+		if change != nil {
+			err = change(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		updatedNote, err = store.findNote(ctx, tx, `notes.id = ?`, noteId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedNote, nil
+}
+
+func (store *SQLiteStore) CreateNote(ctx context.Context, note *models.NoteData) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Find the note if already present with the same unique header.
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE unique_header = ?`, nullString(note.Unique_Header)).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+
+		var sharable sql.NullBool
+		if note.Sharable != nil {
+			sharable = sql.NullBool{Bool: *note.Sharable, Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO notes (id, user_id, header, unique_header, email, notes_data, sharable, notebook_id, workspace_id, version, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			note.ID.Hex(), nullString(note.User_Id), nullString(note.Header), nullString(note.Unique_Header), nullString(note.Email), nullString(note.Data),
+			sharable, nullString(note.Notebook_Id), nullString(note.Workspace_Id), note.Version, formatSQLiteTime(note.Created_At), formatSQLiteTime(note.Updated_At))
+		if err != nil {
+			return sqliteError(err)
+		}
+
+		err = insertSQLiteNoteAccess(ctx, tx, note.ID.Hex(), note.Access)
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteNoteTags(ctx, tx, note.ID.Hex(), note.Tags)
+	})
+}
+
+func (store *SQLiteStore) GetNoteById(ctx context.Context, noteId string) (*models.NoteData, error) {
+	return store.findNote(ctx, store.db, `notes.id = ? AND `+sqliteLiveNoteCondition, noteId)
+}
+
+func (store *SQLiteStore) GetNoteByUniqueHeader(ctx context.Context, uniqueHeader string) (*models.NoteData, error) {
+	return store.findNote(ctx, store.db, `notes.unique_header = ?`, uniqueHeader)
+}
+
+// Expressions notes are listed by, by the name of the sort field.
+// A missing header sorts like an empty one, matching the expression of the index.
+var sqliteNoteSortColumns = map[string]string{
+	SortByCreatedAt: "notes.created_at",
+	SortByUpdatedAt: "notes.updated_at",
+	SortByHeader:    "COALESCE(notes.header, '')",
+}
+
+func (store *SQLiteStore) ListAllNotes(ctx context.Context, afterId string, limit int) ([]models.NoteData, error) {
+	return store.selectNotes(ctx, store.db, sqliteNoteColumns, `notes.id > ? AND `+sqliteLiveNoteCondition+` ORDER BY notes.id LIMIT ?`, afterId, limit)
+}
+
+// Condition matching the notes in the scope of a listing for the user, or in the workspace of the filter if it has one.
+func sqliteNoteScopeCondition(userId string, scope string, filter *NoteFilter) (string, []any) {
+	if filter.Workspace_Id != "" {
+		return `notes.workspace_id = ?`, []any{filter.Workspace_Id}
+	}
+
+	switch scope {
+	case OwnedNotesScope:
+		return sqliteOwnedNotesCondition, []any{userId}
+	case SharedNotesScope:
+		return `EXISTS (SELECT 1 FROM note_access WHERE note_access.note_id = notes.id AND note_access.user_id = ?)`, []any{userId}
+	case PublicNotesScope:
+		return `notes.sharable = 1`, nil
+	default:
+		return sqliteViewableNotesCondition, []any{userId, userId}
+	}
+}
+
+func (store *SQLiteStore) ListNotes(ctx context.Context, query *NoteListQuery) ([]models.NoteData, error) {
+	condition, args := sqliteNoteScopeCondition(query.User_Id, query.Scope, &query.Filter)
+	condition, args = sqliteNoteFilterCondition(`(`+condition+`) AND `+sqliteLiveNoteCondition, args, &query.Filter)
+
+	sortColumn, ok := sqliteNoteSortColumns[query.Sort_By]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field: %s", query.Sort_By)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	// Continue after the cursor: further along the sort field, or at the same value with a further id.
+	if query.After != nil {
+		var afterValue any = formatSQLiteTime(query.After.Time)
+		if query.Sort_By == SortByHeader {
+			afterValue = query.After.Header
+		}
+
+		condition = `(` + condition + `) AND (` + sortColumn + ` ` + comparison + ` ? OR (` + sortColumn + ` = ? AND notes.id ` + comparison + ` ?))`
+		args = append(args, afterValue, afterValue, query.After.Id)
+	}
+
+	clause := condition + ` ORDER BY ` + sortColumn + ` ` + direction + `, notes.id ` + direction
+
+	if query.Limit > 0 {
+		clause += ` LIMIT ?`
+		args = append(args, query.Limit)
+	}
+
+	// The notes data is the only column worth leaving out when the caller does not need it.
+	columns := sqliteNoteColumns
+	if query.Fields != nil && !containsString(query.Fields, "notesData") {
+		columns = strings.Replace(columns, `notes.notes_data`, `NULL`, 1)
+	}
+
+	return store.selectNotes(ctx, store.db, columns, clause, args...)
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (store *SQLiteStore) SearchNotes(ctx context.Context, userId string, query *search.Query, filter *NoteFilter) ([]models.NoteData, error) {
+	condition, args := sqliteNoteScopeCondition(userId, AllNotesScope, filter)
+	condition, args = sqliteNoteFilterCondition(`(`+condition+`) AND `+sqliteLiveNoteCondition, args, filter)
+
+	// Only the notes containing every phrase the query requires are looked up in the full text index,
+	// and then matched against the whole query.
+	if expression := sqliteFullTextExpression(query.RequiredPhrases()); expression != "" {
+		condition += ` AND notes.seq IN (SELECT rowid FROM notes_search WHERE notes_search MATCH ?)`
+		args = append(args, expression)
+	}
+
+	candidateNotes, err := store.findNotes(ctx, store.db, condition, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	foundNotes := []models.NoteData{}
+	for _, note := range candidateNotes {
+		if query.Matches(stringValue(note.Header), stringValue(note.Data)) {
+			foundNotes = append(foundNotes, note)
+		}
+	}
+
+	return foundNotes, nil
+}
+
+// Full text query requiring all the phrases, each quoted so that no word is read as FTS5 syntax.
+func sqliteFullTextExpression(phrases []search.Phrase) string {
+	var parts []string
+
+	for _, phrase := range phrases {
+		quotedWords := make([]string, len(phrase.Words))
+		for i, word := range phrase.Words {
+			quotedWords[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		}
+
+		part := strings.Join(quotedWords, " + ")
+		if phrase.Prefix {
+			part += "*"
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, " AND ")
+}
+
+func (store *SQLiteStore) UpdateNote(ctx context.Context, noteId string, version int64, update *NoteUpdate) (*models.NoteData, error) {
+	// Make the update object.
+	var assignments []string
+	var args []any
+
+	if update.Header != nil {
+		assignments = append(assignments, `header = ?`)
+		args = append(args, *update.Header)
+	}
+
+	if update.Unique_Header != nil {
+		assignments = append(assignments, `unique_header = ?`)
+		args = append(args, *update.Unique_Header)
+	}
+
+	if update.Data != nil {
+		assignments = append(assignments, `notes_data = ?`)
+		args = append(args, *update.Data)
+	}
+
+	if update.Sharable != nil {
+		assignments = append(assignments, `sharable = ?`)
+		args = append(args, *update.Sharable)
+	}
+
+	if update.Notebook_Id != nil {
+		assignments = append(assignments, `notebook_id = NULLIF(?, '')`)
+		args = append(args, *update.Notebook_Id)
+	}
+
+	assignments = append(assignments, `updated_at = ?`)
+	args = append(args, formatSQLiteTime(update.Updated_At))
+
+	change := func(tx *sql.Tx) error {
+		if update.Unique_Header != nil {
+			// The note has the unique header by now, so any other note with it is a duplicate.
+			var count int
+			err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE unique_header = ? AND id != ?`, *update.Unique_Header, noteId).Scan(&count)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrDuplicate
+			}
+		}
+
+		if update.Tags != nil {
+			_, err := tx.ExecContext(ctx, `DELETE FROM note_tags WHERE note_id = ?`, noteId)
+			if err != nil {
+				return err
+			}
+
+			return insertSQLiteNoteTags(ctx, tx, noteId, *update.Tags)
+		}
+
+		return nil
+	}
+
+	return store.updateNote(ctx, noteId, version, assignments, args, change)
+}
+
+func (store *SQLiteStore) ListTags(ctx context.Context, userId string) ([]models.TagCount, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT note_tags.tag, COUNT(*) FROM note_tags JOIN notes ON notes.id = note_tags.note_id
+		WHERE notes.user_id = ? AND notes.workspace_id IS NULL AND notes.deleted_at IS NULL GROUP BY note_tags.tag ORDER BY COUNT(*) DESC, note_tags.tag`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tagCounts := []models.TagCount{}
+
+	for rows.Next() {
+		var tagCount models.TagCount
+
+		err = rows.Scan(&tagCount.Tag, &tagCount.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		tagCounts = append(tagCounts, tagCount)
+	}
+
+	return tagCounts, rows.Err()
+}
+
+func (store *SQLiteStore) ReplaceTags(ctx context.Context, userId string, oldTags []string, newTag string) ([]models.NoteData, error) {
+	var changedNotes []models.NoteData
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		// Notes of the user with any of the old tags, which still holds until the old tags are deleted.
+		condition := sqliteOwnedNotesCondition + ` AND notes.deleted_at IS NULL AND EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.id AND note_tags.tag IN (` + sqlitePlaceholders(len(oldTags)) + `))`
+		args := append([]any{userId}, stringsToArgs(oldTags)...)
+
+		_, err := tx.ExecContext(ctx, `UPDATE notes SET version = version + 1, updated_at = ? WHERE `+condition,
+			append([]any{formatSQLiteTime(time.Now())}, args...)...)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO note_tags (note_id, tag) SELECT notes.id, ? FROM notes WHERE `+condition,
+			append([]any{newTag}, args...)...)
+		if err != nil {
+			return err
+		}
+
+		changedNotes, err = store.findNotes(ctx, tx, condition, args...)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM note_tags WHERE note_id IN (SELECT id FROM notes WHERE user_id = ? AND workspace_id IS NULL AND deleted_at IS NULL)
+			AND tag IN (`+sqlitePlaceholders(len(oldTags))+`) AND tag != ?`, append(args, newTag)...)
+		if err != nil {
+			return err
+		}
+
+		return loadSQLiteNoteTags(ctx, tx, changedNotes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changedNotes, nil
+}
+
+func stringsToArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
+
+func (store *SQLiteStore) SetNoteAccess(ctx context.Context, noteId string, version int64, accessList []models.NoteAccess) (*models.NoteData, error) {
+	return store.updateNote(ctx, noteId, version, []string{`updated_at = ?`}, []any{formatSQLiteTime(time.Now())}, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM note_access WHERE note_id = ?`, noteId)
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteNoteAccess(ctx, tx, noteId, accessList)
+	})
+}
+
+func (store *SQLiteStore) DeleteNote(ctx context.Context, noteId string, version int64) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// The access list and the tags go with the note through their foreign keys.
+		result, err := tx.ExecContext(ctx, `DELETE FROM notes WHERE id = ? AND version = ?`, noteId, version)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			_, err = store.findNote(ctx, tx, `notes.id = ?`, noteId)
+			if err == nil {
+				return ErrVersionConflict
+			}
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (store *SQLiteStore) TrashNote(ctx context.Context, noteId string, version int64, deletedAt time.Time) (*models.NoteData, error) {
+	return store.updateNote(ctx, noteId, version, []string{`unique_header = ?`, `deleted_at = ?`}, []any{TrashedUniqueHeader(noteId), formatSQLiteTime(deletedAt)}, nil)
+}
+
+func (store *SQLiteStore) RestoreNote(ctx context.Context, noteId string, version int64, uniqueHeader string, notebookId *string) (*models.NoteData, error) {
+	return store.updateNote(ctx, noteId, version, []string{`unique_header = ?`, `notebook_id = ?`, `deleted_at = NULL`}, []any{uniqueHeader, nullString(notebookId)}, func(tx *sql.Tx) error {
+		// The note has the unique header by now, so any other note with it is a duplicate.
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE unique_header = ? AND id != ?`, uniqueHeader, noteId).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+
+		return nil
+	})
+}
+
+func (store *SQLiteStore) GetTrashedNote(ctx context.Context, noteId string) (*models.NoteData, error) {
+	return store.findNote(ctx, store.db, `notes.id = ? AND notes.deleted_at IS NOT NULL`, noteId)
+}
+
+func (store *SQLiteStore) ListTrash(ctx context.Context, userId string) ([]models.NoteData, error) {
+	return store.selectNotes(ctx, store.db, sqliteNoteColumns, sqliteOwnedNotesCondition+` AND notes.deleted_at IS NOT NULL ORDER BY notes.deleted_at DESC, notes.id DESC`, userId)
+}
+
+func (store *SQLiteStore) ListWorkspaceTrash(ctx context.Context, workspaceId string) ([]models.NoteData, error) {
+	return store.selectNotes(ctx, store.db, sqliteNoteColumns, `notes.workspace_id = ? AND notes.deleted_at IS NOT NULL ORDER BY notes.deleted_at DESC, notes.id DESC`, workspaceId)
+}
+
+func (store *SQLiteStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		// The access lists, tags and share links go with the notes through their foreign keys, the revisions do not.
+		_, err := tx.ExecContext(ctx, `DELETE FROM note_revisions WHERE note_id IN (SELECT id FROM notes WHERE deleted_at < ?)`, formatSQLiteTime(deletedBefore))
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM notes WHERE deleted_at < ?`, formatSQLiteTime(deletedBefore))
+		if err != nil {
+			return err
+		}
+
+		purged, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+const sqliteNotebookColumns = `id, user_id, name, parent_id, ancestors, created_at, updated_at`
+
+// Condition matching the notebook with the given id and every notebook below it.
+const sqliteNotebookSubtreeCondition = `(id = ? OR instr(ancestors, ? || '/') > 0)`
+
+func scanSQLiteNotebook(row interface{ Scan(...any) error }) (*models.Notebook, error) {
+	var notebook models.Notebook
+	var id, ancestors, createdAt, updatedAt string
+	var parentId sql.NullString
+
+	err := row.Scan(&id, &notebook.User_Id, &notebook.Name, &parentId, &ancestors, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	notebook.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	notebook.Parent_Id = stringPointer(parentId)
+
+	notebook.Ancestors = []string{}
+	if ancestors != "" {
+		notebook.Ancestors = strings.Split(strings.TrimSuffix(ancestors, "/"), "/")
+	}
+
+	notebook.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	notebook.Updated_At, err = parseSQLiteTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notebook, nil
+}
+
+// Ancestors in the form they are stored in, each id followed by a slash.
+func formatSQLiteAncestors(ancestors []string) string {
+	var formatted strings.Builder
+	for _, id := range ancestors {
+		formatted.WriteString(id + "/")
+	}
+
+	return formatted.String()
+}
+
+func (store *SQLiteStore) findNotebook(ctx context.Context, querier sqliteQuerier, notebookId string) (*models.Notebook, error) {
+	return scanSQLiteNotebook(querier.QueryRowContext(ctx, `SELECT `+sqliteNotebookColumns+` FROM notebooks WHERE id = ?`, notebookId))
+}
+
+func (store *SQLiteStore) CreateNotebook(ctx context.Context, notebook *models.Notebook) error {
+	_, err := store.db.ExecContext(ctx, `INSERT INTO notebooks (`+sqliteNotebookColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		notebook.ID.Hex(), notebook.User_Id, notebook.Name, nullString(notebook.Parent_Id), formatSQLiteAncestors(notebook.Ancestors),
+		formatSQLiteTime(notebook.Created_At), formatSQLiteTime(notebook.Updated_At))
+	return sqliteError(err)
+}
+
+func (store *SQLiteStore) GetNotebookById(ctx context.Context, notebookId string) (*models.Notebook, error) {
+	return store.findNotebook(ctx, store.db, notebookId)
+}
+
+func (store *SQLiteStore) ListNotebooks(ctx context.Context, userId string) ([]models.Notebook, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteNotebookColumns+` FROM notebooks WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foundNotebooks := []models.Notebook{}
+
+	for rows.Next() {
+		foundNotebook, err := scanSQLiteNotebook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		foundNotebooks = append(foundNotebooks, *foundNotebook)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	sortNotebooks(foundNotebooks)
+	return foundNotebooks, nil
+}
+
+func (store *SQLiteStore) UpdateNotebook(ctx context.Context, notebookId string, update *NotebookUpdate) (*models.Notebook, error) {
+	var updatedNotebook *models.Notebook
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := store.findNotebook(ctx, tx, notebookId)
+		if err != nil {
+			return err
+		}
+
+		assignments := []string{`updated_at = ?`}
+		args := []any{formatSQLiteTime(update.Updated_At)}
+
+		if update.Name != nil {
+			assignments = append(assignments, `name = ?`)
+			args = append(args, *update.Name)
+		}
+
+		ancestors := ""
+
+		if update.Parent_Id != nil {
+			if *update.Parent_Id != "" {
+				parent, err := store.findNotebook(ctx, tx, *update.Parent_Id)
+				if err != nil {
+					return err
+				}
+				ancestors = formatSQLiteAncestors(append(parent.Ancestors, parent.ID.Hex()))
+			}
+
+			assignments = append(assignments, `parent_id = NULLIF(?, '')`, `ancestors = ?`)
+			args = append(args, *update.Parent_Id, ancestors)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE notebooks SET `+strings.Join(assignments, ", ")+` WHERE id = ?`, append(args, notebookId)...)
+		if err != nil {
+			return sqliteError(err)
+		}
+
+		// The notebooks below it move along, keeping their ancestors from this notebook down.
+		if update.Parent_Id != nil {
+			_, err = tx.ExecContext(ctx, `UPDATE notebooks SET ancestors = ? || substr(ancestors, instr(ancestors, ? || '/'))
+				WHERE instr(ancestors, ? || '/') > 0`, ancestors, notebookId, notebookId)
+			if err != nil {
+				return err
+			}
+		}
+
+		updatedNotebook, err = store.findNotebook(ctx, tx, notebookId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedNotebook, nil
+}
+
+func (store *SQLiteStore) DeleteNotebook(ctx context.Context, notebookId string, cascade bool) ([]models.NoteData, error) {
+	var changedNotes []models.NoteData
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		foundNotebook, err := store.findNotebook(ctx, tx, notebookId)
+		if err != nil {
+			return err
+		}
+
+		if cascade {
+			notesCondition := `notes.notebook_id IN (SELECT id FROM notebooks WHERE ` + sqliteNotebookSubtreeCondition + `)`
+
+			changedNotes, err = store.findNotes(ctx, tx, notesCondition+` AND notes.deleted_at IS NULL`, notebookId, notebookId)
+			if err != nil {
+				return err
+			}
+
+			deletedAt := time.Now()
+
+			_, err = tx.ExecContext(ctx, `UPDATE notes SET unique_header = 'trash/' || id, deleted_at = ? WHERE deleted_at IS NULL AND `+notesCondition,
+				formatSQLiteTime(deletedAt), notebookId, notebookId)
+			if err != nil {
+				return err
+			}
+
+			// The notes go to the trash outside of notebooks, where they come back to when restored.
+			_, err = tx.ExecContext(ctx, `UPDATE notes SET notebook_id = NULL, version = version + 1 WHERE `+notesCondition, notebookId, notebookId)
+			if err != nil {
+				return err
+			}
+
+			for i := range changedNotes {
+				trashedUniqueHeader := TrashedUniqueHeader(changedNotes[i].ID.Hex())
+				changedNotes[i].Unique_Header = &trashedUniqueHeader
+				changedNotes[i].Deleted_At = &deletedAt
+				changedNotes[i].Notebook_Id = nil
+				changedNotes[i].Version++
+			}
+
+			_, err = tx.ExecContext(ctx, `DELETE FROM notebooks WHERE `+sqliteNotebookSubtreeCondition, notebookId, notebookId)
+			return err
+		}
+
+		// A child with the name of a notebook already in the parent breaks the unique index.
+		_, err = tx.ExecContext(ctx, `UPDATE notebooks SET parent_id = ? WHERE parent_id = ?`, nullString(foundNotebook.Parent_Id), notebookId)
+		if err != nil {
+			return sqliteError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE notebooks SET ancestors = replace(ancestors, ? || '/', '') WHERE instr(ancestors, ? || '/') > 0`, notebookId, notebookId)
+		if err != nil {
+			return err
+		}
+
+		notes, err := store.findNotes(ctx, tx, `notes.notebook_id = ?`, notebookId)
+		if err != nil {
+			return err
+		}
+
+		updatedAt := formatSQLiteTime(time.Now())
+		noteIds := []string{}
+
+		for _, note := range notes {
+			// Notes in the trash move along, keeping their trashed unique header.
+			uniqueHeader := stringValue(note.Unique_Header)
+
+			if note.Deleted_At == nil {
+				uniqueHeader = UniqueHeader(stringValue(note.User_Id), foundNotebook.Parent_Id, stringValue(note.Header))
+
+				var count int
+				err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE unique_header = ?`, uniqueHeader).Scan(&count)
+				if err != nil {
+					return err
+				}
+				if count > 0 {
+					return ErrDuplicate
+				}
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE notes SET notebook_id = ?, unique_header = ?, updated_at = ?, version = version + 1 WHERE id = ?`,
+				nullString(foundNotebook.Parent_Id), uniqueHeader, updatedAt, note.ID.Hex())
+			if err != nil {
+				return err
+			}
+
+			noteIds = append(noteIds, note.ID.Hex())
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM notebooks WHERE id = ?`, notebookId)
+		if err != nil {
+			return err
+		}
+
+		changedNotes, err = store.findNotes(ctx, tx, `notes.id IN (SELECT value FROM json_each(?))`, sqliteJSONArray(noteIds))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changedNotes, nil
+}
+
+const sqliteWorkspaceColumns = `id, name, version, created_at, updated_at`
+
+func scanSQLiteWorkspace(row interface{ Scan(...any) error }) (*models.Workspace, error) {
+	var workspace models.Workspace
+	var id, createdAt, updatedAt string
+
+	err := row.Scan(&id, &workspace.Name, &workspace.Version, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Updated_At, err = parseSQLiteTime(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// Selects the workspaces matching the condition along with their members.
+func (store *SQLiteStore) selectWorkspaces(ctx context.Context, querier sqliteQuerier, condition string, args ...any) ([]models.Workspace, error) {
+	rows, err := querier.QueryContext(ctx, `SELECT `+sqliteWorkspaceColumns+` FROM workspaces WHERE `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		workspace, err := scanSQLiteWorkspace(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		workspaces = append(workspaces, *workspace)
+	}
+
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range workspaces {
+		workspaces[i].Members, err = loadSQLiteWorkspaceMembers(ctx, querier, workspaces[i].ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return workspaces, nil
+}
+
+func (store *SQLiteStore) findWorkspace(ctx context.Context, querier sqliteQuerier, workspaceId string) (*models.Workspace, error) {
+	workspaces, err := store.selectWorkspaces(ctx, querier, `id = ?`, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workspaces) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &workspaces[0], nil
+}
+
+func loadSQLiteWorkspaceMembers(ctx context.Context, querier sqliteQuerier, workspaceId string) ([]models.WorkspaceMember, error) {
+	rows, err := querier.QueryContext(ctx, `SELECT user_id, email, role, joined_at FROM workspace_members WHERE workspace_id = ? ORDER BY position`, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMember{}
+	for rows.Next() {
+		var joinedAt string
+		var email sql.NullString
+		var member models.WorkspaceMember
+
+		err = rows.Scan(&member.User_Id, &email, &member.Role, &joinedAt)
+		if err == nil {
+			member.Joined_At, err = parseSQLiteTime(joinedAt)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		member.Email = stringPointer(email)
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+func insertSQLiteWorkspaceMembers(ctx context.Context, tx *sql.Tx, workspaceId string, members []models.WorkspaceMember) error {
+	for position, member := range members {
+		_, err := tx.ExecContext(ctx, `INSERT INTO workspace_members (workspace_id, position, user_id, email, role, joined_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			workspaceId, position, member.User_Id, nullString(member.Email), member.Role, formatSQLiteTime(member.Joined_At))
+		if err != nil {
+			return sqliteError(err)
+		}
+	}
+
+	return nil
+}
+
+func (store *SQLiteStore) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO workspaces (`+sqliteWorkspaceColumns+`) VALUES (?, ?, ?, ?, ?)`,
+			workspace.ID.Hex(), workspace.Name, workspace.Version, formatSQLiteTime(workspace.Created_At), formatSQLiteTime(workspace.Updated_At))
+		if err != nil {
+			return sqliteError(err)
+		}
+
+		return insertSQLiteWorkspaceMembers(ctx, tx, workspace.ID.Hex(), workspace.Members)
+	})
+}
+
+func (store *SQLiteStore) GetWorkspaceById(ctx context.Context, workspaceId string) (*models.Workspace, error) {
+	return store.findWorkspace(ctx, store.db, workspaceId)
+}
+
+func (store *SQLiteStore) ListWorkspaces(ctx context.Context, userId string) ([]models.Workspace, error) {
+	return store.selectWorkspaces(ctx, store.db, `EXISTS (SELECT 1 FROM workspace_members WHERE workspace_members.workspace_id = workspaces.id
+		AND workspace_members.user_id = ?) ORDER BY name, id`, userId)
+}
+
+// Applies the assignments to the workspace if it is still at the given version, increasing it, then runs the change
+// in the same transaction. Returns the workspace as it is afterwards.
+func (store *SQLiteStore) updateWorkspace(ctx context.Context, workspaceId string, version int64, assignments []string, args []any, change func(tx *sql.Tx) error) (*models.Workspace, error) {
+	var updatedWorkspace *models.Workspace
+
+	err := store.withTx(ctx, func(tx *sql.Tx) error {
+		assignments = append(assignments, `version = version + 1`)
+		args = append(args, workspaceId, version)
+
+		result, err := tx.ExecContext(ctx, `UPDATE workspaces SET `+strings.Join(assignments, ", ")+` WHERE id = ? AND version = ?`, args...)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			_, err = store.findWorkspace(ctx, tx, workspaceId)
+			if err == nil {
+				return ErrVersionConflict
+			}
+			return err
+		}
+
+		if change != nil {
+			err = change(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		updatedWorkspace, err = store.findWorkspace(ctx, tx, workspaceId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedWorkspace, nil
+}
+
+func (store *SQLiteStore) RenameWorkspace(ctx context.Context, workspaceId string, version int64, name string, updatedAt time.Time) (*models.Workspace, error) {
+	return store.updateWorkspace(ctx, workspaceId, version, []string{`name = ?`, `updated_at = ?`}, []any{name, formatSQLiteTime(updatedAt)}, nil)
+}
+
+func (store *SQLiteStore) SetWorkspaceMembers(ctx context.Context, workspaceId string, version int64, members []models.WorkspaceMember) (*models.Workspace, error) {
+	return store.updateWorkspace(ctx, workspaceId, version, []string{`updated_at = ?`}, []any{formatSQLiteTime(time.Now())}, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM workspace_members WHERE workspace_id = ?`, workspaceId)
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteWorkspaceMembers(ctx, tx, workspaceId, members)
+	})
+}
+
+func (store *SQLiteStore) DeleteWorkspace(ctx context.Context, workspaceId string, version int64) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// The notes are counted in the same transaction, so that none can be added before the workspace is gone.
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE workspace_id = ?`, workspaceId).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrNotEmpty
+		}
+
+		// The members and invitations go with the workspace through their foreign keys.
+		result, err := tx.ExecContext(ctx, `DELETE FROM workspaces WHERE id = ? AND version = ?`, workspaceId, version)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			_, err = store.findWorkspace(ctx, tx, workspaceId)
+			if err == nil {
+				return ErrVersionConflict
+			}
+			return err
+		}
+
+		return nil
+	})
+}
+
+const sqliteWorkspaceInvitationColumns = `id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at`
+
+func scanSQLiteWorkspaceInvitation(row interface{ Scan(...any) error }) (*models.WorkspaceInvitation, error) {
+	var invitation models.WorkspaceInvitation
+	var id, createdAt, expiresAt string
+
+	err := row.Scan(&id, &invitation.Workspace_Id, &invitation.Email, &invitation.Role, &invitation.Token_Hash, &invitation.Invited_By, &createdAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Expires_At, err = parseSQLiteTime(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (store *SQLiteStore) CreateWorkspaceInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the invitations which have expired, like the TTL index does in mongo, and the earlier one of the email.
+		_, err := tx.ExecContext(ctx, `DELETE FROM workspace_invitations WHERE expires_at < ? OR (workspace_id = ? AND email = ?)`,
+			formatSQLiteTime(time.Now()), invitation.Workspace_Id, invitation.Email)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO workspace_invitations (`+sqliteWorkspaceInvitationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			invitation.ID.Hex(), invitation.Workspace_Id, invitation.Email, invitation.Role, invitation.Token_Hash, invitation.Invited_By,
+			formatSQLiteTime(invitation.Created_At), formatSQLiteTime(invitation.Expires_At))
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) GetWorkspaceInvitationByHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteWorkspaceInvitationColumns+` FROM workspace_invitations WHERE token_hash = ? AND expires_at > ?`,
+		tokenHash, formatSQLiteTime(time.Now()))
+
+	invitation, err := scanSQLiteWorkspaceInvitation(row)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	return invitation, nil
+}
+
+func (store *SQLiteStore) ListWorkspaceInvitations(ctx context.Context, workspaceId string) ([]models.WorkspaceInvitation, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteWorkspaceInvitationColumns+` FROM workspace_invitations WHERE workspace_id = ? AND expires_at > ?
+		ORDER BY created_at DESC, id DESC`, workspaceId, formatSQLiteTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.WorkspaceInvitation{}
+	for rows.Next() {
+		invitation, err := scanSQLiteWorkspaceInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (store *SQLiteStore) DeleteWorkspaceInvitation(ctx context.Context, workspaceId string, invitationId string) error {
+	result, err := store.db.ExecContext(ctx, `DELETE FROM workspace_invitations WHERE id = ? AND workspace_id = ?`, invitationId, workspaceId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const sqliteShareLinkColumns = `id, note_id, role, token_hash, password_hash, max_views, views, created_by, created_at, expires_at, last_viewed_at`
+
+// Links without an expiry never expire.
+const sqliteLiveShareLinkCondition = `(expires_at IS NULL OR expires_at >= ?)`
+
+func scanSQLiteShareLink(row interface{ Scan(...any) error }) (*models.ShareLink, error) {
+	var link models.ShareLink
+	var id, createdAt string
+	var passwordHash, expiresAt, lastViewedAt sql.NullString
+	var maxViews sql.NullInt64
+
+	err := row.Scan(&id, &link.Note_Id, &link.Role, &link.Token_Hash, &passwordHash, &maxViews, &link.Views, &link.Created_By, &createdAt, &expiresAt, &lastViewedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	link.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if passwordHash.Valid {
+		link.Password_Hash = &passwordHash.String
+		link.Password_Protected = true
+	}
+
+	if maxViews.Valid {
+		link.Max_Views = &maxViews.Int64
+	}
+
+	link.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		parsedExpiresAt, err := parseSQLiteTime(expiresAt.String)
+		if err != nil {
+			return nil, err
+		}
+		link.Expires_At = &parsedExpiresAt
+	}
+
+	if lastViewedAt.Valid {
+		parsedLastViewedAt, err := parseSQLiteTime(lastViewedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		link.Last_Viewed_At = &parsedLastViewedAt
+	}
+
+	return &link, nil
+}
+
+func (store *SQLiteStore) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	return store.withTx(ctx, func(tx *sql.Tx) error {
+		// Drop the links which have expired, like the TTL index does in mongo.
+		_, err := tx.ExecContext(ctx, `DELETE FROM share_links WHERE expires_at < ?`, formatSQLiteTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		var passwordHash, expiresAt sql.NullString
+		if link.Password_Hash != nil {
+			passwordHash = sql.NullString{String: *link.Password_Hash, Valid: true}
+		}
+		if link.Expires_At != nil {
+			expiresAt = sql.NullString{String: formatSQLiteTime(*link.Expires_At), Valid: true}
+		}
+
+		var maxViews sql.NullInt64
+		if link.Max_Views != nil {
+			maxViews = sql.NullInt64{Int64: *link.Max_Views, Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO share_links (id, note_id, role, token_hash, password_hash, max_views, views, created_by, created_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			link.ID.Hex(), link.Note_Id, link.Role, link.Token_Hash, passwordHash, maxViews, link.Views, link.Created_By,
+			formatSQLiteTime(link.Created_At), expiresAt)
+		return sqliteError(err)
+	})
+}
+
+func (store *SQLiteStore) GetShareLinkByHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteShareLinkColumns+` FROM share_links WHERE token_hash = ? AND `+sqliteLiveShareLinkCondition,
+		tokenHash, formatSQLiteTime(time.Now()))
+
+	link, err := scanSQLiteShareLink(row)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	return link, nil
+}
+
+func (store *SQLiteStore) ListShareLinks(ctx context.Context, noteId string) ([]models.ShareLink, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteShareLinkColumns+` FROM share_links WHERE note_id = ? AND `+sqliteLiveShareLinkCondition+`
+		ORDER BY id DESC`, noteId, formatSQLiteTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		link, err := scanSQLiteShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+func (store *SQLiteStore) RecordShareLinkView(ctx context.Context, linkId string, viewedAt time.Time) (*models.ShareLink, error) {
+	// Checking the views left and counting the view in one statement keeps concurrent views within the limit.
+	row := store.db.QueryRowContext(ctx, `UPDATE share_links SET views = views + 1, last_viewed_at = ?
+		WHERE id = ? AND `+sqliteLiveShareLinkCondition+` AND (max_views IS NULL OR views < max_views)
+		RETURNING `+sqliteShareLinkColumns, formatSQLiteTime(viewedAt), linkId, formatSQLiteTime(viewedAt))
+
+	link, err := scanSQLiteShareLink(row)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	return link, nil
+}
+
+// The links of a note deleted for good are already gone through their foreign key, this covers a note still kept.
+func (store *SQLiteStore) DeleteNoteShareLinks(ctx context.Context, noteId string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM share_links WHERE note_id = ?`, noteId)
+	return err
+}
+
+func (store *SQLiteStore) DeleteShareLink(ctx context.Context, noteId string, linkId string) error {
+	result, err := store.db.ExecContext(ctx, `DELETE FROM share_links WHERE id = ? AND note_id = ?`, linkId, noteId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+const sqliteNoteRevisionColumns = `id, note_id, revision, author_id, header, notes_data, content_hash, restored_from, created_at`
+
+func scanSQLiteNoteRevision(row interface{ Scan(...any) error }) (*models.NoteRevision, error) {
+	var revision models.NoteRevision
+	var id, createdAt string
+	var header, data sql.NullString
+	var restoredFrom sql.NullInt64
+
+	err := row.Scan(&id, &revision.Note_Id, &revision.Revision, &revision.Author_Id, &header, &data, &revision.Content_Hash, &restoredFrom, &createdAt)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+
+	revision.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Header = stringPointer(header)
+	revision.Data = stringPointer(data)
+
+	if restoredFrom.Valid {
+		revision.Restored_From = &restoredFrom.Int64
+	}
+
+	revision.Created_At, err = parseSQLiteTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+func (store *SQLiteStore) CreateNoteRevision(ctx context.Context, revision *models.NoteRevision) error {
+	var restoredFrom sql.NullInt64
+	if revision.Restored_From != nil {
+		restoredFrom = sql.NullInt64{Int64: *revision.Restored_From, Valid: true}
+	}
+
+	_, err := store.db.ExecContext(ctx, `INSERT INTO note_revisions (`+sqliteNoteRevisionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		revision.ID.Hex(), revision.Note_Id, revision.Revision, revision.Author_Id, nullString(revision.Header), nullString(revision.Data),
+		revision.Content_Hash, restoredFrom, formatSQLiteTime(revision.Created_At))
+	return sqliteError(err)
+}
+
+func (store *SQLiteStore) GetLatestNoteRevisionNumber(ctx context.Context, noteId string) (int64, error) {
+	var latestRevision int64
+	err := store.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM note_revisions WHERE note_id = ?`, noteId).Scan(&latestRevision)
+	if err != nil {
+		return 0, err
+	}
+
+	return latestRevision, nil
+}
+
+func (store *SQLiteStore) GetNoteRevision(ctx context.Context, noteId string, revision int64) (*models.NoteRevision, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT `+sqliteNoteRevisionColumns+` FROM note_revisions WHERE note_id = ? AND revision = ?`, noteId, revision)
+	return scanSQLiteNoteRevision(row)
+}
+
+func (store *SQLiteStore) ListNoteRevisions(ctx context.Context, noteId string) ([]models.NoteRevision, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT id, note_id, revision, author_id, header, NULL, content_hash, restored_from, created_at
+		FROM note_revisions WHERE note_id = ? ORDER BY revision`, noteId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foundRevisions := []models.NoteRevision{}
+
+	for rows.Next() {
+		foundRevision, err := scanSQLiteNoteRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		foundRevisions = append(foundRevisions, *foundRevision)
+	}
+
+	return foundRevisions, rows.Err()
+}
+
+func (store *SQLiteStore) DeleteNoteRevisions(ctx context.Context, noteId string) error {
+	_, err := store.db.ExecContext(ctx, `DELETE FROM note_revisions WHERE note_id = ?`, noteId)
+	return err
+}
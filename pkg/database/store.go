@@ -0,0 +1,461 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+)
+
+// Errors returned by every store implementation, so that callers do not depend on the backend.
+var (
+	ErrNotFound        = errors.New("no such document present")
+	ErrDuplicate       = errors.New("document already exists")
+	ErrVersionConflict = errors.New("document has been modified concurrently")
+	ErrNotEmpty        = errors.New("document still holds other documents")
+)
+
+// Names of the storage backends which can be selected at startup.
+const (
+	MongoBackend  = config.MongoBackend
+	MemoryBackend = config.MemoryBackend
+	SQLiteBackend = config.SQLiteBackend
+)
+
+type UserStore interface {
+	// Returns ErrDuplicate if a user with the same email exists.
+	CreateUser(ctx context.Context, user *models.UserDataServer) error
+	GetUserByEmail(ctx context.Context, email string) (*models.UserDataServer, error)
+	GetUserById(ctx context.Context, userId string) (*models.UserDataServer, error)
+	UpdateLastLogin(ctx context.Context, userId string, lastLogin time.Time) error
+	UpdatePassword(ctx context.Context, userId string, password string, updatedAt time.Time) error
+	// Replaces the verification token of the unverified user, unless one was sent after sentBefore.
+	// Reports whether the token was replaced.
+	SetVerificationToken(ctx context.Context, userId string, tokenHash string, sentAt time.Time, expiresAt time.Time, sentBefore time.Time) (bool, error)
+	// Verifies the email of the user with the unexpired verification token, which cannot be used again.
+	// Returns ErrNotFound if no unverified user has the token.
+	VerifyEmail(ctx context.Context, tokenHash string, verifiedAt time.Time) (*models.UserDataServer, error)
+	// Deletes every session of the user and revokes every token issued up to the given time.
+	RevokeAllTokens(ctx context.Context, userId string, revokedBefore time.Time) error
+	// Lists a page of the users matching the query, in the order of their ids.
+	ListUsers(ctx context.Context, query *UserListQuery) ([]models.UserDataServer, error)
+	// Returns ErrNotFound if there is no such user.
+	SetUserRole(ctx context.Context, userId string, role string, updatedAt time.Time) error
+	// Returns ErrNotFound if there is no such user.
+	SetUserDisabled(ctx context.Context, userId string, disabled bool, updatedAt time.Time) error
+}
+
+// Users listed for the admins. Search matches part of the email or the names whatever the case,
+// and empty fields match every user.
+type UserListQuery struct {
+	Search string
+	Role   string
+	After  string // user id the page starts after
+	Limit  int
+}
+
+// Sessions are found by their id only till they expire, the database may keep expired ones for a while.
+type SessionStore interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionById(ctx context.Context, sessionId string) (*models.Session, error)
+	// Lists the sessions of the user which have not expired, the last used first.
+	ListSessions(ctx context.Context, userId string) ([]models.Session, error)
+	// Replaces the refresh token hash of the session only if it still equals the current one, and records the use.
+	// Reports whether it did.
+	RotateSessionToken(ctx context.Context, sessionId string, currentTokenHash string, newTokenHash string, ipAddress string, lastUsedAt time.Time, expiresAt time.Time) (bool, error)
+	// Returns ErrNotFound if there is no such session.
+	DeleteSession(ctx context.Context, sessionId string) error
+}
+
+type PasswordResetStore interface {
+	CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error
+	// Finds the reset with the token hash which has not expired and deletes it, so that its token is used only once.
+	// Returns ErrNotFound if there is none.
+	TakePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	// Deletes every reset of the user, once the password has changed.
+	DeletePasswordResets(ctx context.Context, userId string) error
+}
+
+// TOTP second factor of the users, and their recovery codes.
+type MFAStore interface {
+	// Sets the secret of a pending TOTP enrollment of the user, unless TOTP is enabled already.
+	// Reports whether it was set.
+	SetTOTPSecret(ctx context.Context, userId string, secret string) (bool, error)
+	// Enables TOTP with the pending secret, recording the time step of the code confirming it.
+	// Reports whether it was enabled, it is not if it was enabled already or there is no pending secret.
+	EnableTOTP(ctx context.Context, userId string, step int64) (bool, error)
+	// Disables TOTP, removing the secret and every recovery code of the user.
+	DisableTOTP(ctx context.Context, userId string) error
+	// Records the time step of a TOTP code of the user, only if it is later than the one recorded last,
+	// so that every code is used once. Reports whether it was recorded.
+	UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error)
+	// Replaces every recovery code of the user.
+	SetRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error
+	// Removes the recovery code of the user, so that it is used once. Reports whether the user had it.
+	UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userId string) (int, error)
+}
+
+// Personal access tokens of the users.
+type AccessTokenStore interface {
+	CreateAccessToken(ctx context.Context, accessToken *models.AccessToken) error
+	// Finds the token with the hash which has not expired. Returns ErrNotFound if there is none.
+	GetAccessTokenByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error)
+	// Lists the tokens of the user which have not expired, newest first.
+	ListAccessTokens(ctx context.Context, userId string) ([]models.AccessToken, error)
+	SetAccessTokenLastUsed(ctx context.Context, tokenId string, lastUsedAt time.Time) error
+	// Returns ErrNotFound if the user has no such token.
+	DeleteAccessToken(ctx context.Context, userId string, tokenId string) error
+	// Deletes every token of the user, when all of their tokens are revoked.
+	DeleteAccessTokens(ctx context.Context, userId string) error
+}
+
+// Failed logins per email and per ip address, and the lockouts they caused.
+type LoginAttemptStore interface {
+	// Counts a failed login under the key and returns the number of failures, starting again from one
+	// if the last failure was before forgetBefore.
+	RecordLoginFailure(ctx context.Context, key string, failedAt time.Time, forgetBefore time.Time, expiresAt time.Time) (int, error)
+	// Returns ErrNotFound if no failure is counted under the key.
+	GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error)
+	ClearLoginFailures(ctx context.Context, key string) error
+	CreateLockout(ctx context.Context, lockout *models.Lockout) error
+	// Lists the lockouts newest first.
+	ListLockouts(ctx context.Context, limit int) ([]models.Lockout, error)
+}
+
+type RevokedTokenStore interface {
+	// Revoking an already revoked token is not an error.
+	RevokeToken(ctx context.Context, revokedToken *models.RevokedToken) error
+	// Puts the token on the deny-list in one step unless it is on it already, reporting whether this call put it there,
+	// so that of concurrent calls with the same token only one gets true.
+	ConsumeToken(ctx context.Context, revokedToken *models.RevokedToken) (bool, error)
+	IsTokenRevoked(ctx context.Context, tokenId string) (bool, error)
+}
+
+// Fields of a note changed by an update, nil fields are left as they are.
+type NoteUpdate struct {
+	Header        *string
+	Unique_Header *string
+	Data          *string
+	Sharable      *bool
+	Notebook_Id   *string // empty to take the note out of its notebook
+	Tags          *[]string
+	Updated_At    time.Time
+}
+
+// Conditions narrowing down a listing or a search of notes.
+type NoteFilter struct {
+	Tags         []string // notes with every one of the tags
+	Notebook_Ids []string // notes in any of the notebooks, the one asked for first, nil for notes in any notebook or none
+	Workspace_Id string   // every note of the workspace in place of the notes of the user, empty for the notes of the user
+}
+
+// Key which keeps the headers of the notes of a user unique within a notebook, or among the notes outside of notebooks.
+// Notes outside of notebooks keep the key they had before notebooks existed.
+func UniqueHeader(userId string, notebookId *string, header string) string {
+	if notebookId == nil || *notebookId == "" {
+		return userId + header
+	}
+
+	return userId + "/" + *notebookId + "/" + header
+}
+
+// Owner the headers of the note are kept unique for: its workspace, or the user outside of workspaces.
+func HeaderOwner(note *models.NoteData) string {
+	if note.Workspace_Id != nil {
+		return "workspace/" + *note.Workspace_Id
+	}
+
+	return stringValue(note.User_Id)
+}
+
+// Key which takes the place of the unique header of a note while it is in the trash,
+// so that a new note can have its header meanwhile.
+func TrashedUniqueHeader(noteId string) string {
+	return "trash/" + noteId
+}
+
+// Whether the note is in one of the notebooks of the filter, or the filter has none.
+func isInNotebooks(note *models.NoteData, notebookIds []string) bool {
+	return notebookIds == nil || (note.Notebook_Id != nil && slices.Contains(notebookIds, *note.Notebook_Id))
+}
+
+// Whether the tags of a note include every tag of the filter.
+func hasAllTags(noteTags []string, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(noteTags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Whether the tags of a note include any of the tags.
+func hasAnyTag(noteTags []string, tags []string) bool {
+	return slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(noteTags, tag) })
+}
+
+// Tags of a note with the old tags replaced by the new one, sorted and without duplicates.
+func replaceTags(noteTags []string, oldTags []string, newTag string) []string {
+	replacedTags := []string{newTag}
+
+	for _, tag := range noteTags {
+		if !slices.Contains(oldTags, tag) && tag != newTag {
+			replacedTags = append(replacedTags, tag)
+		}
+	}
+
+	slices.Sort(replacedTags)
+	return replacedTags
+}
+
+// Which of the notes viewable by a user a listing covers. Notes in workspaces are only listed through their workspace,
+// unless they are shared with the user or sharable.
+const (
+	AllNotesScope    = ""       // owned, shared with the user or sharable
+	OwnedNotesScope  = "owned"  // owned by the user, outside of workspaces
+	SharedNotesScope = "shared" // shared with the user by their owner
+	PublicNotesScope = "public" // sharable, whoever owns them
+)
+
+// Fields notes can be listed by, named like in the documents.
+const (
+	SortByCreatedAt = "createdAt"
+	SortByUpdatedAt = "updatedAt"
+	SortByHeader    = "header"
+)
+
+// Position of a note in a listing, the note id breaks ties of the sort field.
+type NoteCursor struct {
+	Time   time.Time `json:"t,omitempty"`
+	Header string    `json:"h,omitempty"`
+	Id     string    `json:"id"`
+}
+
+// Cursor pointing at the note in a listing sorted by the given field.
+func NoteCursorOf(note *models.NoteData, sortBy string) *NoteCursor {
+	cursor := &NoteCursor{Id: note.ID.Hex()}
+
+	switch sortBy {
+	case SortByUpdatedAt:
+		cursor.Time = note.Updated_At
+	case SortByHeader:
+		if note.Header != nil {
+			cursor.Header = *note.Header
+		}
+	default:
+		cursor.Time = note.Created_At
+	}
+
+	return cursor
+}
+
+type NoteListQuery struct {
+	User_Id    string
+	Scope      string
+	Sort_By    string
+	Descending bool
+	After      *NoteCursor // the page starts after this note, nil for the first page
+	Limit      int
+	Fields     []string // document fields the caller needs, nil for all of them
+	Filter     NoteFilter
+}
+
+// Notes in the trash are only found through the trash store, and only its changes, deleting
+// and the notebook deletes apply to them.
+type NoteStore interface {
+	// Returns ErrDuplicate if a note with the same unique header exists.
+	CreateNote(ctx context.Context, note *models.NoteData) error
+	GetNoteById(ctx context.Context, noteId string) (*models.NoteData, error)
+	GetNoteByUniqueHeader(ctx context.Context, uniqueHeader string) (*models.NoteData, error)
+	// Lists a page of the notes of every user in the order of their ids, starting after the given id,
+	// for rebuilding what is derived from the notes, like a search index.
+	ListAllNotes(ctx context.Context, afterId string, limit int) ([]models.NoteData, error)
+	// Lists a page of the notes in the scope of the query, in the order of the query.
+	ListNotes(ctx context.Context, query *NoteListQuery) ([]models.NoteData, error)
+	// Finds the notes viewable by the user whose header or notes data match the query, in no particular order.
+	SearchNotes(ctx context.Context, userId string, query *search.Query, filter *NoteFilter) ([]models.NoteData, error)
+	// Counts the notes owned by the user outside of workspaces per tag, the most used tags first.
+	ListTags(ctx context.Context, userId string) ([]models.TagCount, error)
+	// Replaces the old tags by the new one on every note owned by the user outside of workspaces, which renames a tag
+	// or merges tags into one, and returns the changed notes.
+	ReplaceTags(ctx context.Context, userId string, oldTags []string, newTag string) ([]models.NoteData, error)
+	// The changes below only apply to the note while it is at the given version, and increase it.
+	// They return ErrVersionConflict otherwise.
+	// Returns ErrDuplicate if another note has the new unique header.
+	UpdateNote(ctx context.Context, noteId string, version int64, update *NoteUpdate) (*models.NoteData, error)
+	SetNoteAccess(ctx context.Context, noteId string, version int64, accessList []models.NoteAccess) (*models.NoteData, error)
+	DeleteNote(ctx context.Context, noteId string, version int64) error
+}
+
+type TrashStore interface {
+	// Moves the note to the trash, in place of its unique header it gets the trashed one.
+	// Like the changes above it only applies to the note at the given version.
+	TrashNote(ctx context.Context, noteId string, version int64, deletedAt time.Time) (*models.NoteData, error)
+	// Takes the note at the given version out of the trash with the unique header, into the notebook or outside of notebooks if nil.
+	// Returns ErrDuplicate if another note has the unique header.
+	RestoreNote(ctx context.Context, noteId string, version int64, uniqueHeader string, notebookId *string) (*models.NoteData, error)
+	// Returns ErrNotFound if the note is not in the trash.
+	GetTrashedNote(ctx context.Context, noteId string) (*models.NoteData, error)
+	// Lists the notes of the user outside of workspaces in the trash, the last deleted first.
+	ListTrash(ctx context.Context, userId string) ([]models.NoteData, error)
+	// Lists the notes of the workspace in the trash, the last deleted first.
+	ListWorkspaceTrash(ctx context.Context, workspaceId string) ([]models.NoteData, error)
+	// Deletes the notes moved to the trash before the given time along with their revisions, and returns how many.
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// Fields of a notebook changed by an update, nil fields are left as they are.
+type NotebookUpdate struct {
+	Name       *string
+	Parent_Id  *string // empty to move the notebook to the top
+	Updated_At time.Time
+}
+
+// Orders notebooks by their depth, then by their name, which puts every notebook after the one holding it.
+func sortNotebooks(notebooks []models.Notebook) {
+	slices.SortStableFunc(notebooks, func(a models.Notebook, b models.Notebook) int {
+		if len(a.Ancestors) != len(b.Ancestors) {
+			return len(a.Ancestors) - len(b.Ancestors)
+		}
+		if a.Name != b.Name {
+			return strings.Compare(a.Name, b.Name)
+		}
+
+		return strings.Compare(a.ID.Hex(), b.ID.Hex())
+	})
+}
+
+// Ancestors of a notebook below the moved notebook, once the moved notebook has the new ancestors.
+func movedAncestors(ancestors []string, movedId string, newAncestors []string) []string {
+	return append(slices.Clone(newAncestors), ancestors[slices.Index(ancestors, movedId):]...)
+}
+
+// Ancestors of a notebook below the deleted notebook, once what the deleted notebook held moved up into its parent.
+func reparentedAncestors(ancestors []string, deletedId string) []string {
+	return slices.DeleteFunc(slices.Clone(ancestors), func(id string) bool { return id == deletedId })
+}
+
+type NotebookStore interface {
+	// Returns ErrDuplicate if the parent already holds a notebook with the same name.
+	CreateNotebook(ctx context.Context, notebook *models.Notebook) error
+	GetNotebookById(ctx context.Context, notebookId string) (*models.Notebook, error)
+	// Lists every notebook of the user, the ones at the top first and every notebook before the ones it holds.
+	ListNotebooks(ctx context.Context, userId string) ([]models.Notebook, error)
+	// Renames or moves the notebook, with everything it holds.
+	// Returns ErrDuplicate if the new parent already holds a notebook with the same name.
+	UpdateNotebook(ctx context.Context, notebookId string, update *NotebookUpdate) (*models.Notebook, error)
+	// Deletes the notebook. With cascade the notebooks below it go too and all their notes move to the trash,
+	// outside of notebooks, otherwise what it holds moves up into its parent. Returns the trashed or moved notes.
+	// Returns ErrDuplicate if something moving up has the name or the header of something already in the parent.
+	DeleteNotebook(ctx context.Context, notebookId string, cascade bool) ([]models.NoteData, error)
+}
+
+// Workspaces and the invitations into them.
+type WorkspaceStore interface {
+	CreateWorkspace(ctx context.Context, workspace *models.Workspace) error
+	GetWorkspaceById(ctx context.Context, workspaceId string) (*models.Workspace, error)
+	// Lists the workspaces the user is a member of, by name.
+	ListWorkspaces(ctx context.Context, userId string) ([]models.Workspace, error)
+	// The changes below only apply to the workspace while it is at the given version, and increase it.
+	// They return ErrVersionConflict otherwise.
+	RenameWorkspace(ctx context.Context, workspaceId string, version int64, name string, updatedAt time.Time) (*models.Workspace, error)
+	SetWorkspaceMembers(ctx context.Context, workspaceId string, version int64, members []models.WorkspaceMember) (*models.Workspace, error)
+	// Deletes the workspace along with its invitations.
+	// Returns ErrNotEmpty if the workspace holds notes, live ones or ones in the trash.
+	DeleteWorkspace(ctx context.Context, workspaceId string, version int64) error
+	// Replaces any earlier invitation of the email into the workspace.
+	CreateWorkspaceInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error
+	// Finds the invitation with the token hash which has not expired. Returns ErrNotFound if there is none.
+	GetWorkspaceInvitationByHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error)
+	// Lists the invitations into the workspace which have not expired, newest first.
+	ListWorkspaceInvitations(ctx context.Context, workspaceId string) ([]models.WorkspaceInvitation, error)
+	// Returns ErrNotFound if the workspace has no such invitation.
+	DeleteWorkspaceInvitation(ctx context.Context, workspaceId string, invitationId string) error
+}
+
+// Links opening notes to anyone holding them. Deleting notes for good from the trash deletes their links
+// along with their revisions.
+type ShareLinkStore interface {
+	CreateShareLink(ctx context.Context, link *models.ShareLink) error
+	// Finds the link with the token hash which has not expired. Returns ErrNotFound if there is none.
+	GetShareLinkByHash(ctx context.Context, tokenHash string) (*models.ShareLink, error)
+	// Lists the links of the note which have not expired, newest first.
+	ListShareLinks(ctx context.Context, noteId string) ([]models.ShareLink, error)
+	// Counts a view of the link, returning it with the view counted.
+	// Returns ErrNotFound if the link has expired or has been opened as many times as it may be.
+	RecordShareLinkView(ctx context.Context, linkId string, viewedAt time.Time) (*models.ShareLink, error)
+	// Returns ErrNotFound if the note has no such link.
+	DeleteShareLink(ctx context.Context, noteId string, linkId string) error
+	DeleteNoteShareLinks(ctx context.Context, noteId string) error
+}
+
+type NoteRevisionStore interface {
+	// Returns ErrDuplicate if the note already has a revision with the same number.
+	CreateNoteRevision(ctx context.Context, revision *models.NoteRevision) error
+	GetLatestNoteRevisionNumber(ctx context.Context, noteId string) (int64, error)
+	GetNoteRevision(ctx context.Context, noteId string, revision int64) (*models.NoteRevision, error)
+	// Lists the revisions oldest first, without their notes data.
+	ListNoteRevisions(ctx context.Context, noteId string) ([]models.NoteRevision, error)
+	DeleteNoteRevisions(ctx context.Context, noteId string) error
+}
+
+// Everything the application keeps, behind one interface per backend.
+type Store interface {
+	UserStore
+	SessionStore
+	PasswordResetStore
+	MFAStore
+	AccessTokenStore
+	LoginAttemptStore
+	RevokedTokenStore
+	NoteStore
+	TrashStore
+	NotebookStore
+	WorkspaceStore
+	ShareLinkStore
+	NoteRevisionStore
+	Close(ctx context.Context) error
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+// Store selected at startup, used by the controllers, helpers and middleware.
+var StoreObject Store
+
+// Opens the store of the configured backend and makes it the one in use.
+func OpenStore(cfg *config.Config) (Store, error) {
+	var store Store
+	var err error
+
+	switch cfg.Storage_Backend {
+	case MongoBackend:
+		store, err = NewMongoStore(cfg.Mongo)
+	case MemoryBackend:
+		store = NewMemoryStore()
+	case SQLiteBackend:
+		store, err = NewSQLiteStore(cfg.SQLite.Path)
+	default:
+		err = fmt.Errorf("unknown storage backend: %s", cfg.Storage_Backend)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	StoreObject = store
+	return store, nil
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Personal access tokens start with the prefix, telling them apart from the signed tokens of the sessions.
+const AccessTokenPrefix = "pat_"
+
+const MaxAccessTokenNameLength = 100
+
+// Using a token records when it was last used, at most this often.
+const accessTokenLastUsedInterval = time.Minute
+
+// Returned for a personal access token which does not exist, has expired or was deleted.
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// Checks the name, scopes and expiry asked for a new token, leaving the scopes without repeats.
+func ValidateAccessTokenRequest(request *models.CreateAccessTokenRequest) error {
+	request.Name = strings.TrimSpace(request.Name)
+	name := request.Name
+	if name == "" || len(name) > MaxAccessTokenNameLength {
+		return fmt.Errorf("name must have from 1 to %d characters", MaxAccessTokenNameLength)
+	}
+
+	if len(request.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required, of: %s", strings.Join(models.AccessTokenScopes, ", "))
+	}
+
+	scopes := []string{}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(models.AccessTokenScopes, scope) {
+			return fmt.Errorf("unknown scope: %s, scopes are: %s", scope, strings.Join(models.AccessTokenScopes, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	request.Scopes = scopes
+
+	if request.Expires_At != nil && !request.Expires_At.After(time.Now()) {
+		return errors.New("expiry must be in the future")
+	}
+
+	return nil
+}
+
+// Creates a personal access token of the user from a validated request, returning the token itself,
+// which is not kept and shown only once.
+func CreateAccessToken(ctx context.Context, userId string, request *models.CreateAccessTokenRequest) (string, *models.AccessToken, error) {
+	secret, err := GenerateRandomId()
+	if err != nil {
+		return "", nil, err
+	}
+	token := AccessTokenPrefix + secret
+
+	accessToken := models.AccessToken{
+		ID:         primitive.NewObjectID(),
+		User_Id:    userId,
+		Name:       request.Name,
+		Token_Hash: HashToken(token),
+		Scopes:     request.Scopes,
+		Created_At: time.Now(),
+		Expires_At: request.Expires_At,
+	}
+
+	err = database.StoreObject.CreateAccessToken(ctx, &accessToken)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the access token of user id: %s.\n\tError: %s", userId, err.Error())
+		return "", nil, err
+	}
+
+	return token, &accessToken, nil
+}
+
+// Finds the personal access token and the user it belongs to, recording that it has been used.
+func ValidateAccessToken(ctx context.Context, token string) (*models.AccessToken, *models.UserDataServer, error) {
+	accessToken, err := database.StoreObject.GetAccessTokenByHash(ctx, HashToken(token))
+	if err == database.ErrNotFound {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foundUser, err := database.StoreObject.GetUserById(ctx, accessToken.User_Id)
+	if err == nil && foundUser.Disabled {
+		err = database.ErrNotFound
+	}
+	if err == database.ErrNotFound {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Recording every use would write on every request, once in a while is enough to tell unused tokens.
+	now := time.Now()
+	if accessToken.Last_Used_At == nil || now.Sub(*accessToken.Last_Used_At) >= accessTokenLastUsedInterval {
+		err = database.StoreObject.SetAccessTokenLastUsed(ctx, accessToken.ID.Hex(), now)
+		if err != nil {
+			logger.Log.Printf("Error: Problem while recording the use of access token id: %s.\n\tError: %s", accessToken.ID.Hex(), err.Error())
+		}
+	}
+
+	return accessToken, foundUser, nil
+}
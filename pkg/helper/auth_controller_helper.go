@@ -1,120 +1,123 @@
-package helper
-
-import (
-	"context"
-	"os"
-	"time"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"golang.org/x/crypto/bcrypt"
-)
-
-func HashPassword(password *string) string {
-	bytesHashPassword, err := bcrypt.GenerateFromPassword([]byte(*password), 14)
-	if err != nil {
-		logger.Log.Printf("Error: Probelem while hashing password.\n\tError: %s", err.Error())
-	}
-
-	return string(bytesHashPassword)
-}
-
-func GenerateAllToken(email string, firstName string, lastName string, userId string) (string, string, error) {
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		logger.Log.Printf("Error: Problem while loading environment variables.")
-		return "", "", err
-	}
-	secretKey := os.Getenv("SECRET_KEY")
-
-	refreshClaims := &models.SignedDetails{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Duration(168) * time.Hour).Unix(),
-		},
-	}
-
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(secretKey))
-	if err != nil {
-		logger.Log.Printf("Error: Problem while creating refresh token for the user.")
-		return "", "", err
-	}
-
-	claims := &models.SignedDetails{
-		Email:         email,
-		First_Name:    firstName,
-		Last_Name:     lastName,
-		User_Id:       userId,
-		Refresh_Token: refreshToken,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Duration(24) * time.Hour).Unix(),
-		},
-	}
-
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
-	if err != nil {
-		logger.Log.Printf("Error: Problem while creating token for the user.")
-		return "", "", err
-	}
-
-	return token, refreshToken, nil
-}
-
-func VerifyPassword(userPassword string, foundUserPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(foundUserPassword), []byte(userPassword))
-	if err != nil {
-		logger.Log.Printf("Error: Problem while comparing passwords.")
-		return false, err
-	}
-
-	return true, nil
-}
-
-func UpdateLastLoginAndRefreshToken(userId string, lastLogin time.Time, refreshToken string) error {
-	// Get the access to the user collection.
-	userCollection, err := database.MongoObject.GetUserCollection()
-	if err != nil {
-		logger.Log.Println("Error: Problem with opening the user collection.")
-		return err
-	}
-
-	// Create and fill the update object.
-	var updateMiniObj primitive.D
-
-	updateMiniObj = append(updateMiniObj, bson.E{Key: "lastLogin", Value: lastLogin})
-	updateMiniObj = append(updateMiniObj, bson.E{Key: "refreshToken", Value: refreshToken})
-	updateMiniObj = append(updateMiniObj, bson.E{Key: "firstName", Value: "Lulla"})
-
-	updateObj := primitive.D{
-		{
-			Key: "$set", Value: updateMiniObj,
-		},
-	}
-
-	objectID, err := primitive.ObjectIDFromHex(userId)
-	if err != nil {
-		logger.Log.Printf("Error: Problem while converting user id to primitve object.\n\tError: %s", err.Error())
-		return err
-	}
-
-	filter := bson.D{{Key: "userId", Value: objectID}}
-
-	options := options.Update().SetUpsert(false)
-
-	// Create an update context.
-	ctxUpdate, cancel := context.WithTimeout(database.MongoObject.Ctx, time.Second*10)
-	defer cancel()
-
-	_, err = userCollection.UpdateOne(ctxUpdate, filter, updateObj, options)
-	if err != nil {
-		logger.Log.Println("Error: Problem while trying to update the field in the database.")
-		return err
-	}
-
-	return nil
-}
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Secret, signing keys and lifetimes used for the tokens, set once at startup.
+var authConfig = config.Default().Auth
+
+func SetAuthConfig(auth config.AuthConfig) {
+	authConfig = auth
+}
+
+// How long a refresh token, and a session left unused, lasts.
+func RefreshTokenLifetime() time.Duration {
+	return authConfig.Refresh_Token_Lifetime
+}
+
+func HashPassword(password *string) string {
+	bytesHashPassword, err := bcrypt.GenerateFromPassword([]byte(*password), 14)
+	if err != nil {
+		logger.Log.Printf("Error: Probelem while hashing password.\n\tError: %s", err.Error())
+	}
+
+	return string(bytesHashPassword)
+}
+
+// Generates a random identifier, used for token ids.
+func GenerateRandomId() (string, error) {
+	bytesId := make([]byte, 16)
+
+	_, err := rand.Read(bytesId)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while generating random id.\n\tError: %s", err.Error())
+		return "", err
+	}
+
+	return hex.EncodeToString(bytesId), nil
+}
+
+// Claims every token is issued with, valid from now on for the lifetime.
+func registeredClaims(userId string, tokenId string, lifetime time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+
+	return jwt.RegisteredClaims{
+		Issuer:    authConfig.Issuer,
+		Subject:   userId,
+		Audience:  jwt.ClaimStrings{authConfig.Audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        tokenId,
+	}
+}
+
+func GenerateAllToken(email string, firstName string, lastName string, userId string, role string, tokenFamily string) (string, string, error) {
+	// Every refresh token gets its own id, so that a rotated token never equals the one it replaced.
+	refreshTokenId, err := GenerateRandomId()
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating refresh token id for the user.")
+		return "", "", err
+	}
+
+	refreshClaims := &models.SignedDetails{
+		User_Id:          userId,
+		Token_Type:       models.RefreshTokenType,
+		Token_Family:     tokenFamily,
+		RegisteredClaims: registeredClaims(userId, refreshTokenId, authConfig.Refresh_Token_Lifetime),
+	}
+
+	refreshToken, err := signToken(refreshClaims)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating refresh token for the user.")
+		return "", "", err
+	}
+
+	// The access token id is what gets put on the deny-list on logout.
+	tokenId, err := GenerateRandomId()
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating token id for the user.")
+		return "", "", err
+	}
+
+	claims := &models.SignedDetails{
+		Email:            email,
+		First_Name:       firstName,
+		Last_Name:        lastName,
+		User_Id:          userId,
+		Token_Type:       models.AccessTokenType,
+		Token_Family:     tokenFamily,
+		Role:             role,
+		RegisteredClaims: registeredClaims(userId, tokenId, authConfig.Access_Token_Lifetime),
+	}
+
+	token, err := signToken(claims)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while creating token for the user.")
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+func VerifyPassword(userPassword string, foundUserPassword string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(foundUserPassword), []byte(userPassword))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		logger.Log.Printf("Error: Problem while comparing passwords.")
+		return false, err
+	}
+
+	return true, nil
+}
@@ -1,52 +1,87 @@
-package helper
-
-import (
-	"fmt"
-	"os"
-	"time"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/joho/godotenv"
-)
-
-func ValidateToken(clientToken string) (*models.SignedDetails, error) {
-	// Parse the token with claims.
-	err := godotenv.Load("C:\\Users\\User\\Desktop\\GoLang\\Project\\.env")
-	if err != nil {
-		logger.Log.Printf("Error: Problem while loading environment variables.")
-		return nil, err
-	}
-	secretKey := os.Getenv("SECRET_KEY")
-
-	// Parse the token.
-	token, err := jwt.ParseWithClaims(clientToken, &models.SignedDetails{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-	if err != nil {
-		logger.Log.Printf("Error: Problem while parsing token.")
-		return nil, err
-	}
-
-	// Check token validation --> if not valid return error.
-	if !token.Valid {
-		logger.Log.Printf("Error: Invalid token.")
-		return nil, fmt.Errorf("invalid token is passed")
-	}
-
-	// Extract the claims.
-	claims, ok := token.Claims.(*models.SignedDetails)
-	if !ok {
-		logger.Log.Printf("Error: Problem while extracting claims from the token.")
-		return nil, fmt.Errorf("problem while trying to extract claims from the token")
-	}
-
-	// If token has expired, send error.
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		logger.Log.Printf("Error: Passed user token has expired.")
-		return nil, fmt.Errorf("token has expired")
-	}
-
-	return claims, nil
-}
+package helper
+
+import (
+	"fmt"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func parseToken(clientToken string) (*models.SignedDetails, error) {
+	// Parse the token, verifying it with the key named in it, only with the algorithms of the configured keys,
+	// and checking its issuer, audience and times.
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(validMethods()),
+		jwt.WithIssuer(authConfig.Issuer),
+		jwt.WithAudience(authConfig.Audience),
+		jwt.WithLeeway(authConfig.Leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims := &models.SignedDetails{}
+
+	_, err := parser.ParseWithClaims(clientToken, claims, verificationKey)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while parsing token.\n\tError: %s", err.Error())
+		return nil, err
+	}
+
+	// The id is what the token is revoked by, and the issue time what revoking all tokens of the user compares.
+	if claims.ID == "" || claims.IssuedAt == nil {
+		logger.Log.Printf("Error: Token without id or issue time.")
+		return nil, fmt.Errorf("token has no id or issue time")
+	}
+
+	return claims, nil
+}
+
+func ValidateToken(clientToken string) (*models.SignedDetails, error) {
+	claims, err := parseToken(clientToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Neither a refresh token nor the challenge of an unfinished login may be usable as an access token.
+	if claims.Token_Type != models.AccessTokenType {
+		logger.Log.Printf("Error: Token of type %q passed in place of an access token.", claims.Token_Type)
+		return nil, fmt.Errorf("%s token cannot be used for authentication", claims.Token_Type)
+	}
+
+	// Every access token is issued to a user in a session, which revoking it checks.
+	if claims.User_Id == "" || claims.Token_Family == "" {
+		logger.Log.Printf("Error: Token without user id or session passed.")
+		return nil, fmt.Errorf("token is not issued to a user session")
+	}
+
+	return claims, nil
+}
+
+func ValidateRefreshToken(refreshToken string) (*models.SignedDetails, error) {
+	claims, err := parseToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Token_Type != models.RefreshTokenType || claims.User_Id == "" || claims.Token_Family == "" {
+		logger.Log.Printf("Error: Passed token is not a refresh token.")
+		return nil, fmt.Errorf("passed token is not a refresh token")
+	}
+
+	return claims, nil
+}
+
+func ValidateMFAToken(mfaToken string) (*models.SignedDetails, error) {
+	claims, err := parseToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Token_Type != models.MFATokenType || claims.User_Id == "" {
+		logger.Log.Printf("Error: Passed token is not an mfa challenge token.")
+		return nil, fmt.Errorf("passed token is not an mfa challenge token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,266 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of lines in a line diff.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"` // 1-based line number in the old text, 0 for inserted lines
+	NewLine int    `json:"newLine,omitempty"` // 1-based line number in the new text, 0 for deleted lines
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Largest texts a diff is computed between. The time taken grows with the lines times the changes between them.
+const (
+	MaxDiffLines = 5000
+	MaxDiffBytes = 1 << 20
+)
+
+// Returned for texts larger than MaxDiffLines or MaxDiffBytes.
+var ErrDiffTooLarge = errors.New("texts too large to diff")
+
+// Computes the shortest line diff between two texts with the linear space variant of the Myers algorithm.
+// Returns ErrDiffTooLarge if either text is over the limits.
+func LineDiff(oldText string, newText string) ([]DiffLine, error) {
+	if len(oldText) > MaxDiffBytes || len(newText) > MaxDiffBytes {
+		return nil, ErrDiffTooLarge
+	}
+
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	if len(oldLines) > MaxDiffLines || len(newLines) > MaxDiffLines {
+		return nil, ErrDiffTooLarge
+	}
+
+	diffLines := make([]DiffLine, 0, max(len(oldLines), len(newLines)))
+	return diffRange(diffLines, oldLines, newLines, 0, 0), nil
+}
+
+// Appends the diff between the lines, which start at the given 0-based lines of the whole texts.
+func diffRange(diffLines []DiffLine, oldLines []string, newLines []string, oldStart int, newStart int) []DiffLine {
+	// Lines the same at the start and the end take no search.
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		diffLines = append(diffLines, DiffLine{Op: DiffEqual, Text: oldLines[prefix], OldLine: oldStart + prefix + 1, NewLine: newStart + prefix + 1})
+		prefix++
+	}
+	oldLines, newLines = oldLines[prefix:], newLines[prefix:]
+	oldStart, newStart = oldStart+prefix, newStart+prefix
+
+	suffix := 0
+	for suffix < len(oldLines) && suffix < len(newLines) && oldLines[len(oldLines)-suffix-1] == newLines[len(newLines)-suffix-1] {
+		suffix++
+	}
+	oldMiddle, newMiddle := oldLines[:len(oldLines)-suffix], newLines[:len(newLines)-suffix]
+
+	x, y, found := -1, -1, false
+	if len(oldMiddle) > 0 && len(newMiddle) > 0 {
+		x, y, found = middleSnake(oldMiddle, newMiddle)
+	}
+
+	if found {
+		diffLines = diffRange(diffLines, oldMiddle[:x], newMiddle[:y], oldStart, newStart)
+		diffLines = diffRange(diffLines, oldMiddle[x:], newMiddle[y:], oldStart+x, newStart+y)
+	} else {
+		// Nothing in common, or one side is empty.
+		for i, line := range oldMiddle {
+			diffLines = append(diffLines, DiffLine{Op: DiffDelete, Text: line, OldLine: oldStart + i + 1})
+		}
+		for i, line := range newMiddle {
+			diffLines = append(diffLines, DiffLine{Op: DiffInsert, Text: line, NewLine: newStart + i + 1})
+		}
+	}
+
+	for i := len(oldMiddle); i < len(oldLines); i++ {
+		diffLines = append(diffLines, DiffLine{Op: DiffEqual, Text: oldLines[i], OldLine: oldStart + i + 1, NewLine: newStart + len(newMiddle) + i - len(oldMiddle) + 1})
+	}
+
+	return diffLines
+}
+
+// Finds where the shortest edit path between the lines crosses its middle, searching from both ends at once
+// and keeping only the furthest reaching x on each diagonal. Reports false if the lines have nothing in common.
+func middleSnake(oldLines []string, newLines []string) (int, int, bool) {
+	n, m := len(oldLines), len(newLines)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	// With an odd difference the paths meet while extending the forward one, otherwise the backward one.
+	delta := n - m
+	meetForward := delta%2 != 0
+
+	// Diagonals which ran off the edges are skipped from then on.
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && oldLines[x] == newLines[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			if x > n {
+				forwardEnd += 2
+			} else if y > m {
+				forwardStart += 2
+			} else if meetForward {
+				backwardIndex := offset + delta - k
+				if backwardIndex >= 0 && backwardIndex < len(backward) && backward[backwardIndex] != -1 && x >= n-backward[backwardIndex] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && oldLines[n-x-1] == newLines[m-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			if x > n {
+				backwardEnd += 2
+			} else if y > m {
+				backwardStart += 2
+			} else if !meetForward {
+				forwardIndex := offset + delta - k
+				if forwardIndex >= 0 && forwardIndex < len(forward) && forward[forwardIndex] != -1 {
+					forwardX := forward[forwardIndex]
+					forwardY := forwardX - (forwardIndex - offset)
+					if forwardX >= n-x {
+						return forwardX, forwardY, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Formats a line diff in the unified diff format, with the given number of context lines around each change.
+func UnifiedDiff(oldName string, newName string, diffLines []DiffLine, contextLines int) string {
+	var builder strings.Builder
+
+	// Find the ranges of lines which make up each hunk.
+	type hunkRange struct{ start, end int }
+	var hunks []hunkRange
+
+	for i, line := range diffLines {
+		if line.Op == DiffEqual {
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i + contextLines + 1
+		if end > len(diffLines) {
+			end = len(diffLines)
+		}
+
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunkRange{start, end})
+		}
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Line numbers where the hunk starts are those of the first line of each side within it.
+	oldLine, newLine := 1, 1
+	position := 0
+
+	for _, hunk := range hunks {
+		for ; position < hunk.start; position++ {
+			if diffLines[position].Op != DiffInsert {
+				oldLine++
+			}
+			if diffLines[position].Op != DiffDelete {
+				newLine++
+			}
+		}
+
+		oldCount, newCount := 0, 0
+		for _, line := range diffLines[hunk.start:hunk.end] {
+			if line.Op != DiffInsert {
+				oldCount++
+			}
+			if line.Op != DiffDelete {
+				newCount++
+			}
+		}
+
+		oldStart, newStart := oldLine, newLine
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&builder, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+		for _, line := range diffLines[hunk.start:hunk.end] {
+			switch line.Op {
+			case DiffEqual:
+				builder.WriteString(" ")
+			case DiffInsert:
+				builder.WriteString("+")
+			case DiffDelete:
+				builder.WriteString("-")
+			}
+			builder.WriteString(line.Text)
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
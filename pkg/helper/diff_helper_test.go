@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Number of lines inserted and deleted by the shortest edit script, through the longest common subsequence.
+func editDistance(oldLines []string, newLines []string) int {
+	common := make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	return len(oldLines) + len(newLines) - 2*common[0][0]
+}
+
+// Checks that the diff turns the old text into the new one with the fewest edits, numbering the lines right.
+func checkLineDiff(t *testing.T, oldText string, newText string) {
+	t.Helper()
+
+	diffLines, err := LineDiff(oldText, newText)
+	if err != nil {
+		t.Fatalf("diff %q and %q: %s", oldText, newText, err)
+	}
+
+	oldLines, newLines := splitLines(oldText), splitLines(newText)
+	var gotOld, gotNew []string
+	edits := 0
+
+	for _, line := range diffLines {
+		if line.Op != DiffInsert {
+			if line.OldLine != len(gotOld)+1 {
+				t.Fatalf("diff %q and %q: old line %d numbered %d", oldText, newText, len(gotOld)+1, line.OldLine)
+			}
+			gotOld = append(gotOld, line.Text)
+		}
+		if line.Op != DiffDelete {
+			if line.NewLine != len(gotNew)+1 {
+				t.Fatalf("diff %q and %q: new line %d numbered %d", oldText, newText, len(gotNew)+1, line.NewLine)
+			}
+			gotNew = append(gotNew, line.Text)
+		}
+		if line.Op != DiffEqual {
+			edits++
+		}
+	}
+
+	if strings.Join(gotOld, "\n") != strings.Join(oldLines, "\n") || strings.Join(gotNew, "\n") != strings.Join(newLines, "\n") {
+		t.Fatalf("diff %q and %q gives back %q and %q", oldText, newText, gotOld, gotNew)
+	}
+
+	if want := editDistance(oldLines, newLines); edits != want {
+		t.Fatalf("diff %q and %q takes %d edits, want %d", oldText, newText, edits, want)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	for _, texts := range [][2]string{
+		{"", ""},
+		{"", "a\nb"},
+		{"a\nb", ""},
+		{"a\nb\nc", "a\nb\nc"},
+		{"a\nb\nc", "a\nx\nc"},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc"},
+		{"a\nb", "c\nd"},
+	} {
+		checkLineDiff(t, texts[0], texts[1])
+	}
+
+	// Texts made of a few distinct lines, so that they share a lot in many ways.
+	random := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for i := 0; i < 2000; i++ {
+		checkLineDiff(t, randomText(), randomText())
+	}
+}
+
+func TestLineDiffRefusesLargeTexts(t *testing.T) {
+	_, err := LineDiff(strings.Repeat("a\n", MaxDiffLines+1), "a")
+	if err != ErrDiffTooLarge {
+		t.Errorf("diff of %d lines = %v, want ErrDiffTooLarge", MaxDiffLines+1, err)
+	}
+
+	_, err = LineDiff("a", strings.Repeat("a", MaxDiffBytes+1))
+	if err != ErrDiffTooLarge {
+		t.Errorf("diff of %d bytes = %v, want ErrDiffTooLarge", MaxDiffBytes+1, err)
+	}
+
+	// Texts at the limit, with nothing in common, are diffed whole.
+	oldText, newText := strings.Repeat("a\n", MaxDiffLines), strings.Repeat("b\n", MaxDiffLines)
+	diffLines, err := LineDiff(oldText, newText)
+	if err != nil || len(diffLines) != 2*MaxDiffLines {
+		t.Errorf("diff of two texts of %d different lines = %d lines and %v, want %d lines", MaxDiffLines, len(diffLines), err, 2*MaxDiffLines)
+	}
+}
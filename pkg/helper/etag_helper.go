@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Strong entity tag of a note, which changes whenever its version does.
+func NoteETag(note *models.NoteData) string {
+	return fmt.Sprintf("\"%s-%d\"", note.ID.Hex(), note.Version)
+}
+
+// Entity tag of a list of notes, which changes whenever a note is added, removed or changed.
+// Anything else the representation depends on, like the fields sent, is passed as variants.
+func NotesListETag(notes []models.NoteData, variants ...string) string {
+	hash := sha256.New()
+
+	for _, note := range notes {
+		fmt.Fprintf(hash, "%s-%d;", note.ID.Hex(), note.Version)
+	}
+
+	for _, variant := range variants {
+		fmt.Fprintf(hash, "|%s", variant)
+	}
+
+	return fmt.Sprintf("\"%s\"", hex.EncodeToString(hash.Sum(nil))[:32])
+}
+
+// Checks whether an If-Match header value matches the entity tag, with the strong comparison it needs:
+// weak tags never match. The header can hold a list of tags or "*".
+func MatchesETagStrongly(headerValue string, etag string) bool {
+	return matchesETag(headerValue, etag, false)
+}
+
+// Checks whether an If-None-Match header value matches the entity tag, with the weak comparison it needs:
+// weak tags are compared by their value. The header can hold a list of tags or "*".
+func MatchesETagWeakly(headerValue string, etag string) bool {
+	return matchesETag(headerValue, etag, true)
+}
+
+func matchesETag(headerValue string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(headerValue, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Thresholds, delays and durations of the login lockout, set once at startup.
+var lockoutConfig = config.Default().Lockout
+
+func SetLockoutConfig(lockout config.LockoutConfig) {
+	lockoutConfig = lockout
+}
+
+// Number of lockouts listed when no limit is asked for, and the most that can be asked for.
+const (
+	DefaultLockoutListSize = 50
+	MaxLockoutListSize     = 500
+)
+
+// Keys the failed logins are counted under, emails are matched whatever their case.
+func accountFailureKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipFailureKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// How long after the last of the failures a login has to wait. Every failure doubles the wait, from the base
+// delay up to the max delay, and reaching the threshold locks the login out for the lockout duration.
+func loginWait(failures int, threshold int) time.Duration {
+	if failures >= threshold {
+		return lockoutConfig.Duration
+	}
+
+	wait := lockoutConfig.Base_Delay
+	for i := 1; i < failures && wait < lockoutConfig.Max_Delay; i++ {
+		wait *= 2
+	}
+
+	return min(wait, lockoutConfig.Max_Delay)
+}
+
+// Time left before a login under the key may be tried again, zero if it may be tried now.
+func remainingLoginWait(ctx context.Context, key string, threshold int, now time.Time) (time.Duration, error) {
+	failures, err := database.StoreObject.GetLoginFailures(ctx, key)
+	if err == database.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if failures.Last_Failure_At.Before(now.Add(-lockoutConfig.Failure_Window)) {
+		return 0, nil
+	}
+
+	return max(failures.Last_Failure_At.Add(loginWait(failures.Failures, threshold)).Sub(now), 0), nil
+}
+
+// Time left before a login to the email from the ip address may be tried, zero if it may be tried now.
+func CheckLoginAllowed(ctx context.Context, email string, ipAddress string) (time.Duration, error) {
+	now := time.Now()
+
+	accountWait, err := remainingLoginWait(ctx, accountFailureKey(email), lockoutConfig.Account_Threshold, now)
+	if err != nil {
+		return 0, err
+	}
+
+	ipWait, err := remainingLoginWait(ctx, ipFailureKey(ipAddress), lockoutConfig.IP_Threshold, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(accountWait, ipWait), nil
+}
+
+// Counts a failed login to the email from the ip address. Failures reaching a threshold lock the login out,
+// which is recorded for auditing.
+func RecordFailedLogin(ctx context.Context, email string, ipAddress string) error {
+	now := time.Now()
+	forgetBefore := now.Add(-lockoutConfig.Failure_Window)
+	// Kept till the failures are forgotten, and at least as long as the lockout they may cause.
+	expiresAt := now.Add(max(lockoutConfig.Failure_Window, lockoutConfig.Duration))
+
+	counters := []struct {
+		kind      string
+		key       string
+		threshold int
+	}{
+		{models.AccountLockout, accountFailureKey(email), lockoutConfig.Account_Threshold},
+		{models.IPLockout, ipFailureKey(ipAddress), lockoutConfig.IP_Threshold},
+	}
+
+	for _, counter := range counters {
+		failures, err := database.StoreObject.RecordLoginFailure(ctx, counter.key, now, forgetBefore, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		// Logins are refused while locked out, so every failure counted from the threshold on starts a lockout.
+		if failures < counter.threshold {
+			continue
+		}
+
+		lockout := models.Lockout{
+			ID:           primitive.NewObjectID(),
+			Kind:         counter.kind,
+			IP_Address:   ipAddress,
+			Failures:     failures,
+			Locked_At:    now,
+			Locked_Until: now.Add(lockoutConfig.Duration),
+		}
+		if counter.kind == models.AccountLockout {
+			lockout.Email = strings.ToLower(email)
+		}
+
+		err = database.StoreObject.CreateLockout(ctx, &lockout)
+		if err != nil {
+			return err
+		}
+
+		logger.Log.Printf("Message: Login locked out by %s till %s after %d failures, email: %s, ip address: %s.",
+			counter.kind, lockout.Locked_Until.Format(time.RFC3339), failures, email, ipAddress)
+	}
+
+	return nil
+}
+
+// Forgets the failed logins to the email once it is logged into. Those of the ip address are kept, so that
+// logging into one account does not let the address go on guessing the passwords of others.
+func ClearFailedLogins(ctx context.Context, email string) error {
+	return database.StoreObject.ClearLoginFailures(ctx, accountFailureKey(email))
+}
+
+var (
+	dummyPasswordOnce sync.Once
+	dummyPasswordHash string
+)
+
+// Compares the password with a hash like the ones of the users, for logins to emails with no user, so that
+// they take as long as the others and do not tell which emails are registered.
+func VerifyDummyPassword(password string) {
+	dummyPasswordOnce.Do(func() {
+		dummyPassword := "dummy password"
+		dummyPasswordHash = HashPassword(&dummyPassword)
+	})
+
+	VerifyPassword(password, dummyPasswordHash)
+}
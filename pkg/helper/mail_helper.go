@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+)
+
+// Mail configuration, set once at startup for the links in the mails.
+var mailConfig = config.Default().Mail
+
+func SetMailConfig(mail config.MailConfig) {
+	mailConfig = mail
+}
+
+// Longest time given to the mailer to send a mail.
+const mailTimeout = 30 * time.Second
+
+// Link to the path of the API with the token in its query, for the mails.
+func mailLink(path string, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", strings.TrimRight(mailConfig.Link_Base_URL, "/"), path, url.QueryEscape(token))
+}
+
+// Sends the mail with the mailer in use, logging when it fails.
+// It runs in the background, so that responses take as long whether a mail is sent or not.
+func sendMail(message *mailer.Message, userId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	err := mailer.MailerObject.Send(ctx, message)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while mailing %q to user id: %s.\n\tError: %s", message.Subject, userId, err.Error())
+		return
+	}
+
+	logger.Log.Printf("Message: Successfully mailed %q to user id: %s", message.Subject, userId)
+}
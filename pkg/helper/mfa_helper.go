@@ -0,0 +1,220 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Number of recovery codes a user gets, each usable once.
+const RecoveryCodeCount = 10
+
+// Codes of RFC 6238 as the authenticator apps generate them by default: six digits of HMAC-SHA1 every 30 seconds.
+var totpOptions = totp.ValidateOpts{
+	Period:    30,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Key of the TOTP enrollment of the user with the base32 secret, or a new secret when it is empty.
+func totpKey(user *models.UserDataServer, secret string) (*otp.Key, error) {
+	opts := totp.GenerateOpts{
+		Issuer:      authConfig.TOTP_Issuer,
+		AccountName: *user.Email,
+		Period:      uint(totpOptions.Period),
+		Digits:      totpOptions.Digits,
+		Algorithm:   totpOptions.Algorithm,
+	}
+
+	if secret != "" {
+		rawSecret, err := base32NoPadding.DecodeString(secret)
+		if err != nil {
+			return nil, err
+		}
+		opts.Secret = rawSecret
+	}
+
+	return totp.Generate(opts)
+}
+
+// Starts a TOTP enrollment of the user with a new secret, replacing a pending one.
+// Returns nil if TOTP is enabled already.
+func StartTOTPEnrollment(ctx context.Context, user *models.UserDataServer) (*otp.Key, error) {
+	key, err := totpKey(user, "")
+	if err != nil {
+		logger.Log.Printf("Error: Problem while generating the TOTP secret of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return nil, err
+	}
+
+	set, err := database.StoreObject.SetTOTPSecret(ctx, user.UserID, key.Secret())
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the TOTP secret of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return nil, err
+	}
+	if !set {
+		return nil, nil
+	}
+
+	return key, nil
+}
+
+// QR code PNG of the otpauth:// URI of the pending TOTP enrollment of the user, for authenticator apps to scan.
+func TOTPQRCode(user *models.UserDataServer, size int) ([]byte, error) {
+	key, err := totpKey(user, user.TOTP_Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := key.Image(size, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	err = png.Encode(&buffer, image)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Finds the time step the code is valid in, allowing one step of clock skew either way.
+func matchTOTPCode(secret string, code string, now time.Time) (int64, bool) {
+	period := time.Duration(totpOptions.Period) * time.Second
+
+	for _, skew := range []time.Duration{-period, 0, period} {
+		at := now.Add(skew)
+
+		expected, err := totp.GenerateCodeCustom(secret, at, totpOptions)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.TrimSpace(code))) == 1 {
+			return at.Unix() / int64(totpOptions.Period), true
+		}
+	}
+
+	return 0, false
+}
+
+// Enables the pending TOTP enrollment of the user if the code fits its secret, and returns the new recovery codes.
+// Returns nil codes if the code does not fit or there is no pending enrollment.
+func ConfirmTOTPEnrollment(ctx context.Context, user *models.UserDataServer, code string) ([]string, error) {
+	if user.TOTP_Enabled || user.TOTP_Secret == "" {
+		return nil, nil
+	}
+
+	step, matched := matchTOTPCode(user.TOTP_Secret, code, time.Now())
+	if !matched {
+		return nil, nil
+	}
+
+	enabled, err := database.StoreObject.EnableTOTP(ctx, user.UserID, step)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while enabling TOTP of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	return NewRecoveryCodes(ctx, user.UserID)
+}
+
+// Checks the TOTP code of the user with TOTP enabled. Every code is accepted once, and none older than the last accepted one.
+func VerifyTOTPCode(ctx context.Context, user *models.UserDataServer, code string) (bool, error) {
+	if !user.TOTP_Enabled {
+		return false, nil
+	}
+
+	step, matched := matchTOTPCode(user.TOTP_Secret, code, time.Now())
+	if !matched {
+		return false, nil
+	}
+
+	return database.StoreObject.UseTOTPStep(ctx, user.UserID, step)
+}
+
+// Recovery codes are compared without case, spaces and dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// Replaces the recovery codes of the user with new ones, returned once to be shown to the user.
+func NewRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	codes := []string{}
+	codeHashes := []string{}
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		randomBytes := make([]byte, 10)
+
+		_, err := rand.Read(randomBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		// 16 base32 characters, 80 random bits, written in groups of four.
+		code := strings.ToLower(base32NoPadding.EncodeToString(randomBytes))
+		code = code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+
+		codes = append(codes, code)
+		codeHashes = append(codeHashes, HashToken(normalizeRecoveryCode(code)))
+	}
+
+	err := database.StoreObject.SetRecoveryCodes(ctx, userId, codeHashes)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the recovery codes of user id: %s.\n\tError: %s", userId, err.Error())
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Uses up the recovery code of the user, reporting whether the user had it.
+func UseRecoveryCode(ctx context.Context, userId string, code string) (bool, error) {
+	return database.StoreObject.UseRecoveryCode(ctx, userId, HashToken(normalizeRecoveryCode(code)))
+}
+
+// Checks the second factor of the user with TOTP enabled, a TOTP code or else a recovery code.
+func VerifySecondFactor(ctx context.Context, user *models.UserDataServer, code string, recoveryCode string) (bool, error) {
+	if code != "" {
+		return VerifyTOTPCode(ctx, user, code)
+	}
+
+	if recoveryCode != "" && user.TOTP_Enabled {
+		return UseRecoveryCode(ctx, user.UserID, recoveryCode)
+	}
+
+	return false, nil
+}
+
+// Issues the challenge token of a login whose password was right, to be exchanged for the session tokens with the second factor.
+func GenerateMFAToken(userId string) (string, error) {
+	tokenId, err := GenerateRandomId()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &models.SignedDetails{
+		User_Id:          userId,
+		Token_Type:       models.MFATokenType,
+		RegisteredClaims: registeredClaims(userId, tokenId, authConfig.MFA_Challenge_Lifetime),
+	}
+
+	return signToken(claims)
+}
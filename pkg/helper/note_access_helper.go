@@ -0,0 +1,104 @@
+package helper
+
+import (
+	"context"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Higher rank roles include everything the lower rank roles are allowed to do.
+var noteRoleRank = map[string]int{
+	models.ViewerRole:    1,
+	models.CommenterRole: 2,
+	models.EditorRole:    3,
+	models.OwnerRole:     4,
+}
+
+// Roles which the owner can grant to other users.
+func IsGrantableNoteRole(role string) bool {
+	return role == models.EditorRole || role == models.CommenterRole || role == models.ViewerRole
+}
+
+// Gets the role of the user on the note, empty if the user has no access.
+// The notes of a workspace belong to it rather than to their author, its members have the role on them
+// their role in the workspace stands for, unless the note grants them a higher one.
+func GetNoteRole(ctx context.Context, note *models.NoteData, userId string) (string, error) {
+	role := ""
+
+	if note.Workspace_Id == nil {
+		if note.User_Id != nil && *note.User_Id == userId {
+			return models.OwnerRole, nil
+		}
+	} else {
+		workspace, err := database.StoreObject.GetWorkspaceById(ctx, *note.Workspace_Id)
+		if err != nil && err != database.ErrNotFound {
+			return "", err
+		}
+		if err == nil {
+			role = workspaceNoteRole[GetWorkspaceRole(workspace, userId)]
+		}
+	}
+
+	for _, access := range note.Access {
+		if access.User_Id == userId && noteRoleRank[access.Role] > noteRoleRank[role] {
+			role = access.Role
+		}
+	}
+
+	return role, nil
+}
+
+// Checks whether the user has at least the given role on the note.
+func HasNoteRole(ctx context.Context, note *models.NoteData, userId string, role string) (bool, error) {
+	noteRole, err := GetNoteRole(ctx, note, userId)
+	if err != nil {
+		return false, err
+	}
+
+	userRank, ok := noteRoleRank[noteRole]
+	if !ok {
+		return false, nil
+	}
+
+	return userRank >= noteRoleRank[role], nil
+}
+
+// Notes marked sharable can be viewed by every authenticated user.
+func CanViewNote(ctx context.Context, note *models.NoteData, userId string) (bool, error) {
+	if note.Sharable != nil && *note.Sharable {
+		return true, nil
+	}
+
+	return HasNoteRole(ctx, note, userId, models.ViewerRole)
+}
+
+// Adds the grant to the access list, replacing any earlier grant to the same user.
+func UpsertNoteAccess(accessList []models.NoteAccess, grant models.NoteAccess) []models.NoteAccess {
+	updatedList := []models.NoteAccess{}
+
+	for _, access := range accessList {
+		if access.User_Id != grant.User_Id {
+			updatedList = append(updatedList, access)
+		}
+	}
+
+	return append(updatedList, grant)
+}
+
+// Removes the grant of the user from the access list, reporting whether there was one.
+func RemoveNoteAccess(accessList []models.NoteAccess, userId string) ([]models.NoteAccess, bool) {
+	updatedList := []models.NoteAccess{}
+	found := false
+
+	for _, access := range accessList {
+		if access.User_Id == userId {
+			found = true
+			continue
+		}
+
+		updatedList = append(updatedList, access)
+	}
+
+	return updatedList, found
+}
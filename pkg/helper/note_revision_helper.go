@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hash identifying the content of a note, so that identical revisions can be recognised.
+func ContentHash(header *string, data *string) string {
+	hash := sha256.New()
+
+	if header != nil {
+		hash.Write([]byte(*header))
+	}
+	hash.Write([]byte{0})
+	if data != nil {
+		hash.Write([]byte(*data))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Appends a revision holding the current content of the note.
+// The revision number is one more than the latest one, retried if a concurrent update took it first.
+func AppendNoteRevision(ctx context.Context, note *models.NoteData, authorId string, restoredFrom *int64, createdAt time.Time) (*models.NoteRevision, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		latestRevision, err := database.StoreObject.GetLatestNoteRevisionNumber(ctx, note.ID.Hex())
+		if err != nil {
+			logger.Log.Printf("Error: Problem while finding the latest note revision.\n\tError: %s", err.Error())
+			return nil, err
+		}
+
+		revision := models.NoteRevision{
+			ID:            primitive.NewObjectID(),
+			Note_Id:       note.ID.Hex(),
+			Revision:      latestRevision + 1,
+			Author_Id:     authorId,
+			Header:        note.Header,
+			Data:          note.Data,
+			Content_Hash:  ContentHash(note.Header, note.Data),
+			Restored_From: restoredFrom,
+			Created_At:    createdAt,
+		}
+
+		err = database.StoreObject.CreateNoteRevision(ctx, &revision)
+		if err == nil {
+			return &revision, nil
+		}
+		if err != database.ErrDuplicate {
+			logger.Log.Printf("Error: Problem while storing the note revision.\n\tError: %s", err.Error())
+			return nil, err
+		}
+	}
+
+	logger.Log.Printf("Error: Could not find a free revision number for note id: %s.", note.ID.Hex())
+	return nil, fmt.Errorf("could not find a free revision number for note id: %s", note.ID.Hex())
+}
+
+// Notes created before revisions were kept get their current content recorded as the first revision,
+// so that it is not lost on their first update.
+func EnsureInitialNoteRevision(ctx context.Context, note *models.NoteData) error {
+	latestRevision, err := database.StoreObject.GetLatestNoteRevisionNumber(ctx, note.ID.Hex())
+	if err != nil {
+		logger.Log.Printf("Error: Problem while finding the latest note revision.\n\tError: %s", err.Error())
+		return err
+	}
+
+	if latestRevision > 0 {
+		return nil
+	}
+
+	_, err = AppendNoteRevision(ctx, note, *note.User_Id, nil, note.Updated_At)
+	return err
+}
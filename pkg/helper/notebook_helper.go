@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Limits on the notebooks of a user.
+const (
+	MaxNotebookNameLength = 100
+	MaxNotebookDepth      = 16
+)
+
+// Trims the name of a notebook, checking that something printable is left.
+func NormalizeNotebookName(name string) (string, error) {
+	normalizedName := strings.TrimSpace(name)
+
+	if normalizedName == "" {
+		return "", fmt.Errorf("notebook name must not be empty")
+	}
+
+	if utf8.RuneCountInString(normalizedName) > MaxNotebookNameLength {
+		return "", fmt.Errorf("notebook name is longer than %d characters", MaxNotebookNameLength)
+	}
+
+	if strings.IndexFunc(normalizedName, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("notebook name must not contain control characters")
+	}
+
+	return normalizedName, nil
+}
+
+// Finds the notebook, returning database.ErrNotFound as well when it belongs to another user.
+func GetOwnedNotebook(ctx context.Context, userId string, notebookId string) (*models.Notebook, error) {
+	notebook, err := database.StoreObject.GetNotebookById(ctx, notebookId)
+	if err != nil {
+		return nil, err
+	}
+
+	if notebook.User_Id != userId {
+		return nil, database.ErrNotFound
+	}
+
+	return notebook, nil
+}
+
+// Ids of the notebook of the user and of every notebook below it, the notebook itself first.
+func NotebookSubtree(ctx context.Context, userId string, notebookId string) ([]string, error) {
+	_, err := GetOwnedNotebook(ctx, userId, notebookId)
+	if err != nil {
+		return nil, err
+	}
+
+	notebooks, err := database.StoreObject.ListNotebooks(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	subtree := []string{notebookId}
+	for _, notebook := range notebooks {
+		if slices.Contains(notebook.Ancestors, notebookId) {
+			subtree = append(subtree, notebook.ID.Hex())
+		}
+	}
+
+	return subtree, nil
+}
+
+// Checks that the notebook can move into the parent, nil for the top:
+// not into itself or a notebook below it, and not deeper than the notebooks may go.
+func CheckNotebookMove(notebooks []models.Notebook, notebook *models.Notebook, parent *models.Notebook) error {
+	notebookId := notebook.ID.Hex()
+
+	parentDepth := 0
+	if parent != nil {
+		if parent.ID == notebook.ID || slices.Contains(parent.Ancestors, notebookId) {
+			return fmt.Errorf("a notebook cannot be moved into itself or a notebook below it")
+		}
+		parentDepth = len(parent.Ancestors) + 1
+	}
+
+	// Levels of notebooks from this one down to the deepest one below it.
+	height := 1
+	for _, descendant := range notebooks {
+		if position := slices.Index(descendant.Ancestors, notebookId); position >= 0 {
+			height = max(height, len(descendant.Ancestors)-position+1)
+		}
+	}
+
+	if parentDepth+height > MaxNotebookDepth {
+		return fmt.Errorf("notebooks can be nested at most %d levels deep", MaxNotebookDepth)
+	}
+
+	return nil
+}
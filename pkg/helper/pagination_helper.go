@@ -0,0 +1,148 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Page sizes of the note listing.
+const (
+	DefaultNotesPageSize = 50
+	MaxNotesPageSize     = 200
+)
+
+// Fields of a note which can be asked for in a listing, the ID is always sent.
+var noteListFields = map[string]bool{
+	"userId":       true,
+	"header":       true,
+	"uniqueHeader": true,
+	"email":        true,
+	"notesData":    true,
+	"sharable":     true,
+	"access":       true,
+	"notebookId":   true,
+	"workspaceId":  true,
+	"tags":         true,
+	"version":      true,
+	"createdAt":    true,
+	"updatedAt":    true,
+}
+
+// Content of a next page token, which only continues the listing it was made for.
+type notesPageToken struct {
+	Scope      string               `json:"f,omitempty"`
+	Sort_By    string               `json:"s"`
+	Descending bool                 `json:"d,omitempty"`
+	Tags       []string             `json:"t,omitempty"`
+	Notebook   string               `json:"n,omitempty"`
+	Workspace  string               `json:"w,omitempty"`
+	After      *database.NoteCursor `json:"c"`
+}
+
+// Makes the opaque token for the page after the given note.
+func EncodeNotesPageToken(query *database.NoteListQuery, lastNote *models.NoteData) (string, error) {
+	token := notesPageToken{
+		Scope:      query.Scope,
+		Sort_By:    query.Sort_By,
+		Descending: query.Descending,
+		Tags:       query.Filter.Tags,
+		Notebook:   notebookOf(&query.Filter),
+		Workspace:  query.Filter.Workspace_Id,
+		After:      database.NoteCursorOf(lastNote, query.Sort_By),
+	}
+
+	content, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(content), nil
+}
+
+// Reads a next page token, checking that it belongs to a listing like the query.
+func DecodeNotesPageToken(query *database.NoteListQuery, encodedToken string) (*database.NoteCursor, error) {
+	content, err := base64.RawURLEncoding.DecodeString(encodedToken)
+	if err != nil {
+		return nil, fmt.Errorf("malformed page token")
+	}
+
+	var token notesPageToken
+
+	err = json.Unmarshal(content, &token)
+	if err != nil || token.After == nil || token.After.Id == "" {
+		return nil, fmt.Errorf("malformed page token")
+	}
+
+	if token.Scope != query.Scope || token.Sort_By != query.Sort_By || token.Descending != query.Descending ||
+		!slices.Equal(token.Tags, query.Filter.Tags) || token.Notebook != notebookOf(&query.Filter) ||
+		token.Workspace != query.Filter.Workspace_Id {
+		return nil, fmt.Errorf("page token belongs to a listing with another filter or sort order")
+	}
+
+	return token.After, nil
+}
+
+// Notebook a listing is narrowed down to, empty if it is not.
+// The notebooks below it may change between pages, the token holds on to the notebook asked for.
+func notebookOf(filter *database.NoteFilter) string {
+	if len(filter.Notebook_Ids) == 0 {
+		return ""
+	}
+
+	return filter.Notebook_Ids[0]
+}
+
+// Parses the comma separated list of fields asked for, nil if none are.
+func ParseNoteFields(fields string) ([]string, error) {
+	if strings.TrimSpace(fields) == "" {
+		return nil, nil
+	}
+
+	var parsedFields []string
+
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+
+		if !noteListFields[field] {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+
+		parsedFields = append(parsedFields, field)
+	}
+
+	return parsedFields, nil
+}
+
+// Keeps only the asked for fields of the notes, and their ID.
+func ProjectNoteFields(notes []models.NoteData, fields []string) ([]map[string]interface{}, error) {
+	projectedNotes := []map[string]interface{}{}
+
+	for _, note := range notes {
+		content, err := json.Marshal(note)
+		if err != nil {
+			return nil, err
+		}
+
+		var allFields map[string]interface{}
+
+		err = json.Unmarshal(content, &allFields)
+		if err != nil {
+			return nil, err
+		}
+
+		projectedNote := map[string]interface{}{"ID": allFields["ID"]}
+		for _, field := range fields {
+			projectedNote[field] = allFields[field]
+		}
+
+		projectedNotes = append(projectedNotes, projectedNote)
+	}
+
+	return projectedNotes, nil
+}
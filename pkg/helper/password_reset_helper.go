@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Shortest password a reset can set.
+const MinPasswordLength = 8
+
+// Starts a password reset for the user, mailing them a single use token to set a new password with.
+// The mail is sent in the background, so that the response takes as long whether the user exists or not.
+func StartPasswordReset(ctx context.Context, user *models.UserDataServer) error {
+	token, err := GenerateRandomId()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	reset := models.PasswordReset{
+		ID:         primitive.NewObjectID(),
+		User_Id:    user.UserID,
+		Token_Hash: HashToken(token),
+		Created_At: now,
+		Expires_At: now.Add(authConfig.Password_Reset_Lifetime),
+	}
+
+	err = database.StoreObject.CreatePasswordReset(ctx, &reset)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the password reset of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return err
+	}
+
+	message := mailer.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"A reset of the password of your account was requested. To set a new password,\n"+
+			"send it along with this token to /api/auth/password/reset:\n\n%s\n\n"+
+			"The token can be used once, until %s. If you did not request the reset, ignore this mail.\n",
+			*user.First_Name, token, reset.Expires_At.UTC().Format(time.RFC1123)),
+	}
+
+	go sendMail(&message, user.UserID)
+
+	return nil
+}
+
+// Sets the new password of the user of the reset token, which cannot be used again.
+// Every other reset of the user and every token issued to them is revoked, ending all their sessions.
+func ResetPassword(ctx context.Context, token string, password string) (string, error) {
+	reset, err := database.StoreObject.TakePasswordReset(ctx, HashToken(token))
+	if err != nil {
+		return "", err
+	}
+
+	err = database.StoreObject.UpdatePassword(ctx, reset.User_Id, HashPassword(&password), time.Now())
+	if err != nil {
+		logger.Log.Printf("Error: Problem while updating the password of user id: %s.\n\tError: %s", reset.User_Id, err.Error())
+		return "", err
+	}
+
+	err = database.StoreObject.DeletePasswordResets(ctx, reset.User_Id)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while deleting the password resets of user id: %s.\n\tError: %s", reset.User_Id, err.Error())
+		return "", err
+	}
+
+	err = RevokeAllTokens(ctx, reset.User_Id)
+	if err != nil {
+		return "", err
+	}
+
+	return reset.User_Id, nil
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Emails of the users made admins, set once at startup.
+var adminEmails []string
+
+func SetAdminConfig(admin config.AdminConfig) {
+	adminEmails = admin.Emails
+}
+
+// Number of users listed for the admins when no limit is asked for, and the most that can be asked for.
+const (
+	DefaultUserListSize = 50
+	MaxUserListSize     = 500
+)
+
+func IsValidRole(role string) bool {
+	return slices.Contains(models.Roles, role)
+}
+
+// Whether the role is allowed what the required role is. Tokens and users from before roles count as plain users.
+func HasRole(role string, requiredRole string) bool {
+	if role == "" {
+		role = models.UserRole
+	}
+
+	return slices.Index(models.Roles, role) >= slices.Index(models.Roles, requiredRole)
+}
+
+// Whether users with the email are made admins once they have verified it.
+func IsConfiguredAdminEmail(email string) bool {
+	return slices.ContainsFunc(adminEmails, func(adminEmail string) bool { return strings.EqualFold(adminEmail, email) })
+}
+
+// Makes the user an admin if they have verified a configured admin email and are not one yet.
+// Anyone can sign up with any email, so until it is verified the account stays a plain user.
+func PromoteIfConfiguredAdmin(ctx context.Context, user *models.UserDataServer) error {
+	if !user.Email_Verified || user.Role == models.AdminRole || !IsConfiguredAdminEmail(*user.Email) {
+		return nil
+	}
+
+	err := SetUserRole(ctx, user.UserID, models.AdminRole)
+	if err != nil {
+		return err
+	}
+
+	user.Role = models.AdminRole
+	logger.Log.Printf("Message: User id: %s with the configured admin email: %s made an admin.", user.UserID, *user.Email)
+	return nil
+}
+
+// Makes the existing verified users with the configured admin emails admins, at startup.
+func PromoteConfiguredAdmins(ctx context.Context) error {
+	for _, email := range adminEmails {
+		foundUser, err := database.StoreObject.GetUserByEmail(ctx, email)
+		if err == database.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = PromoteIfConfiguredAdmin(ctx, foundUser)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Changes the role of the user. Their tokens carry the role, so every one of them is revoked and the new role
+// takes effect once they log in again.
+func SetUserRole(ctx context.Context, userId string, role string) error {
+	err := database.StoreObject.SetUserRole(ctx, userId, role, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return RevokeAllTokens(ctx, userId)
+}
+
+// Disables or enables the account of the user. Disabling it revokes every token of the user, ending their sessions.
+func SetUserDisabled(ctx context.Context, userId string, disabled bool) error {
+	err := database.StoreObject.SetUserDisabled(ctx, userId, disabled, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !disabled {
+		return nil
+	}
+
+	return RevokeAllTokens(ctx, userId)
+}
+
+// Sets a new password of the user chosen by an admin, revoking every password reset and every token of the user.
+func SetPasswordByAdmin(ctx context.Context, userId string, password string) error {
+	err := database.StoreObject.UpdatePassword(ctx, userId, HashPassword(&password), time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = database.StoreObject.DeletePasswordResets(ctx, userId)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while deleting the password resets of user id: %s.\n\tError: %s", userId, err.Error())
+		return err
+	}
+
+	return RevokeAllTokens(ctx, userId)
+}
@@ -0,0 +1,50 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hash a refresh token is stored as. Tokens are long and random, so unlike passwords they need no slow hash.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Starts a session for a login of the user from a device, and returns its access token and refresh token.
+func StartSession(ctx context.Context, user *models.UserDataServer, userAgent string, ipAddress string) (string, string, error) {
+	now := time.Now()
+
+	session := models.Session{
+		ID:           primitive.NewObjectID(),
+		User_Id:      user.UserID,
+		User_Agent:   userAgent,
+		IP_Address:   ipAddress,
+		Created_At:   now,
+		Last_Used_At: now,
+		Expires_At:   now.Add(authConfig.Refresh_Token_Lifetime),
+	}
+
+	// Every token of the session carries its id as the token family.
+	token, refreshToken, err := GenerateAllToken(*user.Email, *user.First_Name, *user.Last_Name, user.UserID, user.Role, session.ID.Hex())
+	if err != nil {
+		return "", "", err
+	}
+
+	session.Refresh_Token_Hash = HashToken(refreshToken)
+
+	err = database.StoreObject.CreateSession(ctx, &session)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the session of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
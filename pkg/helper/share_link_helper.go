@@ -0,0 +1,164 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Passwords of share links are hashed like the ones of the users, which only use their first 72 bytes.
+const MaxShareLinkPasswordLength = 72
+
+// Returned for a share link which does not exist, has expired, has run out of views or was revoked,
+// or whose note is gone or in the trash.
+var ErrInvalidShareLink = errors.New("invalid share link")
+
+// Roles a share link can give on its note, only reading it as notes have no comments to open up.
+func IsShareLinkRole(role string) bool {
+	return role == models.ViewerRole
+}
+
+// Key the wrong passwords of the link are counted under, like the failed logins.
+func shareLinkFailureKey(linkId string) string {
+	return "shareLink:" + linkId
+}
+
+// Address of the link with the token, under the same base url as the links in the mails.
+func ShareLinkURL(token string) string {
+	return strings.TrimRight(mailConfig.Link_Base_URL, "/") + "/s/" + token
+}
+
+// Checks the role, password, expiry and views asked for a new link, giving the viewer role when none is asked for.
+func ValidateShareLinkRequest(request *models.CreateShareLinkRequest) error {
+	if request.Role == "" {
+		request.Role = models.ViewerRole
+	}
+	if !IsShareLinkRole(request.Role) {
+		return fmt.Errorf("role: %s cannot be given through a link, it has to be %s", request.Role, models.ViewerRole)
+	}
+
+	if request.Password != "" && (len(request.Password) < MinPasswordLength || len(request.Password) > MaxShareLinkPasswordLength) {
+		return fmt.Errorf("password must have from %d to %d characters", MinPasswordLength, MaxShareLinkPasswordLength)
+	}
+
+	if request.Expires_At != nil && !request.Expires_At.After(time.Now()) {
+		return errors.New("expiry must be in the future")
+	}
+
+	if request.Max_Views != nil && *request.Max_Views < 1 {
+		return errors.New("max views must be at least 1")
+	}
+
+	return nil
+}
+
+// Creates a link to the note from a validated request, returning the token of the link,
+// which is not kept and shown only once.
+func CreateShareLink(ctx context.Context, noteId string, userId string, request *models.CreateShareLinkRequest) (string, *models.ShareLink, error) {
+	token, err := GenerateRandomId()
+	if err != nil {
+		return "", nil, err
+	}
+
+	link := models.ShareLink{
+		ID:         primitive.NewObjectID(),
+		Note_Id:    noteId,
+		Role:       request.Role,
+		Token_Hash: HashToken(token),
+		Max_Views:  request.Max_Views,
+		Created_By: userId,
+		Created_At: time.Now(),
+		Expires_At: request.Expires_At,
+	}
+
+	if request.Password != "" {
+		passwordHash := HashPassword(&request.Password)
+		link.Password_Hash = &passwordHash
+		link.Password_Protected = true
+	}
+
+	err = database.StoreObject.CreateShareLink(ctx, &link)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the share link of the note with note id: %s.\n\tError: %s", noteId, err.Error())
+		return "", nil, err
+	}
+
+	return token, &link, nil
+}
+
+// Finds the link with the token, which may still need its password before it is opened.
+func FindShareLink(ctx context.Context, token string) (*models.ShareLink, error) {
+	link, err := database.StoreObject.GetShareLinkByHash(ctx, HashToken(token))
+	if err == database.ErrNotFound {
+		return nil, ErrInvalidShareLink
+	}
+
+	return link, err
+}
+
+// Time left before the password of the link may be tried again, zero if it may be tried now.
+// Every wrong password makes the wait longer, like the failed logins to an account.
+func CheckShareLinkPasswordAllowed(ctx context.Context, link *models.ShareLink) (time.Duration, error) {
+	return remainingLoginWait(ctx, shareLinkFailureKey(link.ID.Hex()), lockoutConfig.Account_Threshold, time.Now())
+}
+
+// Checks the password of the link, counting it when it is wrong.
+func VerifyShareLinkPassword(ctx context.Context, link *models.ShareLink, password string) (bool, error) {
+	if link.Password_Hash == nil {
+		return true, nil
+	}
+
+	valid, err := VerifyPassword(password, *link.Password_Hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := shareLinkFailureKey(link.ID.Hex())
+	if valid {
+		return true, database.StoreObject.ClearLoginFailures(ctx, key)
+	}
+
+	now := time.Now()
+	_, err = database.StoreObject.RecordLoginFailure(ctx, key, now, now.Add(-lockoutConfig.Failure_Window), now.Add(max(lockoutConfig.Failure_Window, lockoutConfig.Duration)))
+	return false, err
+}
+
+// Opens the link, counting the view, and returns what it shows of its note.
+func OpenShareLink(ctx context.Context, link *models.ShareLink) (*models.SharedNote, error) {
+	// Notes in the trash are not found, so their links work again only once they are restored.
+	note, err := database.StoreObject.GetNoteById(ctx, link.Note_Id)
+	if err == database.ErrNotFound {
+		return nil, ErrInvalidShareLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = database.StoreObject.RecordShareLinkView(ctx, link.ID.Hex(), time.Now())
+	if err == database.ErrNotFound {
+		return nil, ErrInvalidShareLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sharedNote := models.SharedNote{
+		Header:     note.Header,
+		Data:       note.Data,
+		Tags:       note.Tags,
+		Updated_At: note.Updated_At,
+		Role:       link.Role,
+	}
+	if sharedNote.Tags == nil {
+		sharedNote.Tags = []string{}
+	}
+
+	return &sharedNote, nil
+}
@@ -0,0 +1,251 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key of the key set, loaded from its file at startup.
+type signingKey struct {
+	id          string
+	method      jwt.SigningMethod
+	privateKey  crypto.PrivateKey // nil for a key which only verifies
+	publicKey   crypto.PublicKey
+	signFrom    time.Time
+	verifyUntil time.Time
+}
+
+// Whether the tokens of the key are still valid at the time.
+func (key *signingKey) verifies(now time.Time) bool {
+	return key.verifyUntil.IsZero() || now.Before(key.verifyUntil)
+}
+
+// Keys of the configuration, set once at startup.
+var signingKeys []signingKey
+
+// Loads the signing keys of the auth configuration from their files, once at startup after SetAuthConfig.
+func LoadSigningKeys() error {
+	keys := []signingKey{}
+
+	for _, keyConfig := range authConfig.Signing_Keys {
+		key, err := loadSigningKey(keyConfig)
+		if err != nil {
+			logger.Log.Printf("Error: Problem while loading the signing key: %s.\n\tError: %s", keyConfig.Id, err.Error())
+			return err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	signingKeys = keys
+	return nil
+}
+
+func loadSigningKey(keyConfig config.SigningKeyConfig) (*signingKey, error) {
+	content, err := os.ReadFile(keyConfig.Key_File)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in key file %s", keyConfig.Key_File)
+	}
+
+	var parsedKey any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsedKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in key file %s", block.Type, keyConfig.Key_File)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := signingKey{
+		id:          keyConfig.Id,
+		signFrom:    keyConfig.Sign_From,
+		verifyUntil: keyConfig.Verify_Until,
+	}
+
+	switch typedKey := parsedKey.(type) {
+	case *rsa.PrivateKey:
+		key.privateKey = typedKey
+		key.publicKey = &typedKey.PublicKey
+	case *rsa.PublicKey:
+		key.publicKey = typedKey
+	case ed25519.PrivateKey:
+		key.privateKey = typedKey
+		key.publicKey = typedKey.Public()
+	case ed25519.PublicKey:
+		key.publicKey = typedKey
+	default:
+		return nil, fmt.Errorf("unsupported key type %T in key file %s", parsedKey, keyConfig.Key_File)
+	}
+
+	// The algorithm has to fit the key, so that a key is never used with another algorithm.
+	switch keyConfig.Algorithm {
+	case config.RS256Algorithm:
+		publicKey, ok := key.publicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key file %s holds no RSA key", keyConfig.Key_File)
+		}
+		if publicKey.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key in key file %s is shorter than 2048 bits", keyConfig.Key_File)
+		}
+		key.method = jwt.SigningMethodRS256
+	case config.EdDSAAlgorithm:
+		if _, ok := key.publicKey.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("key file %s holds no Ed25519 key", keyConfig.Key_File)
+		}
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", keyConfig.Algorithm)
+	}
+
+	return &key, nil
+}
+
+// Key signing the tokens at the time, the one which started signing last.
+// Nil when there are no signing keys and the HS256 secret signs the tokens.
+func activeSigningKey(now time.Time) (*signingKey, error) {
+	var activeKey *signingKey
+
+	for i := range signingKeys {
+		key := &signingKeys[i]
+		if key.privateKey == nil || key.signFrom.After(now) || !key.verifies(now) {
+			continue
+		}
+
+		if activeKey == nil || !key.signFrom.Before(activeKey.signFrom) {
+			activeKey = key
+		}
+	}
+
+	if activeKey == nil && len(signingKeys) > 0 {
+		return nil, fmt.Errorf("none of the signing keys signs tokens now")
+	}
+
+	return activeKey, nil
+}
+
+// Signs the claims with the active key, naming it in the kid header.
+func signToken(claims jwt.Claims) (string, error) {
+	key, err := activeSigningKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(authConfig.Secret_Key))
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	return token.SignedString(key.privateKey)
+}
+
+// Algorithms of the tokens which can be valid, the ones of the signing keys and HS256 while the secret is set.
+func validMethods() []string {
+	methods := []string{}
+	if authConfig.Secret_Key != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	for i := range signingKeys {
+		if !slices.Contains(methods, signingKeys[i].method.Alg()) {
+			methods = append(methods, signingKeys[i].method.Alg())
+		}
+	}
+
+	return methods
+}
+
+// Finds the key verifying the token by its kid header, which only verifies tokens signed with the algorithm of the key.
+// Tokens without a kid are HS256 tokens, valid only while the secret is set.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if kid == "" {
+		if authConfig.Secret_Key == "" || token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("token is not signed by a known key")
+		}
+
+		return []byte(authConfig.Secret_Key), nil
+	}
+
+	for i := range signingKeys {
+		key := &signingKeys[i]
+		if key.id != kid {
+			continue
+		}
+
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("token is not signed with the algorithm of its key")
+		}
+		if !key.verifies(time.Now()) {
+			return nil, fmt.Errorf("key of the token has been retired")
+		}
+
+		return key.publicKey, nil
+	}
+
+	return nil, fmt.Errorf("token is not signed by a known key")
+}
+
+// Public keys verifying tokens now, including the ones which only start signing later,
+// for other services to verify the tokens without sharing a secret.
+func JSONWebKeySet() models.JSONWebKeySet {
+	keySet := models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	now := time.Now()
+
+	for i := range signingKeys {
+		key := &signingKeys[i]
+		if !key.verifies(now) {
+			continue
+		}
+
+		webKey := models.JSONWebKey{
+			Key_Id:    key.id,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+
+		switch publicKey := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			webKey.Key_Type = "RSA"
+			webKey.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			webKey.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			webKey.Key_Type = "OKP"
+			webKey.Curve = "Ed25519"
+			webKey.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		keySet.Keys = append(keySet.Keys, webKey)
+	}
+
+	return keySet
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on the tags of a note.
+const (
+	MaxTagLength = 50
+	MaxNoteTags  = 32
+)
+
+// Brings a tag to the form it is stored in: lower case, with single spaces between its words.
+// Tags are made of letters, numbers, spaces and the characters - _ . : so that they fit in a url path
+// and in the comma separated tag filters.
+func NormalizeTag(tag string) (string, error) {
+	normalizedTag := strings.ToLower(strings.Join(strings.Fields(tag), " "))
+
+	if normalizedTag == "" {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+
+	if utf8.RuneCountInString(normalizedTag) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", normalizedTag, MaxTagLength)
+	}
+
+	for _, character := range normalizedTag {
+		if !unicode.IsLetter(character) && !unicode.IsNumber(character) && !strings.ContainsRune(" -_.:", character) {
+			return "", fmt.Errorf("tag %q may only contain letters, numbers, spaces and - _ . :", normalizedTag)
+		}
+	}
+
+	return normalizedTag, nil
+}
+
+// Normalizes the tags, leaving out duplicates and sorting them.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalizedTags := []string{}
+
+	for _, tag := range tags {
+		normalizedTag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[normalizedTag] {
+			seen[normalizedTag] = true
+			normalizedTags = append(normalizedTags, normalizedTag)
+		}
+	}
+
+	sort.Strings(normalizedTags)
+	return normalizedTags, nil
+}
+
+// Parses the comma separated tags of a filter, nil if none are given.
+func ParseTagFilter(tags string) ([]string, error) {
+	if strings.TrimSpace(tags) == "" {
+		return nil, nil
+	}
+
+	return NormalizeTags(strings.Split(tags, ","))
+}
+
+// Tags of a note with the given tags added, failing if that makes too many.
+func AddTags(noteTags []string, tags []string) ([]string, error) {
+	mergedTags, err := NormalizeTags(append(append([]string{}, noteTags...), tags...))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mergedTags) > MaxNoteTags {
+		return nil, fmt.Errorf("a note can have at most %d tags", MaxNoteTags)
+	}
+
+	return mergedTags, nil
+}
+
+// Tags of a note without the given tag, and whether the note had it.
+func RemoveTag(noteTags []string, tag string) ([]string, bool) {
+	remainingTags := []string{}
+	removed := false
+
+	for _, noteTag := range noteTags {
+		if noteTag == tag {
+			removed = true
+			continue
+		}
+		remainingTags = append(remainingTags, noteTag)
+	}
+
+	return remainingTags, removed
+}
@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"context"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Puts a single token on the deny-list till it expires.
+func RevokeToken(ctx context.Context, tokenId string, userId string, expiresAt int64) error {
+	revokedToken := models.RevokedToken{
+		ID:         primitive.NewObjectID(),
+		Token_Id:   tokenId,
+		User_Id:    userId,
+		Revoked_At: time.Now(),
+		Expires_At: time.Unix(expiresAt, 0),
+	}
+
+	err := database.StoreObject.RevokeToken(ctx, &revokedToken)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the revoked token.\n\tError: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Puts a single token on the deny-list unless it is on it already, reporting whether this call did,
+// for tokens which may be used only once.
+func ConsumeToken(ctx context.Context, tokenId string, userId string, expiresAt int64) (bool, error) {
+	revokedToken := models.RevokedToken{
+		ID:         primitive.NewObjectID(),
+		Token_Id:   tokenId,
+		User_Id:    userId,
+		Revoked_At: time.Now(),
+		Expires_At: time.Unix(expiresAt, 0),
+	}
+
+	consumed, err := database.StoreObject.ConsumeToken(ctx, &revokedToken)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the used token.\n\tError: %s", err.Error())
+		return false, err
+	}
+
+	return consumed, nil
+}
+
+// Revokes every token issued to the user up to now, ends all of their sessions and deletes their personal access tokens.
+// The issue times of the tokens are whole seconds, so the revocation time is kept at the same precision.
+func RevokeAllTokens(ctx context.Context, userId string) error {
+	err := database.StoreObject.RevokeAllTokens(ctx, userId, time.Now().Truncate(time.Second))
+	if err != nil {
+		logger.Log.Printf("Error: Problem while trying to revoke all tokens of the user.\n\tError: %s", err.Error())
+		return err
+	}
+
+	err = database.StoreObject.DeleteAccessTokens(ctx, userId)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while trying to delete the access tokens of the user.\n\tError: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Checks the token against the deny-list, against the user's revocation timestamp and against the sessions of the user.
+func IsTokenRevoked(ctx context.Context, claims *models.SignedDetails) (bool, error) {
+	// Check whether the token itself has been revoked.
+	revoked, err := database.StoreObject.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while finding the token in the revoked tokens.\n\tError: %s", err.Error())
+		return false, err
+	}
+	if revoked {
+		return true, nil
+	}
+
+	// Check whether all tokens of the user issued up to some time have been revoked.
+	foundUser, err := database.StoreObject.GetUserById(ctx, claims.User_Id)
+	if err != nil {
+		// Tokens of a user who no longer exists are never valid.
+		if err == database.ErrNotFound {
+			return true, nil
+		}
+
+		logger.Log.Printf("Error: Problem while finding the user of the token.\n\tError: %s", err.Error())
+		return false, err
+	}
+
+	// Disabling the account revokes its tokens, checking it too keeps the account out whatever happens in between.
+	if foundUser.Disabled {
+		return true, nil
+	}
+
+	// Tokens issued in the second of the revocation are let through here, so that logging in again right after it works.
+	// Those issued in that second before the revocation belong to the sessions it ended, and are rejected below.
+	if !foundUser.Revoked_Before.IsZero() && claims.IssuedAt.Unix() < foundUser.Revoked_Before.Unix() {
+		return true, nil
+	}
+
+	// Tokens of a session which was revoked, or which ended along with its refresh token, are no longer valid.
+	// Tokens from before sessions existed have a token family without a session, so they are not valid either.
+	if claims.Token_Family != "" {
+		foundSession, err := database.StoreObject.GetSessionById(ctx, claims.Token_Family)
+		if err == database.ErrNotFound {
+			return true, nil
+		}
+		if err != nil {
+			logger.Log.Printf("Error: Problem while finding the session of the token.\n\tError: %s", err.Error())
+			return false, err
+		}
+
+		if foundSession.User_Id != claims.User_Id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"context"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+)
+
+// Retention and purge interval of the trash, set once at startup.
+var trashConfig = config.Default().Trash
+
+func SetTrashConfig(trash config.TrashConfig) {
+	trashConfig = trash
+}
+
+// Time at which the note deleted at the given time is purged from the trash.
+func TrashPurgeTime(deletedAt time.Time) time.Time {
+	return deletedAt.Add(trashConfig.Retention)
+}
+
+// Deletes the notes which have been in the trash for longer than the retention.
+func PurgeTrash(ctx context.Context) (int64, error) {
+	purged, err := database.StoreObject.PurgeTrash(ctx, time.Now().Add(-trashConfig.Retention))
+	if err != nil {
+		logger.Log.Printf("Error: Problem while purging the trash.\n\tError: %s", err.Error())
+		return purged, err
+	}
+
+	if purged > 0 {
+		logger.Log.Printf("Message: Purged %d notes from the trash.", purged)
+	}
+
+	return purged, nil
+}
+
+// Purges the trash right away and then every purge interval, in the background till the context is done.
+func StartTrashPurge(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(trashConfig.Purge_Interval)
+		defer ticker.Stop()
+
+		for {
+			PurgeTrash(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,127 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Lifetime, resend interval and restrictions of the email verification, set once at startup.
+var verificationConfig = config.Default().Verification
+
+func SetVerificationConfig(verification config.VerificationConfig) {
+	verificationConfig = verification
+}
+
+// Returned when a verification mail was sent to the user too recently to send another.
+var ErrVerificationThrottled = errors.New("verification mail sent too recently")
+
+// Whether users are kept from the action till they have verified their email.
+func IsRestrictedForUnverified(action string) bool {
+	return slices.Contains(verificationConfig.Unverified_Restrictions, action)
+}
+
+// Whether the user may take the action, having verified their email if the configuration asks for it.
+// The user is read from the store, so that verifying takes effect without new tokens.
+func IsAllowedAction(ctx context.Context, userId string, action string) (bool, error) {
+	if !IsRestrictedForUnverified(action) {
+		return true, nil
+	}
+
+	foundUser, err := database.StoreObject.GetUserById(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return foundUser.Email_Verified, nil
+}
+
+// Whether the email is a bare address, like name@example.com, which mails can be sent to.
+func IsValidEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// Gives the new, unverified user a verification token, returned to be mailed once the user is stored.
+func NewEmailVerification(user *models.UserDataServer, now time.Time) (string, error) {
+	token, err := GenerateRandomId()
+	if err != nil {
+		return "", err
+	}
+
+	user.Email_Verified = false
+	user.Verification_Token_Hash = HashToken(token)
+	user.Verification_Sent_At = now
+	user.Verification_Expires_At = now.Add(verificationConfig.Lifetime)
+
+	return token, nil
+}
+
+// Mails the user the link verifying their email with the token, in the background.
+func MailEmailVerification(user *models.UserDataServer, token string) {
+	message := mailer.Message{
+		To:      *user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"To verify the email of your account, open this link:\n\n%s\n\n"+
+			"The link can be used until %s. If you did not sign up, ignore this mail.\n",
+			*user.First_Name, mailLink("/api/auth/verify", token), user.Verification_Expires_At.UTC().Format(time.RFC1123)),
+	}
+
+	go sendMail(&message, user.UserID)
+}
+
+// Mails the unverified user a new verification token, which replaces the previous one.
+// Returns ErrVerificationThrottled with the time to wait if the last one was sent within the resend interval.
+func ResendEmailVerification(ctx context.Context, user *models.UserDataServer) (time.Duration, error) {
+	now := time.Now()
+	sentBefore := now.Add(-verificationConfig.Resend_Interval)
+
+	if user.Verification_Sent_At.After(sentBefore) {
+		return user.Verification_Sent_At.Sub(sentBefore), ErrVerificationThrottled
+	}
+
+	token, err := NewEmailVerification(user, now)
+	if err != nil {
+		return 0, err
+	}
+
+	// Another request may have sent a mail since the user was read, the store only lets one of them through.
+	replaced, err := database.StoreObject.SetVerificationToken(ctx, user.UserID, user.Verification_Token_Hash, user.Verification_Sent_At, user.Verification_Expires_At, sentBefore)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the verification token of user id: %s.\n\tError: %s", user.UserID, err.Error())
+		return 0, err
+	}
+	if !replaced {
+		return verificationConfig.Resend_Interval, ErrVerificationThrottled
+	}
+
+	MailEmailVerification(user, token)
+	return 0, nil
+}
+
+// Verifies the email of the user with the mailed token. Returns database.ErrNotFound for an unknown or expired token.
+func VerifyEmail(ctx context.Context, token string) (*models.UserDataServer, error) {
+	verifiedUser, err := database.StoreObject.VerifyEmail(ctx, HashToken(token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Owning a configured admin email is only proven now.
+	err = PromoteIfConfiguredAdmin(ctx, verifiedUser)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while making user id: %s with a configured admin email an admin.\n\tError: %s", verifiedUser.UserID, err.Error())
+		return nil, err
+	}
+
+	return verifiedUser, nil
+}
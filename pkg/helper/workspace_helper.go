@@ -0,0 +1,208 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lifetime of the invitations into workspaces, set once at startup.
+var workspaceConfig = config.Default().Workspace
+
+func SetWorkspaceConfig(workspace config.WorkspaceConfig) {
+	workspaceConfig = workspace
+}
+
+// Longest name of a workspace.
+const MaxWorkspaceNameLength = 100
+
+// Returned when an invitation is accepted by a user with another email than the one invited.
+var ErrInvitationEmailMismatch = errors.New("invitation is for another email")
+
+// Higher rank roles include everything the lower rank roles are allowed to do.
+var workspaceRoleRank = map[string]int{
+	models.WorkspaceViewerRole: 1,
+	models.WorkspaceMemberRole: 2,
+	models.WorkspaceAdminRole:  3,
+	models.WorkspaceOwnerRole:  4,
+}
+
+// Role on the notes of a workspace its members have by their role in it.
+var workspaceNoteRole = map[string]string{
+	models.WorkspaceViewerRole: models.ViewerRole,
+	models.WorkspaceMemberRole: models.EditorRole,
+	models.WorkspaceAdminRole:  models.OwnerRole,
+	models.WorkspaceOwnerRole:  models.OwnerRole,
+}
+
+// Roles which can be given to members and invited users, every workspace has a single owner who created it.
+func IsAssignableWorkspaceRole(role string) bool {
+	return role == models.WorkspaceAdminRole || role == models.WorkspaceMemberRole || role == models.WorkspaceViewerRole
+}
+
+// Trims the name of a workspace, checking that something printable is left.
+func NormalizeWorkspaceName(name string) (string, error) {
+	normalizedName := strings.TrimSpace(name)
+
+	if normalizedName == "" {
+		return "", fmt.Errorf("workspace name must not be empty")
+	}
+
+	if utf8.RuneCountInString(normalizedName) > MaxWorkspaceNameLength {
+		return "", fmt.Errorf("workspace name is longer than %d characters", MaxWorkspaceNameLength)
+	}
+
+	if strings.IndexFunc(normalizedName, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("workspace name must not contain control characters")
+	}
+
+	return normalizedName, nil
+}
+
+// Gets the role of the user in the workspace, empty if the user is not a member.
+func GetWorkspaceRole(workspace *models.Workspace, userId string) string {
+	for _, member := range workspace.Members {
+		if member.User_Id == userId {
+			return member.Role
+		}
+	}
+
+	return ""
+}
+
+// Checks whether the user has at least the given role in the workspace.
+func HasWorkspaceRole(workspace *models.Workspace, userId string, role string) bool {
+	userRank, ok := workspaceRoleRank[GetWorkspaceRole(workspace, userId)]
+	if !ok {
+		return false
+	}
+
+	return userRank >= workspaceRoleRank[role]
+}
+
+// Creates a workspace with the user as its owner.
+func CreateWorkspace(ctx context.Context, name string, owner *models.UserDataServer) (*models.Workspace, error) {
+	now := time.Now()
+
+	workspace := models.Workspace{
+		ID:   primitive.NewObjectID(),
+		Name: name,
+		Members: []models.WorkspaceMember{
+			{User_Id: owner.UserID, Email: owner.Email, Role: models.WorkspaceOwnerRole, Joined_At: now},
+		},
+		Created_At: now,
+		Updated_At: now,
+	}
+
+	err := database.StoreObject.CreateWorkspace(ctx, &workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// Invites the email into the workspace with the role, replacing any earlier invitation of it,
+// and mails the token accepting or declining the invitation.
+func InviteToWorkspace(ctx context.Context, workspace *models.Workspace, email string, role string, invitedBy string) (*models.WorkspaceInvitation, error) {
+	token, err := GenerateRandomId()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	invitation := models.WorkspaceInvitation{
+		ID:           primitive.NewObjectID(),
+		Workspace_Id: workspace.ID.Hex(),
+		Email:        strings.ToLower(email),
+		Role:         role,
+		Token_Hash:   HashToken(token),
+		Invited_By:   invitedBy,
+		Created_At:   now,
+		Expires_At:   now.Add(workspaceConfig.Invitation_Lifetime),
+	}
+
+	err = database.StoreObject.CreateWorkspaceInvitation(ctx, &invitation)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while storing the invitation of %s into workspace id: %s.\n\tError: %s", invitation.Email, invitation.Workspace_Id, err.Error())
+		return nil, err
+	}
+
+	message := mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You are invited to the workspace %s", workspace.Name),
+		Body: fmt.Sprintf("Hello,\n\n"+
+			"You are invited to join the workspace %s as %s. To join it, log in with this email and send\n"+
+			"this token to /api/invitations/accept, or send it to /api/invitations/decline to decline:\n\n%s\n\n"+
+			"The token can be used until %s. If you do not know the workspace, ignore this mail.\n",
+			workspace.Name, role, token, invitation.Expires_At.UTC().Format(time.RFC1123)),
+	}
+
+	go sendMail(&message, invitation.Email)
+
+	return &invitation, nil
+}
+
+// Adds the user to the workspace of the invitation with the mailed token, which cannot be used again.
+// Returns database.ErrNotFound for an unknown or expired token, and ErrInvitationEmailMismatch if the invitation
+// is for another email than the one of the user.
+func AcceptWorkspaceInvitation(ctx context.Context, token string, user *models.UserDataServer) (*models.Workspace, error) {
+	invitation, err := database.StoreObject.GetWorkspaceInvitationByHash(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email == nil || !strings.EqualFold(*user.Email, invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	workspace, err := database.StoreObject.GetWorkspaceById(ctx, invitation.Workspace_Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Members accepting an invitation again keep the role they have.
+	if GetWorkspaceRole(workspace, user.UserID) == "" {
+		member := models.WorkspaceMember{User_Id: user.UserID, Email: user.Email, Role: invitation.Role, Joined_At: time.Now()}
+
+		workspace, err = database.StoreObject.SetWorkspaceMembers(ctx, workspace.ID.Hex(), workspace.Version, append(workspace.Members, member))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = database.StoreObject.DeleteWorkspaceInvitation(ctx, invitation.Workspace_Id, invitation.ID.Hex())
+	if err != nil && err != database.ErrNotFound {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// Declines the invitation with the mailed token, which cannot be used again.
+// Returns database.ErrNotFound for an unknown or expired token.
+func DeclineWorkspaceInvitation(ctx context.Context, token string) (*models.WorkspaceInvitation, error) {
+	invitation, err := database.StoreObject.GetWorkspaceInvitationByHash(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.StoreObject.DeleteWorkspaceInvitation(ctx, invitation.Workspace_Id, invitation.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Mailer writing every mail to a file of its own in a directory instead of sending it,
+// for running the API without a mail server.
+type FileMailer struct {
+	directory string
+	from      string
+}
+
+func NewFileMailer(directory string, from string) (*FileMailer, error) {
+	// The mails hold tokens, so only the owner of the process may read them.
+	err := os.MkdirAll(directory, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{directory: directory, from: from}, nil
+}
+
+func (mailer *FileMailer) Send(ctx context.Context, message *Message) error {
+	now := time.Now()
+
+	content, err := format(mailer.from, message, now)
+	if err != nil {
+		return err
+	}
+
+	// Names sort in the order the mails were sent in, and never clash. Only the owner can read the file.
+	file, err := os.CreateTemp(mailer.directory, fmt.Sprintf("%d-*.eml", now.UnixNano()))
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
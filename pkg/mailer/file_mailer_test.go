@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesEachMail(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mails")
+
+	mailer, err := NewFileMailer(directory, "notes@example.com")
+	if err != nil {
+		t.Fatalf("new file mailer: %s", err)
+	}
+
+	info, err := os.Stat(directory)
+	if err != nil {
+		t.Fatalf("stat the directory: %s", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("directory mode = %o, want 700", info.Mode().Perm())
+	}
+
+	subjects := []string{"Verify your email", "Reset your password"}
+	for _, subject := range subjects {
+		err = mailer.Send(context.Background(), &Message{To: "ada@example.com", Subject: subject, Body: "Hello Ada,\n\ntoken=abc123\n"})
+		if err != nil {
+			t.Fatalf("send %q: %s", subject, err)
+		}
+	}
+
+	names, err := filepath.Glob(filepath.Join(directory, "*.eml"))
+	if err != nil {
+		t.Fatalf("list the mails: %s", err)
+	}
+	if len(names) != len(subjects) {
+		t.Fatalf("%d mails written, want %d", len(names), len(subjects))
+	}
+
+	// The names sort in the order the mails were sent in.
+	for i, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("stat %s: %s", name, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %o, want 600", name, info.Mode().Perm())
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("open %s: %s", name, err)
+		}
+		defer file.Close()
+
+		message, err := mail.ReadMessage(file)
+		if err != nil {
+			t.Fatalf("parse %s: %s", name, err)
+		}
+
+		if got := message.Header.Get("From"); got != "notes@example.com" {
+			t.Errorf("From = %q, want %q", got, "notes@example.com")
+		}
+		if got := message.Header.Get("To"); got != "ada@example.com" {
+			t.Errorf("To = %q, want %q", got, "ada@example.com")
+		}
+		if got := message.Header.Get("Subject"); got != subjects[i] {
+			t.Errorf("Subject = %q, want %q", got, subjects[i])
+		}
+
+		content, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+		if err != nil {
+			t.Fatalf("decode %s: %s", name, err)
+		}
+		if !strings.Contains(string(content), "token=abc123\r\n") {
+			t.Errorf("body = %q, want it to hold the token", content)
+		}
+	}
+}
+
+func TestFileMailerRefusesInvalidRecipient(t *testing.T) {
+	directory := t.TempDir()
+
+	mailer, err := NewFileMailer(directory, "notes@example.com")
+	if err != nil {
+		t.Fatalf("new file mailer: %s", err)
+	}
+
+	err = mailer.Send(context.Background(), &Message{To: "not an address", Subject: "Hello", Body: "Hello"})
+	if err == nil {
+		t.Fatal("send to an invalid address succeeded")
+	}
+
+	names, _ := filepath.Glob(filepath.Join(directory, "*"))
+	if len(names) != 0 {
+		t.Errorf("%d files written for a refused mail, want none", len(names))
+	}
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+)
+
+// Plain text mail to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sends the mails of the application, like the tokens to reset passwords.
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// Mailer selected at startup, used by the helpers.
+var MailerObject Mailer
+
+// Opens the mailer of the configuration and makes it the one in use.
+func Open(mailConfig config.MailConfig) (Mailer, error) {
+	var mailer Mailer
+	var err error
+
+	switch mailConfig.Mailer {
+	case config.FileMailer:
+		mailer, err = NewFileMailer(mailConfig.Drop_Directory, mailConfig.From)
+	case config.SMTPMailer:
+		mailer = NewSMTPMailer(mailConfig)
+	default:
+		err = fmt.Errorf("unknown mailer: %s", mailConfig.Mailer)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	MailerObject = mailer
+	return mailer, nil
+}
+
+// Formats the message as an internet message from the address, with its body quoted-printable.
+func format(from string, message *Message, now time.Time) ([]byte, error) {
+	// Addresses and subjects are single header lines, anything splitting them would add headers.
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return nil, fmt.Errorf("mail header contains a line break")
+	}
+
+	_, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient address %q: %w", message.To, err)
+	}
+
+	messageId := make([]byte, 16)
+	_, err = rand.Read(messageId)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "From: %s\r\n", from)
+	fmt.Fprintf(&content, "To: %s\r\n", message.To)
+	fmt.Fprintf(&content, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&content, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&content, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(messageId), domain)
+	content.WriteString("MIME-Version: 1.0\r\n")
+	content.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	content.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&content)
+	_, err = writer.Write([]byte(strings.ReplaceAll(message.Body, "\n", "\r\n")))
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return content.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+)
+
+// Mailer sending the mails through an SMTP server, over TLS whenever the server offers STARTTLS.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(mailConfig config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{
+		host:     mailConfig.SMTP_Host,
+		port:     mailConfig.SMTP_Port,
+		username: mailConfig.SMTP_Username,
+		password: mailConfig.SMTP_Password,
+		from:     mailConfig.From,
+	}
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, message *Message) error {
+	content, err := format(mailer.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(mailer.host, mailer.port))
+	if err != nil {
+		return err
+	}
+
+	// The whole conversation with the server ends with the context.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, mailer.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: mailer.host})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Plain authentication is refused over unencrypted connections to any host but the local one.
+	if mailer.username != "" {
+		err = client.Auth(smtp.PlainAuth("", mailer.username, mailer.password, mailer.host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(mailer.from)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(message.To)
+	if err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(content)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
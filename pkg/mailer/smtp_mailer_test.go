@@ -0,0 +1,167 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+)
+
+// What the fake server was told during one conversation.
+type smtpSession struct {
+	mailFrom string
+	rcptTo   []string
+	data     string
+}
+
+// Starts an SMTP server on a free local port taking one mail without TLS or authentication,
+// returning its port and the session once the conversation is over.
+func startFakeSMTPServer(t *testing.T) (string, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		var session smtpSession
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 fake ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				reply("250-fake")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				// Parameters like BODY=8BITMIME follow the address.
+				session.mailFrom = strings.Fields(line[len("MAIL FROM:"):])[0]
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				session.rcptTo = append(session.rcptTo, line[len("RCPT TO:"):])
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(dataLine, "."))
+				}
+				session.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				sessions <- session
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port, sessions
+}
+
+func TestSMTPMailerSendsPasswordReset(t *testing.T) {
+	port, sessions := startFakeSMTPServer(t)
+
+	mailer := NewSMTPMailer(config.MailConfig{
+		Mailer:    config.SMTPMailer,
+		From:      "notes@example.com",
+		SMTP_Host: "127.0.0.1",
+		SMTP_Port: port,
+	})
+
+	body := "Hello Ada,\n\nUse the link below to reset your password, it works for the next hour:\n\n" +
+		"http://localhost:8000/users/password/reset?token=abc123\n"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := mailer.Send(ctx, &Message{To: "ada@example.com", Subject: "Reset your password", Body: body})
+	if err != nil {
+		t.Fatalf("send: %s", err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-ctx.Done():
+		t.Fatal("the server never got the mail")
+	}
+
+	if session.mailFrom != "<notes@example.com>" {
+		t.Errorf("MAIL FROM = %q, want %q", session.mailFrom, "<notes@example.com>")
+	}
+	if len(session.rcptTo) != 1 || session.rcptTo[0] != "<ada@example.com>" {
+		t.Errorf("RCPT TO = %q, want only %q", session.rcptTo, "<ada@example.com>")
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("parse the mail: %s", err)
+	}
+
+	for header, want := range map[string]string{
+		"From":                      "notes@example.com",
+		"To":                        "ada@example.com",
+		"Subject":                   "Reset your password",
+		"Content-Transfer-Encoding": "quoted-printable",
+	} {
+		if got := message.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	content, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatalf("decode the body: %s", err)
+	}
+
+	want := strings.ReplaceAll(body, "\n", "\r\n")
+	if string(content) != want {
+		t.Errorf("body = %q, want %q", content, want)
+	}
+}
+
+func TestSMTPMailerRefusesHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer(config.MailConfig{Mailer: config.SMTPMailer, From: "notes@example.com", SMTP_Host: "127.0.0.1", SMTP_Port: "1"})
+
+	// Nothing listens on the port, the mail has to be refused before connecting.
+	err := mailer.Send(context.Background(), &Message{To: "ada@example.com", Subject: "Reset\r\nBcc: eve@example.com", Body: "Hello"})
+	if err == nil || !strings.Contains(err.Error(), "line break") {
+		t.Errorf("send = %v, want an error about the line break", err)
+	}
+}
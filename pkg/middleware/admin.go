@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var adminConfig config.AdminConfig
+
+// Sets the admin key, once at startup before any request is served.
+func SetAdminConfig(admin config.AdminConfig) {
+	adminConfig = admin
+}
+
+// Lets only requests with the configured admin key in the X-Admin-Key header through, or without the header,
+// requests of a login session of an admin. Without a configured key only admins are let through.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Admin-Key") == "" && c.GetHeader("token") != "" {
+			if authenticateRequest(c, false) && hasRole(c, models.AdminRole) {
+				c.Next()
+			}
+			return
+		}
+
+		if adminConfig.Key == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: Admin endpoints need the token of an admin, no admin key is configured."})
+			c.Abort()
+			logger.Log.Println("Error: Admin endpoint called without an admin key configured.")
+			return
+		}
+
+		adminKey := c.GetHeader("X-Admin-Key")
+		if subtle.ConstantTimeCompare([]byte(adminKey), []byte(adminConfig.Key)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Invalid admin key."})
+			c.Abort()
+			logger.Log.Printf("Error: Invalid admin key used from ip: %s.", c.ClientIP())
+			return
+		}
+
+		c.Next()
+	}
+}
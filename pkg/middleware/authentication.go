@@ -1,39 +1,115 @@
-package middleware
-
-import (
-	"net/http"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
-	"github.com/gin-gonic/gin"
-)
-
-// Write the authenticate function.
-
-func Authenticate() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		clientToken := c.Request.Header.Get("token")
-		if clientToken == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: No token provided, authentication cannot be done."})
-			c.Abort()
-			logger.Log.Println("Error: No token provided, authentication cannot be done.")
-			return
-		}
-
-		claims, err := helper.ValidateToken(clientToken)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			c.Abort()
-			logger.Log.Printf("Error: %s", err.Error())
-			return
-		}
-
-		c.Set("email", claims.Email)
-		c.Set("firstName", claims.First_Name)
-		c.Set("lastName", claims.Last_Name)
-		c.Set("userId", claims.User_Id)
-		c.Set("refreshToken", claims.Refresh_Token)
-
-		c.Next()
-	}
-}
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// Write the authenticate function.
+
+// Authenticates the request with the access token of a login session or with a personal access token,
+// whose scopes RequireScope checks.
+func Authenticate() gin.HandlerFunc {
+	return authenticate(true)
+}
+
+// Authenticates the request only with the access token of a login session, keeping personal access tokens
+// from managing the account.
+func AuthenticateSession() gin.HandlerFunc {
+	return authenticate(false)
+}
+
+func authenticate(acceptAccessTokens bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticateRequest(c, acceptAccessTokens) {
+			c.Next()
+		}
+	}
+}
+
+// Authenticates the request from its token header, setting who made it in the context.
+// Reports whether it was authenticated, having responded with the error otherwise.
+func authenticateRequest(c *gin.Context, acceptAccessTokens bool) bool {
+	clientToken := c.Request.Header.Get("token")
+	if clientToken == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error: No token provided, authentication cannot be done."})
+		c.Abort()
+		logger.Log.Println("Error: No token provided, authentication cannot be done.")
+		return false
+	}
+
+	if helper.IsAccessToken(clientToken) {
+		if !acceptAccessTokens {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Error: Personal access tokens cannot be used for this request, log in instead."})
+			c.Abort()
+			logger.Log.Println("Error: Personal access token passed for a request of a login session.")
+			return false
+		}
+
+		return authenticateAccessToken(c, clientToken)
+	}
+
+	claims, err := helper.ValidateToken(clientToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		logger.Log.Printf("Error: %s", err.Error())
+		return false
+	}
+
+	// Reject tokens revoked through logout before their expiry.
+	revoked, err := helper.IsTokenRevoked(c.Request.Context(), claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		logger.Log.Printf("Error: Problem while checking token revocation.\n\tError: %s", err.Error())
+		return false
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Token has been revoked."})
+		c.Abort()
+		logger.Log.Printf("Error: Revoked token used by user id: %s.", claims.User_Id)
+		return false
+	}
+
+	c.Set("email", claims.Email)
+	c.Set("firstName", claims.First_Name)
+	c.Set("lastName", claims.Last_Name)
+	c.Set("userId", claims.User_Id)
+	c.Set("tokenId", claims.ID)
+	c.Set("tokenFamily", claims.Token_Family)
+	c.Set("tokenExpiresAt", claims.ExpiresAt.Unix())
+	c.Set("role", claims.Role)
+
+	return true
+}
+
+func authenticateAccessToken(c *gin.Context, clientToken string) bool {
+	accessToken, foundUser, err := helper.ValidateAccessToken(c.Request.Context(), clientToken)
+	if err == helper.ErrInvalidAccessToken {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error: Invalid personal access token."})
+		c.Abort()
+		logger.Log.Println("Error: Invalid personal access token passed.")
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while checking the personal access token.\n\tError: %s", err.Error())})
+		c.Abort()
+		logger.Log.Printf("Error: Problem while checking the personal access token.\n\tError: %s", err.Error())
+		return false
+	}
+
+	c.Set("email", *foundUser.Email)
+	c.Set("firstName", *foundUser.First_Name)
+	c.Set("lastName", *foundUser.Last_Name)
+	c.Set("userId", foundUser.UserID)
+	c.Set("accessTokenId", accessToken.ID.Hex())
+	c.Set("scopes", accessToken.Scopes)
+	c.Set("role", foundUser.Role)
+
+	return true
+}
@@ -1,29 +1,34 @@
-package middleware
-
-import (
-	"net/http"
-	"time"
-
-	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
-	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
-)
-
-var globalRateLimiter *rate.Limiter
-
-func init() {
-	globalRateLimiter = rate.NewLimiter(rate.Every(time.Second), 5)
-}
-
-func ExternalRateLimiter() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if !globalRateLimiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{"Error": "Error: Too many requests made."})
-			c.Abort()
-			logger.Log.Println("Error: Too many requests made.")
-			return
-		}
-
-		c.Next()
-	}
-}
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+var (
+	rateLimitConfig   = config.Default().Rate_Limit
+	globalRateLimiter = rate.NewLimiter(rate.Limit(rateLimitConfig.Global_Rate), rateLimitConfig.Global_Burst)
+)
+
+// Sets the rate limits, once at startup before any request is served.
+func SetRateLimitConfig(rateLimit config.RateLimitConfig) {
+	rateLimitConfig = rateLimit
+	globalRateLimiter = rate.NewLimiter(rate.Limit(rateLimit.Global_Rate), rateLimit.Global_Burst)
+}
+
+func ExternalRateLimiter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !globalRateLimiter.Allow() {
+			c.JSON(http.StatusTooManyRequests, gin.H{"Error": "Error: Too many requests made."})
+			c.Abort()
+			logger.Log.Println("Error: Too many requests made.")
+			return
+		}
+
+		c.Next()
+	}
+}
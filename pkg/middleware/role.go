@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// Lets only authenticated users with the role, or a role above it, through.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasRole(c, role) {
+			c.Next()
+		}
+	}
+}
+
+// Reports whether the authenticated user has the role, having responded with the error otherwise.
+func hasRole(c *gin.Context, role string) bool {
+	if !helper.HasRole(c.GetString("role"), role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: The %s role is required.", role)})
+		c.Abort()
+		logger.Log.Printf("Error: User id: %s without the %s role kept from: %s %s.", c.GetString("userId"), role, c.Request.Method, c.FullPath())
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Keeps requests authenticated with a personal access token without the scope from the route.
+// Requests of login sessions may do everything the user may.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CheckScope(c, scope) {
+			c.Next()
+		}
+	}
+}
+
+// Checks the request may use the scope, responding when its personal access token does not have it.
+// For handlers where only some requests need the scope.
+func CheckScope(c *gin.Context, scope string) bool {
+	scopes, ok := c.Get("scopes")
+	if !ok {
+		return true
+	}
+
+	if !slices.Contains(scopes.([]string), scope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: The personal access token does not have the scope: %s.", scope)})
+		c.Abort()
+		logger.Log.Printf("Error: Access token id: %s without the scope: %s used by user id: %s.", c.GetString("accessTokenId"), scope, c.GetString("userId"))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// What the restricted actions let users do, for the responses.
+var actionDescriptions = map[string]string{
+	config.CreateAction: "create notes and notebooks",
+	config.ShareAction:  "share notes",
+}
+
+// Keeps authenticated users who have not verified their email from the action, if the configuration restricts it.
+func RequireVerifiedEmail(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CheckVerifiedEmail(c, action) {
+			c.Next()
+		}
+	}
+}
+
+// Checks the authenticated user may take the action, responding when they have not verified their email.
+// For handlers where only some requests take the action.
+func CheckVerifiedEmail(c *gin.Context, action string) bool {
+	userId := c.GetString("userId")
+	allowed, err := helper.IsAllowedAction(c.Request.Context(), userId, action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error: Problem while finding the user.\n\tError: %s", err.Error())})
+		c.Abort()
+		logger.Log.Printf("Error: Problem while finding the user with user id: %s.\n\tError: %s", userId, err.Error())
+		return false
+	}
+
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Error: The email of the account has to be verified to %s.", actionDescriptions[action])})
+		c.Abort()
+		logger.Log.Printf("Error: Unverified user id: %s kept from the action: %s.", userId, action)
+		return false
+	}
+
+	return true
+}
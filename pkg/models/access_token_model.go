@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes granted to personal access tokens, each allowing a part of the notes api.
+const (
+	NotesReadScope  = "notes:read"
+	NotesWriteScope = "notes:write"
+	NotesShareScope = "notes:share"
+)
+
+var AccessTokenScopes = []string{NotesReadScope, NotesWriteScope, NotesShareScope}
+
+// Long-lived token a user creates for scripts and integrations, only its hash is kept.
+type AccessToken struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	User_Id      string             `json:"userId" bson:"userId"`
+	Name         string             `json:"name" bson:"name"`
+	Token_Hash   string             `json:"-" bson:"tokenHash"`
+	Scopes       []string           `json:"scopes" bson:"scopes"`
+	Created_At   time.Time          `json:"createdAt" bson:"createdAt"`
+	Expires_At   *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`   // never expires when missing
+	Last_Used_At *time.Time         `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"` // never used when missing
+}
+
+// Body of a request to create a personal access token.
+type CreateAccessTokenRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Expires_At *time.Time `json:"expiresAt"`
+}
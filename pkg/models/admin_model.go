@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Account of a user as the admins see it, without the password and the secrets.
+type UserAccount struct {
+	User_Id        string    `json:"userId"`
+	First_Name     *string   `json:"firstName"`
+	Last_Name      *string   `json:"lastName"`
+	Email          *string   `json:"email"`
+	Role           string    `json:"role"`
+	Disabled       bool      `json:"disabled"`
+	Email_Verified bool      `json:"emailVerified"`
+	TOTP_Enabled   bool      `json:"totpEnabled"`
+	Created_At     time.Time `json:"createdAt"`
+	Last_Login     time.Time `json:"lastLogin"`
+}
+
+// Body of a request of an admin to change the role of a user.
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Body of a request of an admin to reset the password of a user. Without a password the user is mailed a link to set one.
+type AdminPasswordResetRequest struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AccountLockout = "account"
+	IPLockout      = "ip"
+)
+
+// Failed logins counted under a key, an email or an ip address.
+type LoginFailures struct {
+	Key             string    `json:"key" bson:"_id"`
+	Failures        int       `json:"failures" bson:"failures"`
+	Last_Failure_At time.Time `json:"lastFailureAt" bson:"lastFailureAt"`
+	Expires_At      time.Time `json:"expiresAt" bson:"expiresAt"` // the failures are forgotten after this time
+}
+
+// Record of a login locked out after too many failures, kept for auditing.
+type Lockout struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	Kind         string             `json:"kind" bson:"kind"`
+	Email        string             `json:"email,omitempty" bson:"email,omitempty"`
+	IP_Address   string             `json:"ipAddress" bson:"ipAddress"`
+	Failures     int                `json:"failures" bson:"failures"`
+	Locked_At    time.Time          `json:"lockedAt" bson:"lockedAt"`
+	Locked_Until time.Time          `json:"lockedUntil" bson:"lockedUntil"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// One-time code logging in without the TOTP device, only its hash is kept.
+type RecoveryCode struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	User_Id   string             `json:"userId" bson:"userId"`
+	Code_Hash string             `json:"-" bson:"codeHash"`
+}
+
+// Body of the second step of a login, with either a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	MFA_Token     string `json:"mfaToken"`
+	Code          string `json:"code"`
+	Recovery_Code string `json:"recoveryCode"`
+}
+
+// Body of the requests confirmed with a current TOTP code.
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Body of a request to disable TOTP, confirmed with the password and a TOTP or recovery code.
+type DisableTOTPRequest struct {
+	Password      string `json:"password"`
+	Code          string `json:"code"`
+	Recovery_Code string `json:"recoveryCode"`
+}
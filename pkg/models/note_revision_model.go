@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Immutable snapshot of the content of a note after an update.
+type NoteRevision struct {
+	ID            primitive.ObjectID `bson:"_id"`                              // will be created
+	Note_Id       string             `json:"noteId" bson:"noteId"`             // hex id of the revised note
+	Revision      int64              `json:"revision" bson:"revision"`         // starts at 1 and increases by 1 per update
+	Author_Id     string             `json:"authorId" bson:"authorId"`         // user who made the update
+	Header        *string            `json:"header" bson:"header"`             // header after the update
+	Data          *string            `json:"notesData" bson:"notesData"`       // notes data after the update
+	Content_Hash  string             `json:"contentHash" bson:"contentHash"`   // sha256 of header and notes data
+	Restored_From *int64             `json:"restoredFrom" bson:"restoredFrom"` // revision restored by this one, if any
+	Created_At    time.Time          `json:"createdAt" bson:"createdAt"`       // will be created
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Folder of notes of a user, which can hold other notebooks.
+type Notebook struct {
+	ID         primitive.ObjectID `bson:"_id"`                        // will be created
+	User_Id    string             `json:"userId" bson:"userId"`       // will be taken from middleware
+	Name       string             `json:"name" bson:"name"`           // will be provided in request, unique among its siblings
+	Parent_Id  *string            `json:"parentId" bson:"parentId"`   // hex id of the notebook holding it, nil at the top
+	Ancestors  []string           `json:"ancestors" bson:"ancestors"` // hex ids of the notebooks above it, from the top down
+	Created_At time.Time          `json:"createdAt" bson:"createdAt"` // will be created
+	Updated_At time.Time          `json:"updatedAt" bson:"updatedAt"` // will be created
+}
@@ -1,19 +1,48 @@
-package models
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-type NoteData struct {
-	ID            primitive.ObjectID `bson:"_id"`                              // will be created
-	User_Id       *string            `json:"userId" bson:"userId"`             // will be taken from middleware
-	Header        *string            `json:"header" bson:"header"`             // will be provided in request
-	Unique_Header *string            `json:"uniqueHeader" bson:"uniqueHeader"` // will be created
-	Email         *string            `json:"email" bson:"email"`               // will be taken from middleware
-	Data          *string            `json:"notesData" bson:"notesData"`       // will be provided in request
-	Sharable      *bool              `json:"sharable" bson:"sharable"`         // will be provided in request
-	Created_At    time.Time          `json:"createdAt" bson:"createdAt"`       // will be created
-	Updated_At    time.Time          `json:"updatedAt" bson:"updatedAt"`       // will be created
-}
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a user can have on a note, from the most to the least privileged.
+const (
+	OwnerRole     = "owner"
+	EditorRole    = "editor"
+	CommenterRole = "commenter"
+	ViewerRole    = "viewer"
+)
+
+type NoteData struct {
+	ID            primitive.ObjectID `bson:"_id"`                              // will be created
+	User_Id       *string            `json:"userId" bson:"userId"`             // will be taken from middleware
+	Header        *string            `json:"header" bson:"header"`             // will be provided in request
+	Unique_Header *string            `json:"uniqueHeader" bson:"uniqueHeader"` // will be created
+	Email         *string            `json:"email" bson:"email"`               // will be taken from middleware
+	Data          *string            `json:"notesData" bson:"notesData"`       // will be provided in request
+	Sharable      *bool              `json:"sharable" bson:"sharable"`         // will be provided in request
+	Notebook_Id   *string            `json:"notebookId" bson:"notebookId"`     // will be provided in request, nil outside of notebooks
+	Workspace_Id  *string            `json:"workspaceId" bson:"workspaceId"`   // will be provided in request, nil for notes of the user alone
+	Access        []NoteAccess       `json:"access" bson:"access"`             // will be changed through share endpoints
+	Tags          []string           `json:"tags" bson:"tags"`                 // will be provided in request or changed through tag endpoints
+	Version       int64              `json:"version" bson:"version"`           // will be created and increased on every change
+	Created_At    time.Time          `json:"createdAt" bson:"createdAt"`       // will be created
+	Updated_At    time.Time          `json:"updatedAt" bson:"updatedAt"`       // will be created
+	Deleted_At    *time.Time         `json:"deletedAt" bson:"deletedAt"`       // will be set when the note is moved to the trash, nil otherwise
+}
+
+// Grant of a role on a note to a user other than the owner.
+type NoteAccess struct {
+	User_Id    string    `json:"userId" bson:"userId"`
+	Email      *string   `json:"email" bson:"email"`
+	Role       string    `json:"role" bson:"role"`
+	Granted_By string    `json:"grantedBy" bson:"grantedBy"`
+	Granted_At time.Time `json:"grantedAt" bson:"grantedAt"`
+}
+
+// Number of notes of a user with the tag.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Request of a user to reset a forgotten password, only the hash of the token mailed to them is kept.
+type PasswordReset struct {
+	ID         primitive.ObjectID `bson:"_id"`                        // will be created
+	User_Id    string             `json:"userId" bson:"userId"`       // will be taken from the user with the email
+	Token_Hash string             `json:"-" bson:"tokenHash"`         // hash of the token mailed to the user
+	Created_At time.Time          `json:"createdAt" bson:"createdAt"` // will be created
+	Expires_At time.Time          `json:"expiresAt" bson:"expiresAt"` // the token cannot be used after it
+}
+
+// Body of a request for a mail to reset the password.
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// Body of a request to set a new password with the mailed token.
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Login of a user on a device, its hex id is the token family of the tokens issued to it.
+type Session struct {
+	ID                 primitive.ObjectID `bson:"_id"`                          // will be created
+	User_Id            string             `json:"userId" bson:"userId"`         // will be taken from the login
+	Refresh_Token_Hash string             `json:"-" bson:"refreshTokenHash"`    // hash of the only refresh token currently valid
+	User_Agent         string             `json:"userAgent" bson:"userAgent"`   // of the device which logged in
+	IP_Address         string             `json:"ipAddress" bson:"ipAddress"`   // the session was last used from
+	Created_At         time.Time          `json:"createdAt" bson:"createdAt"`   // will be created
+	Last_Used_At       time.Time          `json:"lastUsedAt" bson:"lastUsedAt"` // last login or refresh
+	Expires_At         time.Time          `json:"expiresAt" bson:"expiresAt"`   // when its refresh token expires
+}
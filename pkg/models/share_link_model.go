@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Link opening a note to anyone holding its token, without an account. Only the hashes of the token and of
+// the password are kept.
+type ShareLink struct {
+	ID                 primitive.ObjectID `json:"id" bson:"_id"`                                        // will be created
+	Note_Id            string             `json:"noteId" bson:"noteId"`                                 // will be taken from the url
+	Role               string             `json:"role" bson:"role"`                                     // viewer
+	Token_Hash         string             `json:"-" bson:"tokenHash"`                                   // hash of the token in the link
+	Password_Hash      *string            `json:"-" bson:"passwordHash,omitempty"`                      // opens without a password when missing
+	Password_Protected bool               `json:"passwordProtected" bson:"passwordProtected"`           // whether the link asks for a password
+	Max_Views          *int64             `json:"maxViews,omitempty" bson:"maxViews,omitempty"`         // can be opened any number of times when missing
+	Views              int64              `json:"views" bson:"views"`                                   // increased every time the link is opened
+	Created_By         string             `json:"createdBy" bson:"createdBy"`                           // will be taken from middleware
+	Created_At         time.Time          `json:"createdAt" bson:"createdAt"`                           // will be created
+	Expires_At         *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`       // never expires when missing
+	Last_Viewed_At     *time.Time         `json:"lastViewedAt,omitempty" bson:"lastViewedAt,omitempty"` // never opened when missing
+}
+
+// Body of a request to create a share link, giving the viewer role by default.
+type CreateShareLinkRequest struct {
+	Role       string     `json:"role"`
+	Password   string     `json:"password"`
+	Expires_At *time.Time `json:"expiresAt"`
+	Max_Views  *int64     `json:"maxViews"`
+}
+
+// What a share link shows of a note, leaving out who owns it and who else it is shared with.
+type SharedNote struct {
+	Header     *string   `json:"header"`
+	Data       *string   `json:"notesData"`
+	Tags       []string  `json:"tags"`
+	Updated_At time.Time `json:"updatedAt"`
+	Role       string    `json:"role"` // role the link gives on the note
+}
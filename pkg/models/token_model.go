@@ -1,12 +1,50 @@
-package models
-
-import "github.com/dgrijalva/jwt-go"
-
-type SignedDetails struct {
-	Email         string `json:"email" bson:"email"`
-	First_Name    string `json:"firstName" bson:"firstName"`
-	Last_Name     string `json:"lastName" bson:"lastName"`
-	User_Id       string `json:"userId" bson:"userId"`
-	Refresh_Token string `json:"refreshToken" bson:"refreshToken"`
-	jwt.StandardClaims
-}
+package models
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+	MFATokenType     = "mfa" // challenge of a login waiting for the second factor
+)
+
+type SignedDetails struct {
+	Email        string `json:"email" bson:"email"`
+	First_Name   string `json:"firstName" bson:"firstName"`
+	Last_Name    string `json:"lastName" bson:"lastName"`
+	User_Id      string `json:"userId" bson:"userId"`
+	Token_Type   string `json:"tokenType" bson:"tokenType"`           // access, refresh or mfa
+	Token_Family string `json:"tokenFamily" bson:"tokenFamily"`       // hex id of the session the token was issued to
+	Role         string `json:"role,omitempty" bson:"role,omitempty"` // of the user, in access tokens
+	jwt.RegisteredClaims
+}
+
+// Entry of the token deny-list, removed by the database once the token would have expired anyway.
+type RevokedToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Token_Id   string             `json:"tokenId" bson:"tokenId"`
+	User_Id    string             `json:"userId" bson:"userId"`
+	Revoked_At time.Time          `json:"revokedAt" bson:"revokedAt"`
+	Expires_At time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
+
+// Public key of the token signing keys, as published in the JSON Web Key Set.
+type JSONWebKey struct {
+	Key_Type  string `json:"kty"`
+	Key_Id    string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n,omitempty"`   // of RSA keys
+	Exponent  string `json:"e,omitempty"`   // of RSA keys
+	Curve     string `json:"crv,omitempty"` // of Ed25519 keys
+	X         string `json:"x,omitempty"`   // of Ed25519 keys
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -1,30 +1,54 @@
-package models
-
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-type UserDataClient struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	First_Name *string            `json:"firstName" bson:"firstName"`
-	Last_Name  *string            `json:"lastName" bson:"lastName"`
-	Password   *string            `json:"password" bson:"password"`
-	Email      *string            `json:"email" bson:"email"`
-	Token      *string            `json:"token" bson:"token"`
-	UserID     string             `json:"userId" bson:"userId"`
-}
-
-type UserDataServer struct {
-	ID            primitive.ObjectID `bson:"_id"`
-	First_Name    *string            `json:"firstName" bson:"firstName"`
-	Last_Name     *string            `json:"lastName" bson:"lastName"`
-	Password      *string            `json:"password" bson:"password"`
-	Email         *string            `json:"email" bson:"email"`
-	Created_At    time.Time          `json:"createdAt" bson:"createdAt"`
-	Updated_At    time.Time          `json:"updatedAt" bson:"updatedAt"`
-	Last_Login    time.Time          `json:"lastLogin" bson:"lastLogin"`
-	Refresh_Token *string            `json:"refreshToken" bson:"refreshToken"`
-	UserID        string             `json:"userId" bson:"userId"`
-}
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles of the users, each allowed what the ones before it are.
+const (
+	UserRole      = "user"
+	ModeratorRole = "moderator" // may view and delete the notes of any user
+	AdminRole     = "admin"     // may also manage the accounts of the users
+)
+
+var Roles = []string{UserRole, ModeratorRole, AdminRole}
+
+type UserDataClient struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	First_Name    *string            `json:"firstName" bson:"firstName"`
+	Last_Name     *string            `json:"lastName" bson:"lastName"`
+	Password      *string            `json:"password" bson:"password"`
+	Email         *string            `json:"email" bson:"email"`
+	Token         *string            `json:"token" bson:"token"`
+	Refresh_Token *string            `json:"refreshToken" bson:"refreshToken"`
+	UserID        string             `json:"userId" bson:"userId"`
+}
+
+type UserDataServer struct {
+	ID                      primitive.ObjectID `bson:"_id"`
+	First_Name              *string            `json:"firstName" bson:"firstName"`
+	Last_Name               *string            `json:"lastName" bson:"lastName"`
+	Password                *string            `json:"password" bson:"password"`
+	Email                   *string            `json:"email" bson:"email"`
+	Created_At              time.Time          `json:"createdAt" bson:"createdAt"`
+	Updated_At              time.Time          `json:"updatedAt" bson:"updatedAt"`
+	Last_Login              time.Time          `json:"lastLogin" bson:"lastLogin"`
+	Revoked_Before          time.Time          `json:"revokedBefore" bson:"revokedBefore"` // tokens issued up to this time are revoked
+	Email_Verified          bool               `json:"emailVerified" bson:"emailVerified"`
+	Verification_Token_Hash string             `json:"-" bson:"verificationTokenHash,omitempty"` // hash of the token mailed to verify the email, cleared once verified
+	Verification_Sent_At    time.Time          `json:"verificationSentAt" bson:"verificationSentAt"`
+	Verification_Expires_At time.Time          `json:"-" bson:"verificationExpiresAt"`
+	TOTP_Secret             string             `json:"-" bson:"totpSecret,omitempty"` // base32 secret of the enabled or the pending TOTP enrollment
+	TOTP_Enabled            bool               `json:"totpEnabled" bson:"totpEnabled"`
+	TOTP_Last_Step          int64              `json:"-" bson:"totpLastStep"` // time step of the last TOTP code used, codes of it and earlier ones are refused
+	Role                    string             `json:"role" bson:"role"`
+	Disabled                bool               `json:"disabled" bson:"disabled"` // disabled users cannot log in
+	UserID                  string             `json:"userId" bson:"userId"`
+}
+
+// Body of a request for another mail to verify the email.
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
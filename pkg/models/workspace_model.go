@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a member can have in a workspace, from the most to the least privileged.
+// Owners and admins manage the workspace and its notes, members edit the notes and viewers read them.
+const (
+	WorkspaceOwnerRole  = "owner"
+	WorkspaceAdminRole  = "admin"
+	WorkspaceMemberRole = "member"
+	WorkspaceViewerRole = "viewer"
+)
+
+// Space of notes shared by a team, the notes in it belong to the workspace rather than to the member who wrote them.
+type Workspace struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`              // will be created
+	Name       string             `json:"name" bson:"name"`           // will be provided in request
+	Members    []WorkspaceMember  `json:"members" bson:"members"`     // will be changed through the member and invitation endpoints
+	Version    int64              `json:"version" bson:"version"`     // will be created and increased on every change
+	Created_At time.Time          `json:"createdAt" bson:"createdAt"` // will be created
+	Updated_At time.Time          `json:"updatedAt" bson:"updatedAt"` // will be created
+}
+
+// Member of a workspace and their role in it.
+type WorkspaceMember struct {
+	User_Id   string    `json:"userId" bson:"userId"`
+	Email     *string   `json:"email" bson:"email"`
+	Role      string    `json:"role" bson:"role"`
+	Joined_At time.Time `json:"joinedAt" bson:"joinedAt"`
+}
+
+// Invitation mailed to join a workspace, only the hash of its token is kept.
+type WorkspaceInvitation struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`                  // will be created
+	Workspace_Id string             `json:"workspaceId" bson:"workspaceId"` // will be taken from the url
+	Email        string             `json:"email" bson:"email"`             // will be provided in request, lower cased
+	Role         string             `json:"role" bson:"role"`               // role the invited user gets on accepting
+	Token_Hash   string             `json:"-" bson:"tokenHash"`             // hash of the token mailed to the invited user
+	Invited_By   string             `json:"invitedBy" bson:"invitedBy"`     // will be taken from middleware
+	Created_At   time.Time          `json:"createdAt" bson:"createdAt"`     // will be created
+	Expires_At   time.Time          `json:"expiresAt" bson:"expiresAt"`     // the invitation cannot be accepted after it
+}
+
+// Body of a request to create or rename a workspace.
+type WorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+// Body of a request to invite someone into a workspace, as a member by default.
+type WorkspaceInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// Body of a request to change the role of a member.
+type WorkspaceMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Body of a request to accept or decline an invitation with its mailed token.
+type WorkspaceInvitationResponse struct {
+	Token string `json:"token"`
+}
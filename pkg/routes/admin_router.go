@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+Create routes for the administration of the server and its users.
+
+	Users have one of the roles user, moderator and admin, each allowed what the roles below it are.
+	The role is carried in the access tokens, changing it revokes the tokens of the user. The emails
+	configured as admin emails are made admins once they have verified them, at startup or when verifying.
+
+	Admin Endpoints
+
+	These need the login session of an admin, or the admin key in the X-Admin-Key header.
+
+	POST /api/admin/search/reindex: rebuild the search index from every note of the store.
+	GET /api/admin/lockouts?limit=: list the logins locked out after too many failures, newest first.
+	GET /api/admin/users?q=&role=&cursor=&limit=: list the users whose email or names contain the query,
+		optionally only those with the role, in pages.
+	GET /api/admin/users/:id: get the account of a user.
+	PUT /api/admin/users/:id/role: change the role of a user.
+	POST /api/admin/users/:id/disable: disable the account of a user, logging them out and keeping them from logging in.
+	POST /api/admin/users/:id/enable: enable the disabled account of a user again.
+	POST /api/admin/users/:id/logout: log a user out of every session, revoking all of their tokens.
+	POST /api/admin/users/:id/password: set a new password of a user, or without one mail them a link to reset it,
+		logging them out either way.
+
+	Admins cannot change the role of their own account, nor disable it.
+
+	Moderation Endpoints
+
+	These need the login session of a moderator or an admin.
+
+	GET /api/admin/notes/:id: get any note, in the trash too.
+	DELETE /api/admin/notes/:id: delete any note for good, along with its revisions.
+**/
+
+func AdminRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/api/admin/search/reindex", middleware.RequireAdmin(), controllers.ReindexSearch())
+	incomingRoutes.GET("/api/admin/lockouts", middleware.RequireAdmin(), controllers.GetLockouts())
+	incomingRoutes.GET("/api/admin/users", middleware.RequireAdmin(), controllers.GetUsers())
+	incomingRoutes.GET("/api/admin/users/:id", middleware.RequireAdmin(), controllers.GetUserByID())
+	incomingRoutes.PUT("/api/admin/users/:id/role", middleware.RequireAdmin(), controllers.SetUserRole())
+	incomingRoutes.POST("/api/admin/users/:id/disable", middleware.RequireAdmin(), controllers.SetUserDisabled(true))
+	incomingRoutes.POST("/api/admin/users/:id/enable", middleware.RequireAdmin(), controllers.SetUserDisabled(false))
+	incomingRoutes.POST("/api/admin/users/:id/logout", middleware.RequireAdmin(), controllers.LogoutUser())
+	incomingRoutes.POST("/api/admin/users/:id/password", middleware.RequireAdmin(), controllers.ResetUserPassword())
+	incomingRoutes.GET("/api/admin/notes/:id", middleware.AuthenticateSession(), middleware.RequireRole(models.ModeratorRole), controllers.GetAnyNote())
+	incomingRoutes.DELETE("/api/admin/notes/:id", middleware.AuthenticateSession(), middleware.RequireRole(models.ModeratorRole), controllers.DeleteAnyNote())
+}
@@ -1,20 +1,103 @@
-package routes
-
-import (
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
-	"github.com/gin-gonic/gin"
-)
-
-/**
-Create routes for signup and login.
-
-	Authentication Endpoints
-
-	POST /api/auth/signup: create a new user account.
-	POST /api/auth/login: log in to an existing user account and receive an access token.
-**/
-
-func AuthRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.POST("/api/auth/signup", controllers.SignUp())
-	incomingRoutes.POST("/api/auth/login", controllers.Login())
-}
+package routes
+
+import (
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+Create routes for signup, login, token refresh, logout, email verification, two-factor authentication, password resets, sessions and the keys verifying the tokens.
+
+	Authentication Endpoints
+
+	POST /api/auth/signup: create a new user account.
+	POST /api/auth/login: log in to an existing user account and receive an access token,
+		or an mfa challenge token if the account has TOTP enabled.
+	POST /api/auth/login/mfa: finish a login with its mfa challenge token and a TOTP or recovery code,
+		receiving the access token.
+	POST /api/auth/refresh: exchange a refresh token for a new access token and refresh token.
+	POST /api/auth/logout: revoke the access token used for the request and end its session.
+	POST /api/auth/logout-all: revoke every token issued to the authenticated user.
+
+	Failed logins, wrong passwords and second factors alike, are counted per email and per ip address.
+	Each one doubles the wait before the next try, and reaching the threshold locks the login out for a while,
+	answered with 429 and Retry-After. Unknown emails and wrong passwords get the same 401 invalid credentials.
+
+	Verification Endpoints
+
+	Accounts start unverified, a link with a verification token is mailed on signup. The configuration
+	decides what unverified users are kept from: logging in, creating notes and notebooks or sharing notes.
+
+	GET /api/auth/verify?token=...: verify the email of an account with the mailed token.
+	POST /api/auth/verify/resend: mail another token to verify the account with the email, replacing the
+		previous one, at most once per resend interval.
+
+	Two-Factor Endpoints
+
+	TOTP codes are of RFC 6238, six digits every 30 seconds, each accepted once. Recovery codes are hashed and used once.
+
+	GET /api/auth/mfa: tell whether TOTP is enabled for the authenticated user, and how many recovery codes are left.
+	POST /api/auth/mfa/totp: start a TOTP enrollment, receiving its secret and otpauth:// URI.
+	GET /api/auth/mfa/totp/qr: get the otpauth:// URI of the pending TOTP enrollment as a QR code PNG.
+	POST /api/auth/mfa/totp/confirm: enable the pending TOTP enrollment with a code of it, receiving the recovery codes.
+	POST /api/auth/mfa/totp/disable: disable TOTP with the password and a TOTP or recovery code.
+	POST /api/auth/mfa/recovery-codes: replace the recovery codes with new ones, confirmed with a TOTP code.
+
+	Password Endpoints
+
+	Reset tokens are mailed, can be used once and only until they expire. Only their hashes are stored.
+
+	POST /api/auth/password/forgot: mail a token to reset the password of the account with the email,
+		responding alike whether the account exists or not.
+	POST /api/auth/password/reset: set a new password with a mailed token, revoking every token of the user.
+
+	Session Endpoints
+
+	Every login starts a session of its own on the device, holding the hash of its current refresh token.
+
+	GET /api/auth/sessions: list the sessions of the authenticated user, the last used first,
+		marking the one the request was made from.
+	DELETE /api/auth/sessions/:id: revoke a session of the authenticated user, along with all of its tokens.
+
+	Personal Access Token Endpoints
+
+	Personal access tokens let scripts use the notes api without the password. They start with pat_, are passed in the
+	token header like the access tokens of the sessions, and allow only their scopes: notes:read, notes:write and notes:share.
+	Only their hashes are stored. Revoking every token of the user, by logging out everywhere or resetting the password,
+	deletes them too. They cannot be used for the endpoints here, which need a login session.
+
+	POST /api/auth/tokens: create a personal access token with a name, scopes and an optional expiry,
+		responding with the token once.
+	GET /api/auth/tokens: list the personal access tokens of the authenticated user, the newest first, without the tokens themselves.
+	DELETE /api/auth/tokens/:id: revoke a personal access token of the authenticated user.
+
+	Key Endpoints
+
+	GET /.well-known/jwks.json: publish the public keys verifying the tokens, by their kid.
+**/
+
+func AuthRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/api/auth/signup", controllers.SignUp())
+	incomingRoutes.POST("/api/auth/login", controllers.Login())
+	incomingRoutes.POST("/api/auth/login/mfa", controllers.LoginMFA())
+	incomingRoutes.POST("/api/auth/refresh", controllers.RefreshToken())
+	incomingRoutes.POST("/api/auth/logout", middleware.AuthenticateSession(), controllers.Logout())
+	incomingRoutes.POST("/api/auth/logout-all", middleware.AuthenticateSession(), controllers.LogoutAll())
+	incomingRoutes.GET("/api/auth/mfa", middleware.AuthenticateSession(), controllers.GetMFAStatus())
+	incomingRoutes.POST("/api/auth/mfa/totp", middleware.AuthenticateSession(), controllers.EnrollTOTP())
+	incomingRoutes.GET("/api/auth/mfa/totp/qr", middleware.AuthenticateSession(), controllers.GetTOTPQRCode())
+	incomingRoutes.POST("/api/auth/mfa/totp/confirm", middleware.AuthenticateSession(), controllers.ConfirmTOTP())
+	incomingRoutes.POST("/api/auth/mfa/totp/disable", middleware.AuthenticateSession(), controllers.DisableTOTP())
+	incomingRoutes.POST("/api/auth/mfa/recovery-codes", middleware.AuthenticateSession(), controllers.RegenerateRecoveryCodes())
+	incomingRoutes.GET("/api/auth/verify", controllers.VerifyEmail())
+	incomingRoutes.POST("/api/auth/verify/resend", controllers.ResendVerification())
+	incomingRoutes.POST("/api/auth/password/forgot", controllers.ForgotPassword())
+	incomingRoutes.POST("/api/auth/password/reset", controllers.ResetPassword())
+	incomingRoutes.GET("/api/auth/sessions", middleware.AuthenticateSession(), controllers.GetSessions())
+	incomingRoutes.DELETE("/api/auth/sessions/:id", middleware.AuthenticateSession(), controllers.DeleteSessionByID())
+	incomingRoutes.POST("/api/auth/tokens", middleware.AuthenticateSession(), controllers.CreateAccessToken())
+	incomingRoutes.GET("/api/auth/tokens", middleware.AuthenticateSession(), controllers.GetAccessTokens())
+	incomingRoutes.DELETE("/api/auth/tokens/:id", middleware.AuthenticateSession(), controllers.DeleteAccessTokenByID())
+	incomingRoutes.GET("/.well-known/jwks.json", controllers.GetJSONWebKeySet())
+}
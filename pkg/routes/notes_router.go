@@ -1,38 +1,111 @@
-package routes
-
-import (
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
-	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
-	"github.com/gin-gonic/gin"
-)
-
-/**
-Add in a middleware for authentication purpose.
-
-Create routes for doing various functions with the notes collection.
-
-	Note Endpoints
-
-	GET /api/notes: get a list of all notes for the authenticated user.
-	GET /api/notes/:id: get a note by ID for the authenticated user.
-	POST /api/notes: create a new note for the authenticated user.
-	PUT /api/notes/:id: update an existing note by ID for the authenticated user.
-	DELETE /api/notes/:id: delete a note by ID for the authenticated user.
-	POST /api/notes/:id/share: share a note with another user for the authenticated user.
-	GET /api/search?q=:query: search for notes based on keywords for the authenticated user.
-**/
-
-func NotesRoutes(incomingRoutes *gin.Engine) {
-	// Remove the return statement and write your code.
-	incomingRoutes.Use(middleware.Authenticate())
-	incomingRoutes.Use(middleware.InternalRateLimiter())
-	incomingRoutes.GET("/api/notes", controllers.GetAllNotes())
-	incomingRoutes.GET("/api/notes/:id", controllers.GetNotesByID())
-	incomingRoutes.POST("/api/notes", controllers.CreateNotes())
-	incomingRoutes.PUT("/api/notes/:id", controllers.UpdateNotesByID())
-	incomingRoutes.DELETE("/api/notes/:id", controllers.DeleteNotesByID())
-	incomingRoutes.POST("/api/notes/:id/share", controllers.ShareNotesByID())
-
-	// Not checked, but filter corrected.
-	incomingRoutes.GET("/api/search", controllers.SearchNotesByKeywords())
-}
+package routes
+
+import (
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+Add in a middleware for authentication purpose.
+
+Create routes for doing various functions with the notes collection.
+
+Creating notes and notebooks and sharing notes, making them sharable included, can be restricted to users who have
+verified their email.
+
+Requests with a personal access token need its scope for the endpoint: notes:read to read, notes:write to change,
+and notes:share to share notes and manage who they are shared with, setting whether notes are sharable included.
+
+	Note Endpoints
+
+	GET /api/notes?filter=&sort=&order=&limit=&tag=&notebook=&workspace=&cursor=&fields=: get a page of the notes of the authenticated user, with a next page token.
+	GET /api/notes/:id: get a note by ID for the authenticated user.
+	POST /api/notes: create a new note for the authenticated user, or in the workspace of its workspaceId.
+	PUT /api/notes/:id: update an existing note by ID for the authenticated user.
+	DELETE /api/notes/:id: move a note by ID to the trash for the authenticated user.
+	POST /api/notes/:id/share: share a note with another user for the authenticated user.
+	GET /api/notes/:id/access: list the users a note is shared with, for the owner of the note.
+	PUT /api/notes/:id/access/:userId: change the role a user has been granted on a note.
+	DELETE /api/notes/:id/access/:userId: revoke the access of a user to a note.
+	POST /api/notes/:id/links: create a link opening a note without an account, for the owner of the note.
+	GET /api/notes/:id/links: list the links of a note which have not expired, without their tokens.
+	DELETE /api/notes/:id/links/:linkId: revoke a link of a note.
+	GET /api/notes/:id/revisions: list the revisions of a note, without their content.
+	GET /api/notes/:id/revisions/diff?from=:rev&to=:rev: line diff between two revisions of a note, of up to 5000 lines each.
+	GET /api/notes/:id/revisions/:rev: get a single revision of a note with its content.
+	POST /api/notes/:id/revisions/:rev/restore: make the content of an old revision the current one.
+	POST /api/notes/:id/tags: add tags to a note.
+	DELETE /api/notes/:id/tags/:tag: remove a tag from a note.
+	GET /api/search?q=:query&tag=&notebook=&workspace=: search the notes of the authenticated user, ranked with the matches highlighted; words, "phrases", prefix*, AND, OR, NOT and parentheses.
+
+	Tag Endpoints
+
+	GET /api/tags: list the tags of the notes of the authenticated user, with the number of notes having each.
+	PUT /api/tags/:tag: rename a tag on every note of the authenticated user.
+	POST /api/tags/merge: merge tags into one on every note of the authenticated user.
+
+	Notebook Endpoints
+
+	POST /api/notebooks: create a notebook, at the top or inside another notebook.
+	GET /api/notebooks: list the notebooks of the authenticated user.
+	GET /api/notebooks/:id: get a notebook of the authenticated user.
+	PUT /api/notebooks/:id: rename a notebook, or move it with everything inside it.
+	DELETE /api/notebooks/:id?mode=reparent|cascade: delete a notebook, moving what it holds up, or deleting the notebooks in it and trashing the notes.
+
+	Trash Endpoints
+
+	GET /api/trash?workspace=: list the notes in the trash of the authenticated user, or of a workspace.
+	POST /api/trash/:id/restore: take a note out of the trash.
+	DELETE /api/trash/:id: delete a note in the trash for good.
+	DELETE /api/trash?workspace=: empty the trash, of the user or of a workspace.
+
+	Notes stay in the trash for the configured retention, then they are purged for good.
+
+	The tag filters take comma separated tags and keep the notes having every one of them.
+	The notebook filters keep the notes in the notebook and in the notebooks below it.
+	The workspace filters keep every note of the workspace in place of the notes of the user, for its members.
+	The notes of a workspace belong to it: its owner and admins own them, its members edit them and its viewers
+	read them. They cannot be put into notebooks, and only the admins of the workspace look after its trash.
+**/
+
+func NotesRoutes(incomingRoutes *gin.Engine) {
+	// Remove the return statement and write your code.
+	incomingRoutes.Use(middleware.Authenticate())
+	incomingRoutes.Use(middleware.InternalRateLimiter())
+	incomingRoutes.GET("/api/notes", middleware.RequireScope(models.NotesReadScope), controllers.GetAllNotes())
+	incomingRoutes.GET("/api/notes/:id", middleware.RequireScope(models.NotesReadScope), controllers.GetNotesByID())
+	incomingRoutes.POST("/api/notes", middleware.RequireScope(models.NotesWriteScope), middleware.RequireVerifiedEmail(config.CreateAction), controllers.CreateNotes())
+	incomingRoutes.PUT("/api/notes/:id", middleware.RequireScope(models.NotesWriteScope), controllers.UpdateNotesByID())
+	incomingRoutes.DELETE("/api/notes/:id", middleware.RequireScope(models.NotesWriteScope), controllers.DeleteNotesByID())
+	incomingRoutes.POST("/api/notes/:id/share", middleware.RequireScope(models.NotesShareScope), middleware.RequireVerifiedEmail(config.ShareAction), controllers.ShareNotesByID())
+	incomingRoutes.GET("/api/notes/:id/access", middleware.RequireScope(models.NotesShareScope), controllers.GetNoteAccess())
+	incomingRoutes.PUT("/api/notes/:id/access/:userId", middleware.RequireScope(models.NotesShareScope), middleware.RequireVerifiedEmail(config.ShareAction), controllers.ChangeNoteAccess())
+	incomingRoutes.DELETE("/api/notes/:id/access/:userId", middleware.RequireScope(models.NotesShareScope), controllers.RevokeNoteAccess())
+	incomingRoutes.POST("/api/notes/:id/links", middleware.RequireScope(models.NotesShareScope), middleware.RequireVerifiedEmail(config.ShareAction), controllers.CreateShareLink())
+	incomingRoutes.GET("/api/notes/:id/links", middleware.RequireScope(models.NotesShareScope), controllers.GetShareLinks())
+	incomingRoutes.DELETE("/api/notes/:id/links/:linkId", middleware.RequireScope(models.NotesShareScope), controllers.DeleteShareLinkByID())
+	incomingRoutes.GET("/api/notes/:id/revisions", middleware.RequireScope(models.NotesReadScope), controllers.GetNoteRevisions())
+	incomingRoutes.GET("/api/notes/:id/revisions/diff", middleware.RequireScope(models.NotesReadScope), controllers.DiffNoteRevisions())
+	incomingRoutes.GET("/api/notes/:id/revisions/:rev", middleware.RequireScope(models.NotesReadScope), controllers.GetNoteRevisionByNumber())
+	incomingRoutes.POST("/api/notes/:id/revisions/:rev/restore", middleware.RequireScope(models.NotesWriteScope), controllers.RestoreNoteRevision())
+	incomingRoutes.POST("/api/notes/:id/tags", middleware.RequireScope(models.NotesWriteScope), controllers.AddNoteTags())
+	incomingRoutes.DELETE("/api/notes/:id/tags/:tag", middleware.RequireScope(models.NotesWriteScope), controllers.RemoveNoteTag())
+	incomingRoutes.GET("/api/tags", middleware.RequireScope(models.NotesReadScope), controllers.GetTags())
+	incomingRoutes.PUT("/api/tags/:tag", middleware.RequireScope(models.NotesWriteScope), controllers.RenameTag())
+	incomingRoutes.POST("/api/tags/merge", middleware.RequireScope(models.NotesWriteScope), controllers.MergeTags())
+	incomingRoutes.POST("/api/notebooks", middleware.RequireScope(models.NotesWriteScope), middleware.RequireVerifiedEmail(config.CreateAction), controllers.CreateNotebook())
+	incomingRoutes.GET("/api/notebooks", middleware.RequireScope(models.NotesReadScope), controllers.GetNotebooks())
+	incomingRoutes.GET("/api/notebooks/:id", middleware.RequireScope(models.NotesReadScope), controllers.GetNotebookByID())
+	incomingRoutes.PUT("/api/notebooks/:id", middleware.RequireScope(models.NotesWriteScope), controllers.UpdateNotebookByID())
+	incomingRoutes.DELETE("/api/notebooks/:id", middleware.RequireScope(models.NotesWriteScope), controllers.DeleteNotebookByID())
+	incomingRoutes.GET("/api/trash", middleware.RequireScope(models.NotesReadScope), controllers.GetTrash())
+	incomingRoutes.POST("/api/trash/:id/restore", middleware.RequireScope(models.NotesWriteScope), controllers.RestoreTrashedNote())
+	incomingRoutes.DELETE("/api/trash/:id", middleware.RequireScope(models.NotesWriteScope), controllers.DeleteTrashedNote())
+	incomingRoutes.DELETE("/api/trash", middleware.RequireScope(models.NotesWriteScope), controllers.EmptyTrash())
+
+	// Not checked, but filter corrected.
+	incomingRoutes.GET("/api/search", middleware.RequireScope(models.NotesReadScope), controllers.SearchNotesByKeywords())
+}
@@ -0,0 +1,464 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/mailer"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// Mailer handing every mail to the test instead of sending it.
+type channelMailer chan *mailer.Message
+
+func (mails channelMailer) Send(ctx context.Context, message *mailer.Message) error {
+	mails <- message
+	return nil
+}
+
+// Client of the API served in memory, failing the test on responses with an unexpected status.
+type apiClient struct {
+	t      *testing.T
+	router *gin.Engine
+	mails  channelMailer
+}
+
+// Sets up the API the way main does, on the memory store and with the mails handed to the test.
+func newAPIClient(t *testing.T) *apiClient {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	logger.Log.SetOutput(io.Discard)
+
+	cfg := config.Default()
+	cfg.Auth.Secret_Key = "test-secret"
+	cfg.Mail.Link_Base_URL = "http://notes.test"
+	cfg.Rate_Limit.Global_Burst = 1000
+	cfg.Rate_Limit.User_Burst = 1000
+
+	helper.SetAuthConfig(cfg.Auth)
+	err := helper.LoadSigningKeys()
+	if err != nil {
+		t.Fatalf("load the signing keys: %s", err)
+	}
+
+	helper.SetTrashConfig(cfg.Trash)
+	helper.SetVerificationConfig(cfg.Verification)
+	helper.SetMailConfig(cfg.Mail)
+	helper.SetLockoutConfig(cfg.Lockout)
+	helper.SetAdminConfig(cfg.Admin)
+	helper.SetWorkspaceConfig(cfg.Workspace)
+	middleware.SetRateLimitConfig(cfg.Rate_Limit)
+	middleware.SetAdminConfig(cfg.Admin)
+
+	mails := make(channelMailer, 16)
+	mailer.MailerObject = mails
+	database.StoreObject = database.NewMemoryStore()
+
+	router := gin.New()
+	routes.AuthRoutes(router)
+	routes.AdminRoutes(router)
+	routes.WorkspaceRoutes(router)
+	routes.ShareLinkRoutes(router)
+	routes.NotesRoutes(router)
+
+	return &apiClient{t: t, router: router, mails: mails}
+}
+
+// Sends the request with the token and the body as json, returning the decoded response.
+func (api *apiClient) do(method string, path string, token string, body any, wantStatus int) map[string]any {
+	api.t.Helper()
+
+	var content bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&content).Encode(body)
+		if err != nil {
+			api.t.Fatalf("encode the body of %s %s: %s", method, path, err)
+		}
+	}
+
+	request := httptest.NewRequest(method, path, &content)
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("token", token)
+	}
+
+	recorder := httptest.NewRecorder()
+	api.router.ServeHTTP(recorder, request)
+
+	if recorder.Code != wantStatus {
+		api.t.Fatalf("%s %s = %d, want %d\n\tBody: %s", method, path, recorder.Code, wantStatus, recorder.Body.String())
+	}
+
+	response := map[string]any{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return response
+}
+
+// Waits for the mail with the subject sent to the address. The mails are sent in the background.
+func (api *apiClient) mail(to string, subject string) *mailer.Message {
+	api.t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case message := <-api.mails:
+			if message.To == to && message.Subject == subject {
+				return message
+			}
+		case <-timeout:
+			api.t.Fatalf("no mail %q to %s", subject, to)
+		}
+	}
+}
+
+// Signs the user up and verifies their email, returning their user id.
+func (api *apiClient) signUp(firstName string, email string, password string) string {
+	api.t.Helper()
+
+	response := api.signUpUnverified(firstName, email, password)
+	api.verify(email)
+
+	return field(api.t, response, "data", "userId").(string)
+}
+
+// Signs the user up without verifying their email, returning the response.
+func (api *apiClient) signUpUnverified(firstName string, email string, password string) map[string]any {
+	api.t.Helper()
+
+	return api.do(http.MethodPost, "/api/auth/signup", "", map[string]any{"firstName": firstName, "lastName": "Tester", "email": email, "password": password, "phone": "1234567890"}, http.StatusOK)
+}
+
+// Verifies the email through the link mailed to it.
+func (api *apiClient) verify(email string) {
+	api.t.Helper()
+
+	message := api.mail(email, "Verify your email")
+	_, link, found := strings.Cut(message.Body, "/api/auth/verify?")
+	if !found {
+		api.t.Fatalf("no verification link in %q", message.Body)
+	}
+	api.do(http.MethodGet, "/api/auth/verify?"+strings.Fields(link)[0], "", nil, http.StatusOK)
+}
+
+// Logs the user in, returning their access token.
+func (api *apiClient) login(email string, password string) string {
+	api.t.Helper()
+
+	response := api.do(http.MethodPost, "/api/auth/login", "", map[string]any{"email": email, "password": password}, http.StatusOK)
+	return field(api.t, response, "data", "token").(string)
+}
+
+// Value at the path of keys in the decoded json.
+func field(t *testing.T, value any, keys ...string) any {
+	t.Helper()
+
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			t.Fatalf("no object holding %q in %v", key, value)
+		}
+
+		value, ok = object[key]
+		if !ok {
+			t.Fatalf("no %q in %v", key, object)
+		}
+	}
+
+	return value
+}
+
+func TestNotesAPI(t *testing.T) {
+	api := newAPIClient(t)
+
+	aliceId := api.signUp("Alice", "alice@example.com", "secret-alice")
+	bobId := api.signUp("Bobby", "bob@example.com", "secret-bobby")
+
+	alice := api.login("alice@example.com", "secret-alice")
+	bob := api.login("bob@example.com", "secret-bobby")
+	api.do(http.MethodPost, "/api/auth/login", "", map[string]any{"email": "alice@example.com", "password": "wrong-password"}, http.StatusUnauthorized)
+
+	// Creating, reading and updating a note.
+	created := api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "groceries", "notesData": "milk"}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+	if owner := field(t, created, "data", "userId"); owner != aliceId {
+		t.Errorf("owner of the new note = %v, want %s", owner, aliceId)
+	}
+
+	note := api.do(http.MethodGet, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	if data := field(t, note, "notesData"); data != "milk" {
+		t.Errorf("data of the note = %v, want milk", data)
+	}
+
+	api.do(http.MethodPut, "/api/notes/"+noteId, alice, map[string]any{"notesData": "milk and eggs"}, http.StatusOK)
+	note = api.do(http.MethodGet, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	if data := field(t, note, "notesData"); data != "milk and eggs" {
+		t.Errorf("data of the updated note = %v, want milk and eggs", data)
+	}
+
+	// Sharing the note with another user, who can read but not change it.
+	api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusForbidden)
+	api.do(http.MethodPost, "/api/notes/"+noteId+"/share", alice, map[string]any{"userId": bobId, "role": "viewer"}, http.StatusOK)
+	note = api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusOK)
+	if data := field(t, note, "notesData"); data != "milk and eggs" {
+		t.Errorf("data of the shared note = %v, want milk and eggs", data)
+	}
+	api.do(http.MethodPut, "/api/notes/"+noteId, bob, map[string]any{"notesData": "chocolate"}, http.StatusForbidden)
+
+	api.do(http.MethodDelete, "/api/notes/"+noteId+"/access/"+bobId, alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusForbidden)
+
+	// Deleting the note moves it to the trash, from where it can be restored.
+	api.do(http.MethodDelete, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/api/notes/"+noteId, alice, nil, http.StatusBadRequest)
+	trash := api.do(http.MethodGet, "/api/trash", alice, nil, http.StatusOK)
+	if trashed := field(t, trash, "data").([]any); len(trashed) != 1 {
+		t.Errorf("%d notes in the trash, want 1", len(trashed))
+	}
+	api.do(http.MethodPost, "/api/trash/"+noteId+"/restore", alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+}
+
+func TestShareLinksAPI(t *testing.T) {
+	api := newAPIClient(t)
+
+	api.signUp("Alice", "alice@example.com", "secret-alice")
+	alice := api.login("alice@example.com", "secret-alice")
+
+	created := api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "recipe", "notesData": "flour and water"}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+
+	// Notes have no comments, so links only give the viewer role.
+	api.do(http.MethodPost, "/api/notes/"+noteId+"/links", alice, map[string]any{"role": "commenter"}, http.StatusBadRequest)
+
+	maxViews := 2
+	link := api.do(http.MethodPost, "/api/notes/"+noteId+"/links", alice, map[string]any{"maxViews": maxViews}, http.StatusCreated)
+	if message := field(t, link, "message"); message != "Share link created. Keep it safe, it is not shown again." {
+		t.Errorf("message of the new link = %v", message)
+	}
+	token := field(t, link, "data", "token").(string)
+	linkId := field(t, link, "data", "link", "id").(string)
+
+	// Anyone holding the link can open the note till it runs out of views.
+	for view := 0; view < maxViews; view++ {
+		shared := api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusOK)
+		if data := field(t, shared, "data", "notesData"); data != "flour and water" {
+			t.Errorf("data of the shared note = %v, want flour and water", data)
+		}
+	}
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusNotFound)
+
+	// A revoked link stops working at once.
+	link = api.do(http.MethodPost, "/api/notes/"+noteId+"/links", alice, map[string]any{}, http.StatusCreated)
+	token = field(t, link, "data", "token").(string)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusOK)
+	api.do(http.MethodDelete, "/api/notes/"+noteId+"/links/"+field(t, link, "data", "link", "id").(string), alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusNotFound)
+
+	// Deleting the note for good deletes its links too.
+	api.do(http.MethodDelete, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	api.do(http.MethodDelete, "/api/trash/"+noteId, alice, nil, http.StatusOK)
+
+	links, err := database.StoreObject.ListShareLinks(context.Background(), noteId)
+	if err != nil {
+		t.Fatalf("list the links of the deleted note: %s", err)
+	}
+	if len(links) != 0 {
+		t.Errorf("%d links left of the deleted note, %s among them, want none", len(links), linkId)
+	}
+}
+
+func TestNotebooksAPI(t *testing.T) {
+	api := newAPIClient(t)
+
+	api.signUp("Alice", "alice@example.com", "secret-alice")
+	alice := api.login("alice@example.com", "secret-alice")
+
+	notebook := api.do(http.MethodPost, "/api/notebooks", alice, map[string]any{"name": "kitchen"}, http.StatusCreated)
+	notebookId := field(t, notebook, "ID").(string)
+	api.do(http.MethodPost, "/api/notebooks", alice, map[string]any{"name": "kitchen"}, http.StatusConflict)
+
+	created := api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "bread", "notesData": "flour", "notebookId": notebookId}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+	link := api.do(http.MethodPost, "/api/notes/"+noteId+"/links", alice, map[string]any{}, http.StatusCreated)
+	token := field(t, link, "data", "token").(string)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusOK)
+
+	// Deleting the notebook with everything inside it moves its notes to the trash, their links stop working.
+	deleted := api.do(http.MethodDelete, "/api/notebooks/"+notebookId+"?mode=cascade", alice, nil, http.StatusOK)
+	if count := field(t, deleted, "trashedNotes"); count != float64(1) {
+		t.Errorf("%v notes trashed with the notebook, want 1", count)
+	}
+	api.do(http.MethodGet, "/api/notebooks/"+notebookId, alice, nil, http.StatusNotFound)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusNotFound)
+
+	// Restored, the note comes back outside of notebooks with its revisions and links.
+	restored := api.do(http.MethodPost, "/api/trash/"+noteId+"/restore", alice, nil, http.StatusOK)
+	if notebook := field(t, restored, "notebookId"); notebook != nil {
+		t.Errorf("notebook of the restored note = %v, want none", notebook)
+	}
+	api.do(http.MethodGet, "/api/notes/"+noteId+"/revisions/1", alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusOK)
+
+	// Deleted from the trash, it goes for good along with its links.
+	notebook = api.do(http.MethodPost, "/api/notebooks", alice, map[string]any{"name": "kitchen"}, http.StatusCreated)
+	notebookId = field(t, notebook, "ID").(string)
+	api.do(http.MethodPut, "/api/notes/"+noteId, alice, map[string]any{"notebookId": notebookId}, http.StatusOK)
+	api.do(http.MethodDelete, "/api/notebooks/"+notebookId+"?mode=cascade", alice, nil, http.StatusOK)
+	api.do(http.MethodDelete, "/api/trash/"+noteId, alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/s/"+token, "", nil, http.StatusNotFound)
+
+	links, err := database.StoreObject.ListShareLinks(context.Background(), noteId)
+	if err != nil {
+		t.Fatalf("list the links of the deleted note: %s", err)
+	}
+	if len(links) != 0 {
+		t.Errorf("%d links left of the deleted note, want none", len(links))
+	}
+}
+
+func TestWorkspacesAPI(t *testing.T) {
+	api := newAPIClient(t)
+
+	api.signUp("Alice", "alice@example.com", "secret-alice")
+	bobId := api.signUp("Bobby", "bob@example.com", "secret-bobby")
+	alice := api.login("alice@example.com", "secret-alice")
+	bob := api.login("bob@example.com", "secret-bobby")
+
+	workspace := api.do(http.MethodPost, "/api/workspaces", alice, map[string]any{"name": "home"}, http.StatusCreated)
+	workspaceId := field(t, workspace, "data", "id").(string)
+
+	created := api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "chores", "notesData": "dishes", "workspaceId": workspaceId}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+	api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusForbidden)
+
+	// Joining through the mailed invitation gives the role it was sent with.
+	api.do(http.MethodPost, "/api/workspaces/"+workspaceId+"/invitations", alice, map[string]any{"email": "bob@example.com", "role": "viewer"}, http.StatusCreated)
+	// The token is the paragraph after the one telling what to do with it.
+	message := api.mail("bob@example.com", "You are invited to the workspace home")
+	token := strings.Split(message.Body, "\n\n")[2]
+	joined := api.do(http.MethodPost, "/api/invitations/accept", bob, map[string]any{"token": token}, http.StatusOK)
+
+	members := field(t, joined, "data", "members").([]any)
+	if len(members) != 2 || field(t, members[1], "userId") != bobId || field(t, members[1], "role") != "viewer" {
+		t.Errorf("members of the workspace = %v, want the owner and bob as viewer", members)
+	}
+
+	note := api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusOK)
+	if data := field(t, note, "notesData"); data != "dishes" {
+		t.Errorf("data of the workspace note = %v, want dishes", data)
+	}
+	api.do(http.MethodPut, "/api/notes/"+noteId, bob, map[string]any{"notesData": "nothing"}, http.StatusForbidden)
+	api.do(http.MethodPost, "/api/invitations/accept", bob, map[string]any{"token": token}, http.StatusNotFound)
+
+	// Members who leave lose the notes of the workspace.
+	api.do(http.MethodDelete, "/api/workspaces/"+workspaceId+"/members/"+bobId, alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/api/notes/"+noteId, bob, nil, http.StatusForbidden)
+
+	// Only a workspace without notes, in the trash neither, can be deleted.
+	api.do(http.MethodDelete, "/api/workspaces/"+workspaceId, alice, nil, http.StatusConflict)
+	api.do(http.MethodDelete, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	api.do(http.MethodDelete, "/api/workspaces/"+workspaceId, alice, nil, http.StatusConflict)
+	api.do(http.MethodDelete, "/api/trash?workspace="+workspaceId, alice, nil, http.StatusOK)
+	api.do(http.MethodDelete, "/api/workspaces/"+workspaceId, alice, nil, http.StatusOK)
+	api.do(http.MethodGet, "/api/workspaces/"+workspaceId, alice, nil, http.StatusNotFound)
+}
+
+func TestConfiguredAdminEmailNeedsVerifying(t *testing.T) {
+	api := newAPIClient(t)
+	helper.SetAdminConfig(config.AdminConfig{Emails: []string{"root@example.com"}})
+
+	// Anyone can sign up with the admin email, which gives no admin rights till it is verified.
+	signedUp := api.signUpUnverified("Mallory", "root@example.com", "secret-root")
+	unverified := field(t, signedUp, "data", "token").(string)
+	api.do(http.MethodGet, "/api/admin/users", unverified, nil, http.StatusForbidden)
+
+	err := helper.PromoteConfiguredAdmins(context.Background())
+	if err != nil {
+		t.Fatalf("promote the configured admins: %s", err)
+	}
+	api.do(http.MethodGet, "/api/admin/users", api.login("root@example.com", "secret-root"), nil, http.StatusForbidden)
+
+	// Verifying makes the user an admin, ending the sessions started as a plain user.
+	api.verify("root@example.com")
+	api.do(http.MethodGet, "/api/notes", unverified, nil, http.StatusUnauthorized)
+	api.do(http.MethodGet, "/api/admin/users", api.login("root@example.com", "secret-root"), nil, http.StatusOK)
+}
+
+func TestRestoreRevisionKeepsHeadersUnique(t *testing.T) {
+	api := newAPIClient(t)
+
+	api.signUp("Alice", "alice@example.com", "secret-alice")
+	alice := api.login("alice@example.com", "secret-alice")
+
+	created := api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "plans", "notesData": "first"}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+	api.do(http.MethodPut, "/api/notes/"+noteId, alice, map[string]any{"header": "old plans"}, http.StatusOK)
+	api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "plans", "notesData": "second"}, http.StatusOK)
+
+	// The first revision has the header another note has now.
+	api.do(http.MethodPost, "/api/notes/"+noteId+"/revisions/1/restore", alice, nil, http.StatusConflict)
+	note := api.do(http.MethodGet, "/api/notes/"+noteId, alice, nil, http.StatusOK)
+	if header := field(t, note, "header"); header != "old plans" {
+		t.Errorf("header of the note = %v, want old plans", header)
+	}
+
+	api.do(http.MethodPut, "/api/notes/"+noteId, alice, map[string]any{"header": "plans"}, http.StatusConflict)
+	api.do(http.MethodPost, "/api/notes", alice, map[string]any{"header": "plans", "notesData": "third"}, http.StatusConflict)
+}
+
+func TestUnverifiedUsersCannotShare(t *testing.T) {
+	api := newAPIClient(t)
+
+	signedUp := api.signUpUnverified("Mallory", "mallory@example.com", "secret-mallory")
+	mallory := field(t, signedUp, "data", "token").(string)
+
+	// Notes can be created and changed, but not made public for every user.
+	api.do(http.MethodPost, "/api/notes", mallory, map[string]any{"header": "spam", "notesData": "buy now", "sharable": true}, http.StatusForbidden)
+	created := api.do(http.MethodPost, "/api/notes", mallory, map[string]any{"header": "spam", "notesData": "buy now"}, http.StatusOK)
+	noteId := field(t, created, "data", "ID").(string)
+	api.do(http.MethodPut, "/api/notes/"+noteId, mallory, map[string]any{"sharable": true}, http.StatusForbidden)
+	api.do(http.MethodPut, "/api/notes/"+noteId, mallory, map[string]any{"sharable": false}, http.StatusOK)
+
+	// Workspaces send invitations to any email, so they count as sharing too.
+	api.do(http.MethodPost, "/api/workspaces", mallory, map[string]any{"name": "spam"}, http.StatusForbidden)
+
+	api.verify("mallory@example.com")
+	api.do(http.MethodPut, "/api/notes/"+noteId, mallory, map[string]any{"sharable": true}, http.StatusOK)
+	workspace := api.do(http.MethodPost, "/api/workspaces", mallory, map[string]any{"name": "team"}, http.StatusCreated)
+	api.do(http.MethodPost, "/api/workspaces/"+field(t, workspace, "data", "id").(string)+"/invitations", mallory, map[string]any{"email": "bob@example.com"}, http.StatusCreated)
+}
+
+func TestSharableNeedsShareScope(t *testing.T) {
+	api := newAPIClient(t)
+
+	api.signUp("Alice", "alice@example.com", "secret-alice")
+	alice := api.login("alice@example.com", "secret-alice")
+
+	created := api.do(http.MethodPost, "/api/auth/tokens", alice, map[string]any{"name": "writer", "scopes": []string{"notes:read", "notes:write"}}, http.StatusCreated)
+	writer := field(t, created, "data", "token").(string)
+
+	// Writing notes is allowed, making them public for every user is sharing them.
+	api.do(http.MethodPost, "/api/notes", writer, map[string]any{"header": "public", "notesData": "hello", "sharable": true}, http.StatusForbidden)
+	note := api.do(http.MethodPost, "/api/notes", writer, map[string]any{"header": "private", "notesData": "hello"}, http.StatusOK)
+	noteId := field(t, note, "data", "ID").(string)
+	api.do(http.MethodPut, "/api/notes/"+noteId, writer, map[string]any{"notesData": "hello again"}, http.StatusOK)
+	api.do(http.MethodPut, "/api/notes/"+noteId, writer, map[string]any{"sharable": true}, http.StatusForbidden)
+
+	created = api.do(http.MethodPost, "/api/auth/tokens", alice, map[string]any{"name": "sharer", "scopes": []string{"notes:write", "notes:share"}}, http.StatusCreated)
+	sharer := field(t, created, "data", "token").(string)
+	api.do(http.MethodPut, "/api/notes/"+noteId, sharer, map[string]any{"sharable": true}, http.StatusOK)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+Create the public routes of the share links, opening notes to anyone holding a link without an account.
+
+	The owner of a note creates and revokes its links through the note endpoints. A link gives the viewer role
+	on the note and may have a password, an expiry and a number of views, after which it stops working. Links
+	of notes in the trash work again once the notes are restored.
+
+	Share Link Endpoints
+
+	GET /s/:token: open a share link, with its password in the X-Share-Password header if it has one.
+
+	Wrong passwords make the link wait longer before the next one is tried, like the failed logins.
+**/
+
+func ShareLinkRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/s/:token", controllers.OpenShareLink())
+}
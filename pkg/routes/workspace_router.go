@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/controllers"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/middleware"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+Create routes for the workspaces, the spaces of notes shared by a team.
+
+	The notes created in a workspace belong to it rather than to their author. Every member sees the same notes
+	of it, listed and searched with the workspace parameter of the note endpoints. Members have one of the roles:
+
+	owner: the one who created the workspace, allowed everything, and the only one who can delete it.
+	admin: manages the members, the invitations and the notes, and the trash of the workspace.
+	member: creates and edits the notes.
+	viewer: reads the notes.
+
+	Requests with a personal access token need notes:read to read the workspaces and notes:share to change them.
+	Creating workspaces and inviting into them counts as sharing, which unverified users may be kept from.
+
+	Workspace Endpoints
+
+	POST /api/workspaces: create a workspace, the authenticated user becoming its owner.
+	GET /api/workspaces: list the workspaces the authenticated user is a member of, by name.
+	GET /api/workspaces/:id: get a workspace with its members.
+	PUT /api/workspaces/:id: rename a workspace.
+	DELETE /api/workspaces/:id: delete a workspace without notes, in the trash neither.
+	PUT /api/workspaces/:id/members/:userId: change the role of a member to admin, member or viewer.
+	DELETE /api/workspaces/:id/members/:userId: remove a member, or leave the workspace with the own user id.
+
+	Invitation Endpoints
+
+	Invitations are mailed with a token, which can be used once and only until it expires. Only their hashes are stored.
+
+	POST /api/workspaces/:id/invitations: invite an email into a workspace with a role, member by default.
+	GET /api/workspaces/:id/invitations: list the pending invitations into a workspace, newest first.
+	DELETE /api/workspaces/:id/invitations/:invitationId: withdraw an invitation.
+	POST /api/invitations/accept: join the workspace with the token, for the authenticated user with the invited email.
+	POST /api/invitations/decline: decline the invitation with the token, no login needed.
+**/
+
+func WorkspaceRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/api/workspaces", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), middleware.RequireVerifiedEmail(config.ShareAction), controllers.CreateWorkspace())
+	incomingRoutes.GET("/api/workspaces", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesReadScope), controllers.GetWorkspaces())
+	incomingRoutes.GET("/api/workspaces/:id", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesReadScope), controllers.GetWorkspaceByID())
+	incomingRoutes.PUT("/api/workspaces/:id", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.UpdateWorkspaceByID())
+	incomingRoutes.DELETE("/api/workspaces/:id", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.DeleteWorkspaceByID())
+	incomingRoutes.PUT("/api/workspaces/:id/members/:userId", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.SetWorkspaceMemberRole())
+	incomingRoutes.DELETE("/api/workspaces/:id/members/:userId", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.RemoveWorkspaceMember())
+	incomingRoutes.POST("/api/workspaces/:id/invitations", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), middleware.RequireVerifiedEmail(config.ShareAction), controllers.CreateWorkspaceInvitation())
+	incomingRoutes.GET("/api/workspaces/:id/invitations", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.GetWorkspaceInvitations())
+	incomingRoutes.DELETE("/api/workspaces/:id/invitations/:invitationId", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.DeleteWorkspaceInvitation())
+	incomingRoutes.POST("/api/invitations/accept", middleware.Authenticate(), middleware.InternalRateLimiter(), middleware.RequireScope(models.NotesShareScope), controllers.AcceptWorkspaceInvitation())
+	incomingRoutes.POST("/api/invitations/decline", controllers.DeclineWorkspaceInvitation())
+}
@@ -0,0 +1,437 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on a query, so that no query can make a search slow.
+const (
+	MaxQueryLength = 512
+	MaxQueryTerms  = 32
+	MaxQueryDepth  = 16
+)
+
+var (
+	ErrEmptyQuery      = errors.New("query has no words to search for")
+	ErrNoPositiveTerms = errors.New("query must contain a word which is not negated")
+)
+
+// Words which have to follow each other in a field, a single word being a phrase of one.
+// With Prefix the last word only has to start the word of the field.
+type Phrase struct {
+	Words  []string
+	Prefix bool
+}
+
+type node interface {
+	matches(doc *document) bool
+}
+
+type andNode struct {
+	nodes []node
+}
+
+type orNode struct {
+	nodes []node
+}
+
+type notNode struct {
+	node node
+}
+
+func (phrase *Phrase) matches(doc *document) bool {
+	for _, field := range doc.fields {
+		if len(phrase.occurrences(field.tokens)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (and *andNode) matches(doc *document) bool {
+	for _, child := range and.nodes {
+		if !child.matches(doc) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (or *orNode) matches(doc *document) bool {
+	for _, child := range or.nodes {
+		if child.matches(doc) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (not *notNode) matches(doc *document) bool {
+	return !not.node.matches(doc)
+}
+
+// Parsed search query, matched against the header and the notes data of notes.
+//
+// Words are matched whole and case insensitively, everything but letters and numbers separating them.
+// The syntax is:
+//
+//	apple pie         both words, in any order
+//	"apple pie"       the phrase
+//	app*              a word starting with app, also at the end of a phrase
+//	apple OR pear     either word, AND binding tighter than OR
+//	NOT pear, -pear   the word must not be present
+//	(apple OR pear) AND pie
+//
+// Any other character only separates words, so nothing typed can change the meaning of the query.
+type Query struct {
+	text string
+	root node
+}
+
+// Parses the query typed by a user.
+func Parse(text string) (*Query, error) {
+	if len(text) > MaxQueryLength {
+		return nil, fmt.Errorf("query is longer than %d bytes", MaxQueryLength)
+	}
+
+	parser := &parser{items: lex(text)}
+
+	root, err := parser.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position < len(parser.items) {
+		return nil, fmt.Errorf("unexpected %q in query", parser.items[parser.position].text)
+	}
+
+	if root == nil {
+		return nil, ErrEmptyQuery
+	}
+
+	if parser.terms > MaxQueryTerms {
+		return nil, fmt.Errorf("query has more than %d words", MaxQueryTerms)
+	}
+
+	query := &Query{text: text, root: root}
+
+	if len(query.positivePhrases()) == 0 {
+		return nil, ErrNoPositiveTerms
+	}
+
+	return query, nil
+}
+
+func (query *Query) String() string {
+	return query.text
+}
+
+// Reports whether the header or the notes data match the query.
+func (query *Query) Matches(header string, data string) bool {
+	return query.root.matches(newDocument(header, data))
+}
+
+// Phrases every matching note contains, which a store can look up in its index
+// to narrow down the notes it has to match the query against.
+func (query *Query) RequiredPhrases() []Phrase {
+	return requiredPhrases(query.root)
+}
+
+func requiredPhrases(current node) []Phrase {
+	switch current := current.(type) {
+	case *Phrase:
+		return []Phrase{*current}
+	case *andNode:
+		var phrases []Phrase
+		for _, child := range current.nodes {
+			phrases = append(phrases, requiredPhrases(child)...)
+		}
+		return phrases
+	}
+
+	// Neither branch of an OR is required on its own, and a negated phrase is never required.
+	return nil
+}
+
+// Turns the parts of a parsed query into the query language of a search engine.
+type Builder[T any] interface {
+	Phrase(phrase Phrase) T
+	And(parts []T) T
+	Or(parts []T) T
+	Not(part T) T
+}
+
+// Translates the query for a search engine, so that every engine reads the same syntax.
+func Build[T any](query *Query, builder Builder[T]) T {
+	return build(query.root, builder)
+}
+
+func build[T any](current node, builder Builder[T]) T {
+	switch current := current.(type) {
+	case *Phrase:
+		return builder.Phrase(*current)
+	case *andNode:
+		return builder.And(buildAll(current.nodes, builder))
+	case *orNode:
+		return builder.Or(buildAll(current.nodes, builder))
+	}
+
+	return builder.Not(build(current.(*notNode).node, builder))
+}
+
+func buildAll[T any](nodes []node, builder Builder[T]) []T {
+	parts := make([]T, len(nodes))
+	for i, child := range nodes {
+		parts[i] = build(child, builder)
+	}
+
+	return parts
+}
+
+// Phrases which make a note match rather than keep it from matching, used for ranking and highlighting.
+func (query *Query) positivePhrases() []*Phrase {
+	var phrases []*Phrase
+
+	var walk func(current node)
+	walk = func(current node) {
+		switch current := current.(type) {
+		case *Phrase:
+			phrases = append(phrases, current)
+		case *andNode:
+			for _, child := range current.nodes {
+				walk(child)
+			}
+		case *orNode:
+			for _, child := range current.nodes {
+				walk(child)
+			}
+		}
+	}
+
+	walk(query.root)
+	return phrases
+}
+
+type itemKind int
+
+const (
+	wordItem itemKind = iota
+	phraseItem
+	minusItem
+	openItem
+	closeItem
+)
+
+type item struct {
+	kind itemKind
+	text string
+}
+
+// Splits the query into words, quoted phrases, parentheses and leading minus signs.
+// An unterminated quote runs to the end of the query.
+func lex(text string) []item {
+	var items []item
+
+	for i := 0; i < len(text); {
+		character, size := utf8.DecodeRuneInString(text[i:])
+
+		switch {
+		case unicode.IsSpace(character):
+			i += size
+		case character == '(':
+			items = append(items, item{kind: openItem, text: "("})
+			i += size
+		case character == ')':
+			items = append(items, item{kind: closeItem, text: ")"})
+			i += size
+		case character == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end < 0 {
+				items = append(items, item{kind: phraseItem, text: text[i+1:]})
+				i = len(text)
+			} else {
+				items = append(items, item{kind: phraseItem, text: text[i+1 : i+1+end]})
+				i += end + 2
+			}
+		case character == '-' && i+1 < len(text) && !unicode.IsSpace(rune(text[i+1])):
+			items = append(items, item{kind: minusItem, text: "-"})
+			i += size
+		default:
+			end := strings.IndexFunc(text[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+			})
+			if end < 0 {
+				end = len(text) - i
+			}
+			items = append(items, item{kind: wordItem, text: text[i : i+end]})
+			i += end
+		}
+	}
+
+	return items
+}
+
+type parser struct {
+	items    []item
+	position int
+	terms    int
+}
+
+func (parser *parser) peek() *item {
+	if parser.position >= len(parser.items) {
+		return nil
+	}
+
+	return &parser.items[parser.position]
+}
+
+func (parser *parser) isOperator(operator string) bool {
+	next := parser.peek()
+	return next != nil && next.kind == wordItem && next.text == operator
+}
+
+// Parsing returns a nil node for parts without any word, like a lone punctuation mark, which are left out.
+
+func (parser *parser) parseOr(depth int) (node, error) {
+	if depth > MaxQueryDepth {
+		return nil, fmt.Errorf("query is nested deeper than %d levels", MaxQueryDepth)
+	}
+
+	var nodes []node
+
+	for {
+		child, err := parser.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			nodes = append(nodes, child)
+		}
+
+		if !parser.isOperator("OR") {
+			break
+		}
+		parser.position++
+
+		if parser.peek() == nil || parser.peek().kind == closeItem {
+			return nil, errors.New("OR is not followed by a word")
+		}
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+
+	return &orNode{nodes: nodes}, nil
+}
+
+func (parser *parser) parseAnd(depth int) (node, error) {
+	var nodes []node
+
+	for {
+		next := parser.peek()
+		if next == nil || next.kind == closeItem || parser.isOperator("OR") {
+			break
+		}
+
+		if parser.isOperator("AND") {
+			parser.position++
+
+			next = parser.peek()
+			if next == nil || next.kind == closeItem || parser.isOperator("OR") {
+				return nil, errors.New("AND is not followed by a word")
+			}
+		}
+
+		child, err := parser.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			nodes = append(nodes, child)
+		}
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+
+	return &andNode{nodes: nodes}, nil
+}
+
+func (parser *parser) parseUnary(depth int) (node, error) {
+	if parser.isOperator("NOT") || parser.peek().kind == minusItem {
+		operator := parser.peek().text
+		parser.position++
+
+		next := parser.peek()
+		if next == nil || next.kind == closeItem || parser.isOperator("OR") || parser.isOperator("AND") {
+			return nil, fmt.Errorf("%s is not followed by a word", operator)
+		}
+
+		if depth+1 > MaxQueryDepth {
+			return nil, fmt.Errorf("query is nested deeper than %d levels", MaxQueryDepth)
+		}
+
+		child, err := parser.parseUnary(depth + 1)
+		if err != nil || child == nil {
+			return nil, err
+		}
+
+		return &notNode{node: child}, nil
+	}
+
+	current := parser.peek()
+	parser.position++
+
+	switch current.kind {
+	case openItem:
+		child, err := parser.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if parser.peek() == nil || parser.peek().kind != closeItem {
+			return nil, errors.New("missing closing parenthesis in query")
+		}
+		parser.position++
+
+		return child, nil
+	case phraseItem, wordItem:
+		return parser.newPhrase(current.text), nil
+	}
+
+	// A minus sign is only lexed before a word, so this is a closing parenthesis.
+	return nil, fmt.Errorf("unexpected %q in query", current.text)
+}
+
+// Makes the phrase of the words of a quoted phrase or of a single typed word,
+// a typed word like "e-mail" becoming a phrase of the words it is made of.
+func (parser *parser) newPhrase(text string) node {
+	prefix := strings.HasSuffix(text, "*")
+
+	var words []string
+	for _, token := range tokenize(strings.TrimRight(text, "*")) {
+		words = append(words, token.text)
+	}
+
+	if len(words) == 0 {
+		return nil
+	}
+
+	parser.terms += len(words)
+	return &Phrase{Words: words, Prefix: prefix}
+}
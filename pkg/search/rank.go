@@ -0,0 +1,298 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Weights of a match in the header and in the notes data.
+const (
+	headerWeight = 3.0
+	dataWeight   = 1.0
+)
+
+// Parameters of the BM25 ranking function.
+const (
+	termSaturation   = 1.2
+	lengthNormalized = 0.75
+)
+
+// Length of the notes data snippet around the first match, in characters.
+const SnippetLength = 200
+
+// A matching note with its relevance, higher is better, and the matched words marked in
+// its header and a snippet of its notes data. The highlights are HTML, with <mark> around matches.
+type Result struct {
+	Note       models.NoteData `json:"note"`
+	Score      float64         `json:"score"`
+	Highlights Highlights      `json:"highlights"`
+}
+
+type Highlights struct {
+	Header     string `json:"header"`
+	Notes_Data string `json:"notesData"`
+}
+
+type token struct {
+	text  string // lower cased
+	start int    // byte offsets in the original text
+	end   int
+}
+
+// Splits the text into lower cased words of letters and numbers.
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+	for i, character := range text {
+		isWordCharacter := unicode.IsLetter(character) || unicode.IsNumber(character)
+
+		if isWordCharacter && start < 0 {
+			start = i
+		}
+		if !isWordCharacter && start >= 0 {
+			tokens = append(tokens, token{text: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, token{text: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+
+	return tokens
+}
+
+type field struct {
+	text   string
+	tokens []token
+	weight float64
+}
+
+type document struct {
+	fields []field // header, then notes data
+}
+
+func newDocument(header string, data string) *document {
+	return &document{fields: []field{
+		{text: header, tokens: tokenize(header), weight: headerWeight},
+		{text: data, tokens: tokenize(data), weight: dataWeight},
+	}}
+}
+
+// Indexes of the tokens at which the phrase starts.
+func (phrase *Phrase) occurrences(tokens []token) []int {
+	var starts []int
+
+	for i := 0; i+len(phrase.Words) <= len(tokens); i++ {
+		if phrase.matchesAt(tokens, i) {
+			starts = append(starts, i)
+		}
+	}
+
+	return starts
+}
+
+func (phrase *Phrase) matchesAt(tokens []token, start int) bool {
+	last := len(phrase.Words) - 1
+
+	for j, word := range phrase.Words {
+		if j == last && phrase.Prefix {
+			if !strings.HasPrefix(tokens[start+j].text, word) {
+				return false
+			}
+		} else if tokens[start+j].text != word {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Orders the notes matching the query by relevance, the most recently updated first among equals,
+// and highlights them.
+//
+// Relevance is BM25 over the matching notes, a phrase counting once per occurrence
+// and as much as the number of its words, a match in the header three times as much.
+func Rank(query *Query, notes []models.NoteData) []Result {
+	phrases := query.positivePhrases()
+
+	documents := make([]*document, len(notes))
+	averageLengths := make([]float64, 2)
+
+	for i := range notes {
+		documents[i] = newDocument(stringValue(notes[i].Header), stringValue(notes[i].Data))
+		for j, field := range documents[i].fields {
+			averageLengths[j] += float64(len(field.tokens))
+		}
+	}
+
+	for j := range averageLengths {
+		averageLengths[j] = math.Max(averageLengths[j]/math.Max(float64(len(notes)), 1), 1)
+	}
+
+	// Rare phrases weigh more than ones most of the notes contain.
+	inverseFrequencies := make([]float64, len(phrases))
+	for p, phrase := range phrases {
+		containing := 0
+		for _, doc := range documents {
+			if phrase.matches(doc) {
+				containing++
+			}
+		}
+
+		n := float64(len(notes))
+		inverseFrequencies[p] = math.Log(1 + (n-float64(containing)+0.5)/(float64(containing)+0.5))
+	}
+
+	results := make([]Result, len(notes))
+
+	for i, doc := range documents {
+		score := 0.0
+
+		for j, field := range doc.fields {
+			lengthRatio := float64(len(field.tokens)) / averageLengths[j]
+
+			for p, phrase := range phrases {
+				frequency := float64(len(phrase.occurrences(field.tokens)))
+				if frequency == 0 {
+					continue
+				}
+
+				saturated := frequency * (termSaturation + 1) / (frequency + termSaturation*(1-lengthNormalized+lengthNormalized*lengthRatio))
+				score += inverseFrequencies[p] * field.weight * float64(len(phrase.Words)) * saturated
+			}
+		}
+
+		results[i] = Result{
+			Note:  notes[i],
+			Score: math.Round(score*1000) / 1000,
+			Highlights: Highlights{
+				Header:     highlight(doc.fields[0], phrases, 0),
+				Notes_Data: highlight(doc.fields[1], phrases, SnippetLength),
+			},
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		if !results[a].Note.Updated_At.Equal(results[b].Note.Updated_At) {
+			return results[a].Note.Updated_At.After(results[b].Note.Updated_At)
+		}
+		return results[a].Note.ID.Hex() < results[b].Note.ID.Hex()
+	})
+
+	return results
+}
+
+type span struct {
+	start int
+	end   int
+}
+
+// Escapes the text of the field for HTML, marking the occurrences of the phrases.
+// With a maximum length the text is cut down to a snippet around the first occurrence.
+func highlight(field field, phrases []*Phrase, maxLength int) string {
+	var spans []span
+
+	for _, phrase := range phrases {
+		for _, start := range phrase.occurrences(field.tokens) {
+			end := start + len(phrase.Words) - 1
+			spans = append(spans, span{start: field.tokens[start].start, end: field.tokens[end].end})
+		}
+	}
+
+	// Merge overlapping occurrences, so that marks are never nested.
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+
+	var merged []span
+	for _, current := range spans {
+		if len(merged) > 0 && current.start <= merged[len(merged)-1].end {
+			merged[len(merged)-1].end = max(merged[len(merged)-1].end, current.end)
+			continue
+		}
+		merged = append(merged, current)
+	}
+
+	from, to := 0, len(field.text)
+	if maxLength > 0 && utf8.RuneCountInString(field.text) > maxLength {
+		anchor := 0
+		if len(merged) > 0 {
+			anchor = merged[0].start
+		}
+		from, to = snippetBounds(field.text, anchor, maxLength)
+	}
+
+	var builder strings.Builder
+
+	if from > 0 {
+		builder.WriteString("…")
+	}
+
+	position := from
+	for _, current := range merged {
+		if current.end <= from || current.start >= to {
+			continue
+		}
+
+		start, end := max(current.start, from), min(current.end, to)
+		builder.WriteString(html.EscapeString(field.text[position:start]))
+		builder.WriteString("<mark>")
+		builder.WriteString(html.EscapeString(field.text[start:end]))
+		builder.WriteString("</mark>")
+		position = end
+	}
+	builder.WriteString(html.EscapeString(field.text[position:to]))
+
+	if to < len(field.text) {
+		builder.WriteString("…")
+	}
+
+	return builder.String()
+}
+
+// Byte offsets of a snippet of at most the given number of characters,
+// starting a little before the anchor and at a word boundary if there is one close by.
+func snippetBounds(text string, anchor int, maxLength int) (int, int) {
+	from := anchor
+	for lead := 0; from > 0 && lead < maxLength/4; lead++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		from -= size
+	}
+
+	if from > 0 {
+		if space := strings.IndexFunc(text[from:anchor], unicode.IsSpace); space >= 0 {
+			from += space + 1
+		}
+	}
+
+	to := from
+	for count := 0; to < len(text) && count < maxLength; count++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		to += size
+	}
+
+	if to < len(text) {
+		if space := strings.LastIndexFunc(text[anchor:to], unicode.IsSpace); space > 0 {
+			to = anchor + space
+		}
+	}
+
+	return from, to
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -0,0 +1,582 @@
+package searchindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/helper"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"github.com/blevesearch/bleve/v2"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/de"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/en"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/es"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/it"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	"github.com/blevesearch/bleve/v2/mapping"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	htmlFormatter "github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Number of results of a search, and of notes indexed at once by a reindex.
+const (
+	MaxResults       = 100
+	reindexBatchSize = 500
+)
+
+// File in the index directory naming the generation in use, every reindex building a new one.
+const currentGenerationFile = "CURRENT"
+
+// Keys of the language and of the version of the mapping the index was built with, kept inside the index.
+var (
+	languageKey = []byte("language")
+	mappingKey  = []byte("mapping")
+)
+
+// Version of the mapping, increased whenever a field of the notes is added to it so that older indexes get rebuilt.
+const mappingVersion = "4"
+
+// Embedded Bleve index of the notes, searched instead of the store when configured.
+// The store stays the source of truth, the index can always be rebuilt from it.
+type Index struct {
+	config config.SearchConfig
+	store  database.NoteStore
+
+	mu         sync.RWMutex
+	index      bleve.Index
+	generation string
+	// Notes changed while a reindex copies the store, nil when no reindex is running.
+	changed map[string]bool
+
+	reindexMu sync.Mutex
+}
+
+// Index in use, nil when the store searches itself.
+var IndexObject *Index
+
+// Fields of a note in the index.
+type noteDocument struct {
+	Header       string    `json:"header"`
+	Notes_Data   string    `json:"notesData"`
+	User_Id      string    `json:"userId"`
+	Workspace_Id string    `json:"workspaceId"`
+	In_Workspace bool      `json:"inWorkspace"`
+	Access       []string  `json:"access"`
+	Sharable     bool      `json:"sharable"`
+	Tags         []string  `json:"tags"`
+	Notebook     string    `json:"notebookId"`
+	Created_At   time.Time `json:"createdAt"`
+	Updated_At   time.Time `json:"updatedAt"`
+}
+
+func documentOf(note *models.NoteData) *noteDocument {
+	document := &noteDocument{
+		Header:       stringValue(note.Header),
+		Notes_Data:   stringValue(note.Data),
+		User_Id:      stringValue(note.User_Id),
+		Workspace_Id: stringValue(note.Workspace_Id),
+		In_Workspace: note.Workspace_Id != nil,
+		Access:       []string{},
+		Sharable:     note.Sharable != nil && *note.Sharable,
+		Tags:         append([]string{}, note.Tags...),
+		Notebook:     stringValue(note.Notebook_Id),
+		Created_At:   note.Created_At,
+		Updated_At:   note.Updated_At,
+	}
+
+	for _, access := range note.Access {
+		document.Access = append(document.Access, access.User_Id)
+	}
+
+	return document
+}
+
+// Text is analyzed in the configured language, the ids only matched exactly.
+func newIndexMapping(language string) mapping.IndexMapping {
+	textField := bleve.NewTextFieldMapping()
+	textField.Analyzer = language
+	textField.Store = true
+	textField.IncludeTermVectors = true
+
+	keywordField := bleve.NewKeywordFieldMapping()
+	keywordField.Store = false
+
+	noteMapping := bleve.NewDocumentStaticMapping()
+	noteMapping.AddFieldMappingsAt("header", textField)
+	noteMapping.AddFieldMappingsAt("notesData", textField)
+	noteMapping.AddFieldMappingsAt("userId", keywordField)
+	noteMapping.AddFieldMappingsAt("access", keywordField)
+	noteMapping.AddFieldMappingsAt("tags", keywordField)
+	noteMapping.AddFieldMappingsAt("notebookId", keywordField)
+	noteMapping.AddFieldMappingsAt("workspaceId", keywordField)
+	noteMapping.AddFieldMappingsAt("sharable", bleve.NewBooleanFieldMapping())
+	noteMapping.AddFieldMappingsAt("inWorkspace", bleve.NewBooleanFieldMapping())
+	noteMapping.AddFieldMappingsAt("createdAt", bleve.NewDateTimeFieldMapping())
+	noteMapping.AddFieldMappingsAt("updatedAt", bleve.NewDateTimeFieldMapping())
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = noteMapping
+	indexMapping.DefaultAnalyzer = language
+
+	return indexMapping
+}
+
+// Opens the index in the configured directory, building it from the store
+// when there is none yet or it was built for another language.
+func Open(ctx context.Context, searchConfig config.SearchConfig, store database.NoteStore) (*Index, error) {
+	err := os.MkdirAll(searchConfig.Index_Path, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{config: searchConfig, store: store}
+
+	generation, err := os.ReadFile(filepath.Join(searchConfig.Index_Path, currentGenerationFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(generation) > 0 {
+		index.generation = strings.TrimSpace(string(generation))
+
+		index.index, err = bleve.Open(index.generationPath(index.generation))
+		if err != nil {
+			logger.Log.Printf("Error: Problem while opening the search index, rebuilding it.\n\tError: %s", err.Error())
+			index.index = nil
+		}
+	}
+
+	if index.index != nil {
+		language, err := index.index.GetInternal(languageKey)
+		if err != nil {
+			return nil, err
+		}
+
+		version, err := index.index.GetInternal(mappingKey)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case string(language) != searchConfig.Language:
+			logger.Log.Printf("Message: Search index was built for language: %s, rebuilding it for: %s.", language, searchConfig.Language)
+		case string(version) != mappingVersion:
+			logger.Log.Printf("Message: Search index was built with an older mapping, rebuilding it.")
+		default:
+			logger.Log.Printf("Message: Opened the search index: %s.", index.generationPath(index.generation))
+			return index, nil
+		}
+	}
+
+	_, err = index.Reindex(ctx)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+
+	return index, nil
+}
+
+func (index *Index) generationPath(generation string) string {
+	return filepath.Join(index.config.Index_Path, generation)
+}
+
+func (index *Index) Close() error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if index.index == nil {
+		return nil
+	}
+
+	return index.index.Close()
+}
+
+// Adds or replaces the note in the index.
+func (index *Index) IndexNote(note *models.NoteData) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	noteId := note.ID.Hex()
+
+	if index.changed != nil {
+		index.changed[noteId] = true
+	}
+
+	if index.index == nil {
+		return nil
+	}
+
+	return index.index.Index(noteId, documentOf(note))
+}
+
+func (index *Index) DeleteNote(noteId string) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if index.changed != nil {
+		index.changed[noteId] = true
+	}
+
+	if index.index == nil {
+		return nil
+	}
+
+	return index.index.Delete(noteId)
+}
+
+// Builds a new generation of the index from every note of the store and switches over to it,
+// returning the number of notes indexed. Searches keep using the old generation meanwhile,
+// and notes changed meanwhile are indexed again from the store before switching.
+func (index *Index) Reindex(ctx context.Context) (int, error) {
+	index.reindexMu.Lock()
+	defer index.reindexMu.Unlock()
+
+	index.mu.Lock()
+	index.changed = map[string]bool{}
+	index.mu.Unlock()
+
+	generation := fmt.Sprintf("index-%d", time.Now().UnixNano())
+
+	count, freshIndex, err := index.build(ctx, generation)
+	if err == nil {
+		err = index.switchTo(ctx, generation, freshIndex)
+	}
+
+	if err != nil {
+		if freshIndex != nil {
+			freshIndex.Close()
+		}
+		os.RemoveAll(index.generationPath(generation))
+
+		index.mu.Lock()
+		index.changed = nil
+		index.mu.Unlock()
+
+		logger.Log.Printf("Error: Problem while rebuilding the search index.\n\tError: %s", err.Error())
+		return 0, err
+	}
+
+	logger.Log.Printf("Message: Rebuilt the search index with: %d notes.", count)
+	return count, nil
+}
+
+func (index *Index) build(ctx context.Context, generation string) (int, bleve.Index, error) {
+	freshIndex, err := bleve.New(index.generationPath(generation), newIndexMapping(index.config.Language))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = freshIndex.SetInternal(languageKey, []byte(index.config.Language))
+	if err == nil {
+		err = freshIndex.SetInternal(mappingKey, []byte(mappingVersion))
+	}
+	if err != nil {
+		return 0, freshIndex, err
+	}
+
+	count := 0
+	afterId := ""
+
+	for {
+		notes, err := index.store.ListAllNotes(ctx, afterId, reindexBatchSize)
+		if err != nil {
+			return 0, freshIndex, err
+		}
+
+		batch := freshIndex.NewBatch()
+		for i := range notes {
+			err = batch.Index(notes[i].ID.Hex(), documentOf(&notes[i]))
+			if err != nil {
+				return 0, freshIndex, err
+			}
+		}
+
+		err = freshIndex.Batch(batch)
+		if err != nil {
+			return 0, freshIndex, err
+		}
+
+		count += len(notes)
+
+		if len(notes) < reindexBatchSize {
+			return count, freshIndex, nil
+		}
+		afterId = notes[len(notes)-1].ID.Hex()
+	}
+}
+
+// Catches the fresh index up with the notes changed while it was built, and puts it in use.
+func (index *Index) switchTo(ctx context.Context, generation string, freshIndex bleve.Index) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for noteId := range index.changed {
+		note, err := index.store.GetNoteById(ctx, noteId)
+		if err == database.ErrNotFound {
+			err = freshIndex.Delete(noteId)
+		} else if err == nil {
+			err = freshIndex.Index(noteId, documentOf(note))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write the name of the generation atomically, so that a crash leaves either the old or the new one.
+	temporaryFile := filepath.Join(index.config.Index_Path, currentGenerationFile+".tmp")
+
+	err := os.WriteFile(temporaryFile, []byte(generation), 0o644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(temporaryFile, filepath.Join(index.config.Index_Path, currentGenerationFile))
+	if err != nil {
+		return err
+	}
+
+	oldIndex, oldGeneration := index.index, index.generation
+
+	index.index, index.generation = freshIndex, generation
+	index.changed = nil
+
+	if oldIndex != nil {
+		oldIndex.Close()
+	}
+	if oldGeneration != "" {
+		os.RemoveAll(index.generationPath(oldGeneration))
+	}
+
+	return nil
+}
+
+// Number of notes in each facet value of the results.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets of the notes found, counted after the notes the user cannot view are left out.
+type Facets struct {
+	// Owners with the most notes found.
+	Owner []FacetCount `json:"owner"`
+	// Notes found updated in the past day, week, month and year, and before.
+	Updated []FacetCount `json:"updated"`
+	// Tags on the most notes found.
+	Tag []FacetCount `json:"tag"`
+}
+
+type Response struct {
+	Data   []search.Result `json:"data"`
+	Facets Facets          `json:"facets"`
+}
+
+// Periods of the updated facet.
+var updatedPeriods = []struct {
+	name   string
+	length time.Duration
+}{
+	{"day", 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"year", 365 * 24 * time.Hour},
+}
+
+// Searches the notes viewable by the user which pass the filter, best matches first, with the facets of the results.
+func (index *Index) Search(ctx context.Context, userId string, parsedQuery *search.Query, filter *database.NoteFilter) (*Response, error) {
+	matchQuery := search.Build[query.Query](parsedQuery, &queryBuilder{fuzziness: index.config.Fuzziness})
+
+	var scopeQuery query.Query
+
+	if filter.Workspace_Id != "" {
+		workspaceQuery := bleve.NewTermQuery(filter.Workspace_Id)
+		workspaceQuery.SetField("workspaceId")
+		scopeQuery = workspaceQuery
+	} else {
+		// The notes of workspaces belong to them, not to their authors.
+		userQuery := bleve.NewTermQuery(userId)
+		userQuery.SetField("userId")
+
+		outsideWorkspaceQuery := bleve.NewBoolFieldQuery(false)
+		outsideWorkspaceQuery.SetField("inWorkspace")
+
+		ownerQuery := bleve.NewConjunctionQuery(userQuery, outsideWorkspaceQuery)
+
+		accessQuery := bleve.NewTermQuery(userId)
+		accessQuery.SetField("access")
+
+		sharableQuery := bleve.NewBoolFieldQuery(true)
+		sharableQuery.SetField("sharable")
+
+		scopeQuery = bleve.NewDisjunctionQuery(ownerQuery, accessQuery, sharableQuery)
+	}
+
+	searchQuery := bleve.NewConjunctionQuery(matchQuery, scopeQuery)
+
+	for _, tag := range filter.Tags {
+		tagQuery := bleve.NewTermQuery(tag)
+		tagQuery.SetField("tags")
+		searchQuery.AddQuery(tagQuery)
+	}
+
+	if filter.Notebook_Ids != nil {
+		notebookQuery := bleve.NewDisjunctionQuery()
+		for _, notebookId := range filter.Notebook_Ids {
+			termQuery := bleve.NewTermQuery(notebookId)
+			termQuery.SetField("notebookId")
+			notebookQuery.AddQuery(termQuery)
+		}
+		searchQuery.AddQuery(notebookQuery)
+	}
+
+	request := bleve.NewSearchRequestOptions(searchQuery, MaxResults, 0, false)
+
+	request.Highlight = bleve.NewHighlightWithStyle(htmlFormatter.Name)
+	request.Highlight.AddField("header")
+	request.Highlight.AddField("notesData")
+
+	index.mu.RLock()
+	if index.index == nil {
+		index.mu.RUnlock()
+		return nil, errors.New("search index is being built")
+	}
+	result, err := index.index.SearchInContext(ctx, request)
+	index.mu.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{Data: []search.Result{}}
+
+	for _, hit := range result.Hits {
+		// The store has the current version of the note, the index may lag behind a little.
+		note, err := index.store.GetNoteById(ctx, hit.ID)
+		if err == database.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filter.Workspace_Id != "" && stringValue(note.Workspace_Id) != filter.Workspace_Id {
+			continue
+		}
+
+		viewable, err := helper.CanViewNote(ctx, note, userId)
+		if err != nil {
+			return nil, err
+		}
+		if !viewable {
+			continue
+		}
+
+		response.Data = append(response.Data, search.Result{
+			Note:  *note,
+			Score: math.Round(hit.Score*1000) / 1000,
+			Highlights: search.Highlights{
+				Header:     fragmentsOf(hit, "header", stringValue(note.Header)),
+				Notes_Data: fragmentsOf(hit, "notesData", stringValue(note.Data)),
+			},
+		})
+	}
+
+	// Count the results left, so that the facets never tell about notes the user cannot view.
+	response.Facets = facetsOf(response.Data, time.Now())
+
+	return response, nil
+}
+
+func facetsOf(results []search.Result, now time.Time) Facets {
+	ownerCounts, tagCounts, updatedCounts := map[string]int{}, map[string]int{}, map[string]int{}
+
+	for _, result := range results {
+		ownerCounts[stringValue(result.Note.User_Id)]++
+
+		for _, tag := range result.Note.Tags {
+			tagCounts[tag]++
+		}
+
+		// The periods overlap, a note updated in the past day was updated in the past week too.
+		age := now.Sub(result.Note.Updated_At)
+		for _, period := range updatedPeriods {
+			if age <= period.length {
+				updatedCounts[period.name]++
+			}
+		}
+		if age > updatedPeriods[len(updatedPeriods)-1].length {
+			updatedCounts["older"]++
+		}
+	}
+
+	facets := Facets{
+		Owner:   topFacetCounts(ownerCounts, 10),
+		Updated: []FacetCount{},
+		Tag:     topFacetCounts(tagCounts, 20),
+	}
+
+	for _, period := range updatedPeriods {
+		facets.Updated = append(facets.Updated, FacetCount{Value: period.name, Count: updatedCounts[period.name]})
+	}
+	facets.Updated = append(facets.Updated, FacetCount{Value: "older", Count: updatedCounts["older"]})
+
+	return facets
+}
+
+// The values with the most notes, ties in alphabetical order.
+func topFacetCounts(counts map[string]int, size int) []FacetCount {
+	facetCounts := []FacetCount{}
+	for value, count := range counts {
+		facetCounts = append(facetCounts, FacetCount{Value: value, Count: count})
+	}
+
+	sort.Slice(facetCounts, func(i, j int) bool {
+		if facetCounts[i].Count != facetCounts[j].Count {
+			return facetCounts[i].Count > facetCounts[j].Count
+		}
+		return facetCounts[i].Value < facetCounts[j].Value
+	})
+
+	if len(facetCounts) > size {
+		facetCounts = facetCounts[:size]
+	}
+
+	return facetCounts
+}
+
+// Highlighted fragments of the field, or the start of it when nothing in it matched.
+func fragmentsOf(hit *bleveSearch.DocumentMatch, field string, text string) string {
+	if fragments := hit.Fragments[field]; len(fragments) > 0 {
+		return strings.Join(fragments, " ")
+	}
+
+	if utf8.RuneCountInString(text) <= search.SnippetLength {
+		return html.EscapeString(text)
+	}
+
+	return html.EscapeString(string([]rune(text)[:search.SnippetLength])) + "…"
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
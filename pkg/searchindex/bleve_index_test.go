@@ -0,0 +1,136 @@
+package searchindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/config"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stores a note of the user, with the notes data, the tags and the users it is shared with as viewers.
+func createNote(t *testing.T, store database.NoteStore, userId string, header string, data string, tags []string, viewers ...string) *models.NoteData {
+	t.Helper()
+
+	note := &models.NoteData{
+		ID:         primitive.NewObjectID(),
+		Header:     &header,
+		Data:       &data,
+		User_Id:    &userId,
+		Tags:       tags,
+		Created_At: time.Now(),
+		Updated_At: time.Now(),
+	}
+	uniqueHeader := database.UniqueHeader(userId, nil, header)
+	note.Unique_Header = &uniqueHeader
+
+	for _, viewer := range viewers {
+		note.Access = append(note.Access, models.NoteAccess{User_Id: viewer, Role: models.ViewerRole})
+	}
+
+	err := store.CreateNote(context.Background(), note)
+	if err != nil {
+		t.Fatalf("create note %q: %s", header, err)
+	}
+
+	return note
+}
+
+// Opens an index built from the store in a fresh directory.
+func openIndex(t *testing.T, store database.NoteStore) *Index {
+	t.Helper()
+
+	index, err := Open(context.Background(), config.SearchConfig{Engine: "bleve", Index_Path: t.TempDir(), Language: "en", Fuzziness: 1}, store)
+	if err != nil {
+		t.Fatalf("open the index: %s", err)
+	}
+	t.Cleanup(func() { index.Close() })
+
+	return index
+}
+
+func searchHeaders(t *testing.T, index *Index, userId string, text string) (map[string]bool, *Response) {
+	t.Helper()
+
+	parsedQuery, err := search.Parse(text)
+	if err != nil {
+		t.Fatalf("parse %q: %s", text, err)
+	}
+
+	response, err := index.Search(context.Background(), userId, parsedQuery, &database.NoteFilter{})
+	if err != nil {
+		t.Fatalf("search %q: %s", text, err)
+	}
+
+	headers := map[string]bool{}
+	for _, result := range response.Data {
+		headers[*result.Note.Header] = true
+	}
+
+	return headers, response
+}
+
+func TestSearchPhraseEndingInPrefix(t *testing.T) {
+	store := database.NewMemoryStore()
+	createNote(t, store, "alice", "first", "pay the bills", nil)
+	createNote(t, store, "alice", "second", "bills to pay", nil)
+	createNote(t, store, "alice", "third", "pay bills", nil)
+	createNote(t, store, "alice", "fourth", "pay the rent", nil)
+
+	index := openIndex(t, store)
+
+	// The prefix has to end the phrase, the stop word in between keeping its place.
+	headers, _ := searchHeaders(t, index, "alice", `"pay the bi*"`)
+	if len(headers) != 1 || !headers["first"] {
+		t.Errorf("search for a phrase ending in a prefix found %v, want only first", headers)
+	}
+
+	headers, _ = searchHeaders(t, index, "alice", `"pay the xy*"`)
+	if len(headers) != 0 {
+		t.Errorf("search for a phrase ending in a prefix no word starts with found %v, want nothing", headers)
+	}
+}
+
+func TestSearchFacetsLeaveOutNotesNotViewable(t *testing.T) {
+	store := database.NewMemoryStore()
+	createNote(t, store, "bob", "bob plans", "plans for the trip", []string{"travel"})
+	sharedNote := createNote(t, store, "alice", "alice plans", "plans for the launch", []string{"work"}, "bob")
+
+	index := openIndex(t, store)
+
+	_, response := searchHeaders(t, index, "bob", "plans")
+	if len(response.Data) != 2 {
+		t.Fatalf("search of bob found %d notes, want 2", len(response.Data))
+	}
+
+	// Alice stops sharing the note, without the index hearing of it yet.
+	_, err := store.SetNoteAccess(context.Background(), sharedNote.ID.Hex(), sharedNote.Version, []models.NoteAccess{})
+	if err != nil {
+		t.Fatalf("stop sharing the note: %s", err)
+	}
+
+	headers, response := searchHeaders(t, index, "bob", "plans")
+	if len(headers) != 1 || !headers["bob plans"] {
+		t.Fatalf("search of bob found %v, want only bob plans", headers)
+	}
+
+	if len(response.Facets.Owner) != 1 || response.Facets.Owner[0] != (FacetCount{Value: "bob", Count: 1}) {
+		t.Errorf("owner facet = %v, want only bob once", response.Facets.Owner)
+	}
+	if len(response.Facets.Tag) != 1 || response.Facets.Tag[0] != (FacetCount{Value: "travel", Count: 1}) {
+		t.Errorf("tag facet = %v, want only travel once", response.Facets.Tag)
+	}
+	for _, updated := range response.Facets.Updated {
+		want := 1
+		if updated.Value == "older" {
+			want = 0
+		}
+		if updated.Count != want {
+			t.Errorf("updated facet %s = %d, want %d", updated.Value, updated.Count, want)
+		}
+	}
+}
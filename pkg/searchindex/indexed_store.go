@@ -0,0 +1,123 @@
+package searchindex
+
+import (
+	"context"
+	"time"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/logger"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/database"
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/models"
+)
+
+// Store keeping the search index in sync with every change of a note made through it.
+// A change which fails to reach the index is only logged, a reindex repairs the index.
+type IndexedStore struct {
+	database.Store
+	index *Index
+}
+
+func NewIndexedStore(store database.Store, index *Index) *IndexedStore {
+	return &IndexedStore{Store: store, index: index}
+}
+
+func (store *IndexedStore) CreateNote(ctx context.Context, note *models.NoteData) error {
+	err := store.Store.CreateNote(ctx, note)
+	if err != nil {
+		return err
+	}
+
+	store.indexNote(note)
+	return nil
+}
+
+func (store *IndexedStore) UpdateNote(ctx context.Context, noteId string, version int64, update *database.NoteUpdate) (*models.NoteData, error) {
+	updatedNote, err := store.Store.UpdateNote(ctx, noteId, version, update)
+	if err != nil {
+		return nil, err
+	}
+
+	store.indexNote(updatedNote)
+	return updatedNote, nil
+}
+
+func (store *IndexedStore) SetNoteAccess(ctx context.Context, noteId string, version int64, accessList []models.NoteAccess) (*models.NoteData, error) {
+	updatedNote, err := store.Store.SetNoteAccess(ctx, noteId, version, accessList)
+	if err != nil {
+		return nil, err
+	}
+
+	store.indexNote(updatedNote)
+	return updatedNote, nil
+}
+
+func (store *IndexedStore) ReplaceTags(ctx context.Context, userId string, oldTags []string, newTag string) ([]models.NoteData, error) {
+	changedNotes, err := store.Store.ReplaceTags(ctx, userId, oldTags, newTag)
+
+	// Notes changed before a failure are indexed as well.
+	for i := range changedNotes {
+		store.indexNote(&changedNotes[i])
+	}
+
+	return changedNotes, err
+}
+
+func (store *IndexedStore) DeleteNote(ctx context.Context, noteId string, version int64) error {
+	err := store.Store.DeleteNote(ctx, noteId, version)
+	if err != nil {
+		return err
+	}
+
+	store.deleteNote(noteId)
+	return nil
+}
+
+func (store *IndexedStore) TrashNote(ctx context.Context, noteId string, version int64, deletedAt time.Time) (*models.NoteData, error) {
+	trashedNote, err := store.Store.TrashNote(ctx, noteId, version, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	store.deleteNote(noteId)
+	return trashedNote, nil
+}
+
+func (store *IndexedStore) RestoreNote(ctx context.Context, noteId string, version int64, uniqueHeader string, notebookId *string) (*models.NoteData, error) {
+	restoredNote, err := store.Store.RestoreNote(ctx, noteId, version, uniqueHeader, notebookId)
+	if err != nil {
+		return nil, err
+	}
+
+	store.indexNote(restoredNote)
+	return restoredNote, nil
+}
+
+func (store *IndexedStore) DeleteNotebook(ctx context.Context, notebookId string, cascade bool) ([]models.NoteData, error) {
+	changedNotes, err := store.Store.DeleteNotebook(ctx, notebookId, cascade)
+
+	// The notes moved to the trash leave the index.
+	for i := range changedNotes {
+		store.indexNote(&changedNotes[i])
+	}
+
+	return changedNotes, err
+}
+
+// Indexes the note, or leaves it out of the index while it is in the trash.
+func (store *IndexedStore) indexNote(note *models.NoteData) {
+	if note.Deleted_At != nil {
+		store.deleteNote(note.ID.Hex())
+		return
+	}
+
+	err := store.index.IndexNote(note)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while indexing note id: %s for search.\n\tError: %s", note.ID.Hex(), err.Error())
+	}
+}
+
+func (store *IndexedStore) deleteNote(noteId string) {
+	err := store.index.DeleteNote(noteId)
+	if err != nil {
+		logger.Log.Printf("Error: Problem while deleting note id: %s from the search index.\n\tError: %s", noteId, err.Error())
+	}
+}
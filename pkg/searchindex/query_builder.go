@@ -0,0 +1,144 @@
+package searchindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/IshanSaha05/jwt_authentication_rest_api/pkg/search"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// A match in the header weighs three times as much as one in the notes data, like with the store search.
+const headerBoost = 3.0
+
+// Number of words of the field a prefix at the end of a phrase is expanded to at most.
+const maxPrefixTerms = 256
+
+// Translates a parsed search query to a Bleve query over the header and the notes data.
+type queryBuilder struct {
+	fuzziness int
+}
+
+func (builder *queryBuilder) Phrase(phrase search.Phrase) query.Query {
+	return bleve.NewDisjunctionQuery(
+		builder.fieldQuery(phrase, "header", headerBoost),
+		builder.fieldQuery(phrase, "notesData", 1),
+	)
+}
+
+func (builder *queryBuilder) And(parts []query.Query) query.Query {
+	return bleve.NewConjunctionQuery(parts...)
+}
+
+func (builder *queryBuilder) Or(parts []query.Query) query.Query {
+	return bleve.NewDisjunctionQuery(parts...)
+}
+
+func (builder *queryBuilder) Not(part query.Query) query.Query {
+	notQuery := bleve.NewBooleanQuery()
+	notQuery.AddMustNot(part)
+	return notQuery
+}
+
+// Single words are matched with typos and through the stemmer of the language, phrases exactly.
+// A prefix is matched against the words as the index keeps them, stemmed.
+func (builder *queryBuilder) fieldQuery(phrase search.Phrase, field string, boost float64) query.Query {
+	words := phrase.Words
+
+	switch {
+	case phrase.Prefix && len(words) == 1:
+		prefixQuery := bleve.NewPrefixQuery(words[0])
+		prefixQuery.SetField(field)
+		prefixQuery.SetBoost(boost)
+		return prefixQuery
+	case phrase.Prefix:
+		return &phrasePrefixQuery{words: words[:len(words)-1], prefix: words[len(words)-1], field: field, boost: boost}
+	case len(words) == 1:
+		matchQuery := bleve.NewMatchQuery(words[0])
+		matchQuery.SetField(field)
+		matchQuery.SetBoost(boost)
+		matchQuery.SetFuzziness(builder.fuzzinessFor(words[0]))
+		return matchQuery
+	}
+
+	phraseQuery := bleve.NewMatchPhraseQuery(strings.Join(words, " "))
+	phraseQuery.SetField(field)
+	phraseQuery.SetBoost(boost)
+	return phraseQuery
+}
+
+// Short words allow fewer typos, as a typo in them is more likely to match an unrelated word.
+func (builder *queryBuilder) fuzzinessFor(word string) int {
+	switch length := utf8.RuneCountInString(word); {
+	case length < 4:
+		return 0
+	case length < 8:
+		return min(builder.fuzziness, 1)
+	}
+
+	return builder.fuzziness
+}
+
+// Phrase whose last word only has to start the word of the field, like "pay the bi*".
+// Bleve has no such query, so the prefix is expanded to the words of the field starting with it
+// and the phrase matches any of them at its end.
+type phrasePrefixQuery struct {
+	words  []string
+	prefix string
+	field  string
+	boost  float64
+}
+
+func (phraseQuery *phrasePrefixQuery) Searcher(ctx context.Context, reader index.IndexReader, indexMapping mapping.IndexMapping, options bleveSearch.SearcherOptions) (bleveSearch.Searcher, error) {
+	analyzer := indexMapping.AnalyzerNamed(indexMapping.AnalyzerNameForPath(phraseQuery.field))
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer for the field: %s", phraseQuery.field)
+	}
+
+	// The words before the prefix are analyzed like the field was, a stop word left out keeping its place empty.
+	// The prefix takes the place after the last word.
+	terms := make([][]string, len(phraseQuery.words)+1)
+	for _, token := range analyzer.Analyze([]byte(strings.Join(phraseQuery.words, " "))) {
+		for len(terms) <= token.Position {
+			terms = append(terms, nil)
+		}
+		terms[token.Position-1] = append(terms[token.Position-1], string(token.Term))
+	}
+
+	dictionary, err := reader.FieldDictPrefix(phraseQuery.field, []byte(phraseQuery.prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer dictionary.Close()
+
+	var prefixTerms []string
+	for len(prefixTerms) < maxPrefixTerms {
+		entry, err := dictionary.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+		prefixTerms = append(prefixTerms, entry.Term)
+	}
+
+	if len(prefixTerms) == 0 {
+		return bleve.NewMatchNoneQuery().Searcher(ctx, reader, indexMapping, options)
+	}
+
+	terms[len(terms)-1] = prefixTerms
+	for len(terms[0]) == 0 {
+		terms = terms[1:]
+	}
+
+	multiPhraseQuery := query.NewMultiPhraseQuery(terms, phraseQuery.field)
+	multiPhraseQuery.SetBoost(phraseQuery.boost)
+	return multiPhraseQuery.Searcher(ctx, reader, indexMapping, options)
+}